@@ -0,0 +1,217 @@
+// Package auth authenticates MCP clients connecting to the SSE and Streamable HTTP listener.
+// Clients are accepted with a static bearer token, an API key, a verified TLS client certificate
+// or a JWT signed by a key from a JWKS. The verified Identity is added to the request context.
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/danishjsheikh/swagger-mcp/app/secrets"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultApiKeyHeader is the header carrying a client API key when none is configured.
+const DefaultApiKeyHeader = "X-MCP-API-Key"
+
+// Authentication methods reported in Identity.Method.
+const (
+	MethodBearer     = "bearer"
+	MethodApiKey     = "apiKey"
+	MethodClientCert = "mtls"
+	MethodJWT        = "jwt"
+)
+
+// errUnauthorized is returned for every failed authentication so clients learn nothing about the reason.
+var errUnauthorized = errors.New("unauthorized")
+
+// Identity is the verified identity of the client making a request.
+type Identity struct {
+	Subject string                 // Name of the token or API key, certificate common name, or JWT sub claim
+	Method  string                 // How the client authenticated
+	Claims  map[string]interface{} // JWT claims, for per-user authorization
+}
+
+type contextKey struct{}
+
+// WithIdentity returns a copy of ctx carrying id.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the Identity of the client that made the request ctx belongs to.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(Identity)
+	return id, ok
+}
+
+// Authenticator checks the credentials of incoming requests.
+type Authenticator struct {
+	cfg  models.InboundAuthConfig
+	keys *keySet
+}
+
+// New creates an Authenticator. A local JWKS file is loaded immediately so that mistakes are reported at startup.
+func New(cfg models.InboundAuthConfig) (*Authenticator, error) {
+	if cfg.ApiKeyHeader == "" {
+		cfg.ApiKeyHeader = DefaultApiKeyHeader
+	}
+	a := &Authenticator{cfg: cfg}
+	if cfg.JwksFile != "" || cfg.Issuer != "" {
+		a.keys = newKeySet(cfg.JwksFile, cfg.Issuer)
+		if cfg.JwksFile != "" {
+			if _, err := a.keys.load(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return a, nil
+}
+
+// Enabled reports whether any authentication method is configured.
+func (a *Authenticator) Enabled() bool {
+	return len(a.cfg.Tokens) > 0 || len(a.cfg.ApiKeys) > 0 || a.cfg.ClientCerts || a.keys != nil
+}
+
+// Authenticate returns the Identity of the client making r, trying client certificates, API keys and bearer tokens in turn.
+func (a *Authenticator) Authenticate(r *http.Request) (Identity, error) {
+	if a.cfg.ClientCerts && r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		cert := r.TLS.VerifiedChains[0][0]
+		return Identity{Subject: cert.Subject.CommonName, Method: MethodClientCert}, nil
+	}
+	if key := r.Header.Get(a.cfg.ApiKeyHeader); key != "" && len(a.cfg.ApiKeys) > 0 {
+		name, err := match(key, a.cfg.ApiKeys)
+		if err != nil {
+			return Identity{}, err
+		}
+		return Identity{Subject: name, Method: MethodApiKey}, nil
+	}
+	token, ok := bearerToken(r.Header.Get("Authorization"))
+	if !ok {
+		return Identity{}, errUnauthorized
+	}
+	if len(a.cfg.Tokens) > 0 {
+		name, err := match(token, a.cfg.Tokens)
+		if err == nil {
+			return Identity{Subject: name, Method: MethodBearer}, nil
+		}
+		if !errors.Is(err, errUnauthorized) || a.keys == nil {
+			return Identity{}, err
+		}
+	}
+	if a.keys != nil {
+		return a.verifyJWT(token)
+	}
+	return Identity{}, errUnauthorized
+}
+
+// Middleware rejects requests that fail authentication with 401 and adds the Identity of the others to their context.
+// The credential headers used to authenticate are removed so they are never forwarded to API requests.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	if !a.Enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := a.Authenticate(r)
+		if err != nil {
+			if !errors.Is(err, errUnauthorized) {
+				log.Printf("Authentication error for %s: %v", r.RemoteAddr, err)
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="swagger-mcp"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		r = r.Clone(WithIdentity(r.Context(), id))
+		switch id.Method {
+		case MethodApiKey:
+			r.Header.Del(a.cfg.ApiKeyHeader)
+		case MethodBearer, MethodJWT:
+			r.Header.Del("Authorization")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// match returns the name of the credential equal to value. Credentials are resolved on each call so that
+// rotated file: and exec: secrets take effect, and compared in constant time.
+func match(value string, credentials map[string]string) (string, error) {
+	names := make([]string, 0, len(credentials))
+	for name := range credentials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	found := ""
+	for _, name := range names {
+		secret, err := secrets.Resolve(credentials[name])
+		if err != nil {
+			return "", fmt.Errorf("credential %s: %v", name, err)
+		}
+		if secret != "" && subtle.ConstantTimeCompare([]byte(value), []byte(secret)) == 1 && found == "" {
+			found = name
+		}
+	}
+	if found == "" {
+		return "", errUnauthorized
+	}
+	return found, nil
+}
+
+// bearerToken extracts the token of an Authorization: Bearer header.
+func bearerToken(header string) (string, bool) {
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return "", false
+	}
+	token := strings.TrimSpace(header[7:])
+	return token, token != ""
+}
+
+// verifyJWT checks the signature, expiry, issuer and audience of a JWT.
+func (a *Authenticator) verifyJWT(token string) (Identity, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithExpirationRequired(),
+	}
+	if a.cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.cfg.Issuer))
+	}
+	if a.cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(a.cfg.Audience))
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return a.keys.key(kid)
+	}, opts...)
+	if err != nil {
+		if errors.Is(err, errKeySetUnavailable) {
+			return Identity{}, err
+		}
+		return Identity{}, errUnauthorized
+	}
+	subject, _ := claims.GetSubject()
+	return Identity{Subject: subject, Method: MethodJWT, Claims: claims}, nil
+}
+
+// ParseCredentials parses a name=value,name=value list of client credentials.
+func ParseCredentials(value string) (map[string]string, error) {
+	credentials := map[string]string{}
+	for i, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		// The entry may be a bare secret, so errors only give its position
+		name, secret, found := strings.Cut(pair, "=")
+		if !found || strings.TrimSpace(name) == "" || secret == "" {
+			return nil, fmt.Errorf("invalid credential #%d: must be name=value", i+1)
+		}
+		credentials[strings.TrimSpace(name)] = secret
+	}
+	return credentials, nil
+}
@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func rsaJWKS(t *testing.T, kid string, key *rsa.PrivateKey) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA", "kid": kid, "use": "sig",
+		"n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func request(header, value string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/sse", nil)
+	if header != "" {
+		r.Header.Set(header, value)
+	}
+	return r
+}
+
+func TestAuthenticate_StaticCredentials(t *testing.T) {
+	t.Setenv("AUTH_TEST_TOKEN", "bob-token")
+	a, err := New(models.InboundAuthConfig{
+		Tokens:  map[string]string{"alice": "alice-token", "bob": "env:AUTH_TEST_TOKEN"},
+		ApiKeys: map[string]string{"ci": "ci-key"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		header, value, subject, method string
+	}{
+		{"Authorization", "Bearer alice-token", "alice", MethodBearer},
+		{"Authorization", "bearer bob-token", "bob", MethodBearer},
+		{DefaultApiKeyHeader, "ci-key", "ci", MethodApiKey},
+		{"Authorization", "Bearer wrong", "", ""},
+		{DefaultApiKeyHeader, "alice-token", "", ""},
+		{"", "", "", ""},
+	}
+	for _, c := range cases {
+		id, err := a.Authenticate(request(c.header, c.value))
+		if c.subject == "" {
+			if err == nil {
+				t.Errorf("%s %q: expected rejection, got %+v", c.header, c.value, id)
+			}
+			continue
+		}
+		if err != nil || id.Subject != c.subject || id.Method != c.method {
+			t.Errorf("%s %q: got %+v, %v", c.header, c.value, id, err)
+		}
+	}
+}
+
+func TestAuthenticate_ClientCert(t *testing.T) {
+	a, _ := New(models.InboundAuthConfig{ClientCerts: true})
+	r := request("", "")
+	if _, err := a.Authenticate(r); err == nil {
+		t.Error("expected rejection without TLS")
+	}
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "build-agent"}}}}}
+	if id, err := a.Authenticate(r); err != nil || id.Subject != "build-agent" || id.Method != MethodClientCert {
+		t.Errorf("got %+v, %v", id, err)
+	}
+}
+
+func TestAuthenticate_JWKSFile(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, rsaJWKS(t, "k1", key), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	a, err := New(models.InboundAuthConfig{JwksFile: path, Issuer: "https://idp.example.com", Audience: "swagger-mcp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := jwt.MapClaims{"sub": "alice", "iss": "https://idp.example.com", "aud": "swagger-mcp", "exp": time.Now().Add(time.Hour).Unix(), "role": "admin"}
+
+	id, err := a.Authenticate(request("Authorization", "Bearer "+sign(t, jwt.SigningMethodRS256, "k1", key, valid)))
+	if err != nil || id.Subject != "alice" || id.Method != MethodJWT || id.Claims["role"] != "admin" {
+		t.Fatalf("got %+v, %v", id, err)
+	}
+
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rejected := map[string]string{
+		"wrong audience": sign(t, jwt.SigningMethodRS256, "k1", key, jwt.MapClaims{"sub": "alice", "iss": "https://idp.example.com", "aud": "other", "exp": time.Now().Add(time.Hour).Unix()}),
+		"expired":        sign(t, jwt.SigningMethodRS256, "k1", key, jwt.MapClaims{"sub": "alice", "iss": "https://idp.example.com", "aud": "swagger-mcp", "exp": time.Now().Add(-time.Hour).Unix()}),
+		"no expiry":      sign(t, jwt.SigningMethodRS256, "k1", key, jwt.MapClaims{"sub": "alice", "iss": "https://idp.example.com", "aud": "swagger-mcp"}),
+		"wrong key":      sign(t, jwt.SigningMethodRS256, "k1", other, valid),
+		"unknown kid":    sign(t, jwt.SigningMethodRS256, "k2", key, valid),
+		"hmac":           sign(t, jwt.SigningMethodHS256, "k1", []byte("secret"), valid),
+	}
+	for name, token := range rejected {
+		if _, err := a.Authenticate(request("Authorization", "Bearer "+token)); err == nil {
+			t.Errorf("%s: expected rejection", name)
+		}
+	}
+
+	// Keys are re-read when the file changes
+	if err := os.WriteFile(path, rsaJWKS(t, "k1", other), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(path, future, future)
+	if _, err := a.Authenticate(request("Authorization", "Bearer "+sign(t, jwt.SigningMethodRS256, "k1", other, valid))); err != nil {
+		t.Errorf("expected rotated key to be accepted: %v", err)
+	}
+}
+
+func TestAuthenticate_IssuerDiscovery(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var issuer string
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{"issuer": issuer, "jwks_uri": issuer + "/keys"})
+		case "/keys":
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+				"kty": "EC", "kid": "ec1", "crv": "P-256", "x": b64(key.X.FillBytes(make([]byte, 32))), "y": b64(key.Y.FillBytes(make([]byte, 32))),
+			}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer idp.Close()
+	issuer = idp.URL
+
+	a, err := New(models.InboundAuthConfig{Issuer: issuer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := sign(t, jwt.SigningMethodES256, "ec1", key, jwt.MapClaims{"sub": "svc", "iss": issuer, "exp": time.Now().Add(time.Hour).Unix()})
+	if id, err := a.Authenticate(request("Authorization", "Bearer "+token)); err != nil || id.Subject != "svc" {
+		t.Errorf("got %+v, %v", id, err)
+	}
+	token = sign(t, jwt.SigningMethodES256, "ec1", key, jwt.MapClaims{"sub": "svc", "iss": "https://evil.example.com", "exp": time.Now().Add(time.Hour).Unix()})
+	if _, err := a.Authenticate(request("Authorization", "Bearer "+token)); err == nil {
+		t.Error("expected rejection of token from another issuer")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	a, _ := New(models.InboundAuthConfig{Tokens: map[string]string{"alice": "alice-token"}})
+	var got Identity
+	var forwarded string
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = FromContext(r.Context())
+		forwarded = r.Header.Get("Authorization")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, request("Authorization", "Bearer nope"))
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("expected 401 with challenge, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, request("Authorization", "Bearer alice-token"))
+	if w.Code != http.StatusOK || got.Subject != "alice" {
+		t.Errorf("expected alice to be let through, got %d %+v", w.Code, got)
+	}
+	if forwarded != "" {
+		t.Errorf("expected credential header to be removed, got %q", forwarded)
+	}
+}
+
+func TestParseCredentials(t *testing.T) {
+	creds, err := ParseCredentials("alice=abc==, bob=env:BOB_TOKEN")
+	if err != nil || creds["alice"] != "abc==" || creds["bob"] != "env:BOB_TOKEN" {
+		t.Errorf("got %+v, %v", creds, err)
+	}
+	_, err = ParseCredentials("alice=abc,rawsecret")
+	if err == nil || err.Error() != "invalid credential #2: must be name=value" {
+		t.Errorf("expected error not naming the value, got %v", err)
+	}
+}
@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// jwksMaxAge is how long keys discovered from an issuer are used before they are fetched again.
+	jwksMaxAge = time.Hour
+	// jwksMinRefresh limits how often an unknown key id makes the issuer's keys be fetched again.
+	jwksMinRefresh = time.Minute
+)
+
+// errKeySetUnavailable is returned when the signing keys cannot be loaded.
+var errKeySetUnavailable = errors.New("JWKS unavailable")
+
+// keySet holds the public keys JWTs are verified with, read from a local file or discovered from an issuer.
+type keySet struct {
+	file   string
+	issuer string
+	client *http.Client
+
+	mu      sync.Mutex
+	keys    map[string]crypto.PublicKey
+	modTime time.Time // of file when keys were read
+	fetched time.Time // when keys were fetched from issuer
+}
+
+func newKeySet(file, issuer string) *keySet {
+	return &keySet{
+		file:   strings.TrimPrefix(file, "file://"),
+		issuer: strings.TrimSuffix(issuer, "/"),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// key returns the key with the given id. A key set with a single key also matches tokens without a key id.
+func (k *keySet) key(kid string) (crypto.PublicKey, error) {
+	keys, err := k.load()
+	if err != nil {
+		return nil, err
+	}
+	if key, ok := lookup(keys, kid); ok {
+		return key, nil
+	}
+	// The issuer may have rotated its keys
+	if k.file == "" {
+		k.mu.Lock()
+		stale := time.Since(k.fetched) > jwksMinRefresh
+		if stale {
+			k.keys = nil
+		}
+		k.mu.Unlock()
+		if stale {
+			if keys, err = k.load(); err != nil {
+				return nil, err
+			}
+			if key, ok := lookup(keys, kid); ok {
+				return key, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+func lookup(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, bool) {
+	if key, ok := keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// load returns the current keys, re-reading the file when it changed or fetching the issuer's keys when they are too old.
+func (k *keySet) load() (map[string]crypto.PublicKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.file != "" {
+		info, err := os.Stat(k.file)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errKeySetUnavailable, err)
+		}
+		if k.keys != nil && info.ModTime().Equal(k.modTime) {
+			return k.keys, nil
+		}
+		data, err := os.ReadFile(k.file)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errKeySetUnavailable, err)
+		}
+		keys, err := parseJWKS(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", errKeySetUnavailable, k.file, err)
+		}
+		k.keys, k.modTime = keys, info.ModTime()
+		return keys, nil
+	}
+	if k.keys != nil && time.Since(k.fetched) < jwksMaxAge {
+		return k.keys, nil
+	}
+	keys, err := k.fetch()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errKeySetUnavailable, err)
+	}
+	k.keys, k.fetched = keys, time.Now()
+	return keys, nil
+}
+
+// fetch discovers the issuer's jwks_uri from its OpenID configuration and downloads the keys.
+func (k *keySet) fetch() (map[string]crypto.PublicKey, error) {
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JwksURI string `json:"jwks_uri"`
+	}
+	if err := k.getJSON(k.issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != k.issuer {
+		return nil, fmt.Errorf("issuer %s: discovery document is for issuer %q", k.issuer, discovery.Issuer)
+	}
+	if discovery.JwksURI == "" {
+		return nil, fmt.Errorf("issuer %s: discovery document has no jwks_uri", k.issuer)
+	}
+	resp, err := k.client.Get(discovery.JwksURI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %d", discovery.JwksURI, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseJWKS(data)
+}
+
+func (k *keySet) getJSON(url string, v interface{}) error {
+	resp, err := k.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// jwk is a JSON Web Key as found in a JWKS; only the fields of RSA and EC public keys are read.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS returns the RSA and EC signing keys of a JWKS by key id. Other keys are skipped.
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %v", err)
+	}
+	keys := map[string]crypto.PublicKey{}
+	for i, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		var pub crypto.PublicKey
+		var err error
+		switch key.Kty {
+		case "RSA":
+			pub, err = key.rsa()
+		case "EC":
+			pub, err = key.ecdsa()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("keys[%d]: %v", i, err)
+		}
+		keys[key.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no RSA or EC signing keys")
+	}
+	return keys, nil
+}
+
+func (key jwk) rsa() (*rsa.PublicKey, error) {
+	n, err := decodeInt(key.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid n: %v", err)
+	}
+	e, err := decodeInt(key.E)
+	if err != nil || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid e")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (key jwk) ecdsa() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch key.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", key.Crv)
+	}
+	x, err := decodeInt(key.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid x: %v", err)
+	}
+	y, err := decodeInt(key.Y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid y: %v", err)
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("point is not on the curve")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
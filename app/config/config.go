@@ -0,0 +1,573 @@
+// Package config loads swagger-mcp settings from a YAML or JSON configuration file.
+// Values may reference environment variables as ${NAME} or ${NAME:-default}.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"gopkg.in/yaml.v3"
+)
+
+// File is the structure of a configuration file. JSON files use the same field names.
+type File struct {
+	Transport  TransportFile  `yaml:"transport"`
+	Reload     ReloadFile     `yaml:"reload"`
+	Session    SessionFile    `yaml:"session"`
+	ClientAuth ClientAuthFile `yaml:"clientAuth"`
+	Tools      ToolsFile      `yaml:"tools"`
+	Specs      []SpecFile     `yaml:"specs"`
+}
+
+// ToolsFile configures how the operations of the specs are exposed as tools.
+type ToolsFile struct {
+	Mode               string   `yaml:"mode"`               // operations (one tool per operation), meta (search, describe and call tools) or toolsets
+	Core               []string `yaml:"core"`               // Toolsets (tags) every session has in toolsets mode
+	ReadOnly           bool     `yaml:"readOnly"`           // Only serve operations that cannot change data
+	ConfirmDestructive bool     `yaml:"confirmDestructive"` // Ask the user to confirm calls of destructive operations
+	DryRun             bool     `yaml:"dryRun"`             // Return the requests of tool calls instead of sending them
+	ValidateResponses  string   `yaml:"validateResponses"`  // off, log or warn: check responses against the spec
+}
+
+// ClientAuthFile configures how MCP clients authenticate to the SSE and Streamable HTTP listener.
+type ClientAuthFile struct {
+	Tokens       map[string]string `yaml:"tokens"`       // Accepted bearer tokens by identity name
+	ApiKeys      map[string]string `yaml:"apiKeys"`      // Accepted API keys by identity name
+	ApiKeyHeader string            `yaml:"apiKeyHeader"` // Header carrying the API key
+	ClientCerts  bool              `yaml:"clientCerts"`  // Accept verified TLS client certificates
+	Jwt          JwtFile           `yaml:"jwt"`
+}
+
+// JwtFile configures the verification of client JWTs.
+type JwtFile struct {
+	Jwks     string `yaml:"jwks"`     // Local JWKS file
+	Issuer   string `yaml:"issuer"`   // Expected issuer, whose keys are discovered when jwks is not set
+	Audience string `yaml:"audience"` // Expected audience
+}
+
+// SessionFile configures credentials and headers supplied by SSE clients for their own session.
+type SessionFile struct {
+	Credentials bool                   `yaml:"credentials"` // Accept client bearer tokens, API keys and cookies
+	Headers     []models.HeaderMapping `yaml:"headers"`     // Client headers forwarded to API requests
+}
+
+// TransportFile configures how MCP clients connect to the server.
+type TransportFile struct {
+	Mode            string   `yaml:"mode"`       // stdio, sse, http or sse,http
+	SseAddr         string   `yaml:"sseAddr"`    // SSE server listen address in :Port or IP:Port format
+	SseUrl          string   `yaml:"sseUrl"`     // Base URL for the SSE server
+	SseHeaders      []string `yaml:"sseHeaders"` // Headers read from the SSE request and passed to API requests
+	TLS             TLSFile  `yaml:"tls"`
+	ShutdownTimeout string   `yaml:"shutdownTimeout"` // Grace period for in-flight tool calls on shutdown, e.g. 30s
+}
+
+// TLSFile configures HTTPS for the SSE and Streamable HTTP listener.
+type TLSFile struct {
+	Cert              string `yaml:"cert"`              // PEM certificate, reloaded when the file changes
+	Key               string `yaml:"key"`               // PEM private key, reloaded when the file changes
+	ClientCA          string `yaml:"clientCA"`          // PEM CA bundle client certificates are verified against
+	RequireClientCert bool   `yaml:"requireClientCert"` // Reject clients without a verified certificate
+}
+
+// ReloadFile configures spec hot reload.
+type ReloadFile struct {
+	Watch        bool   `yaml:"watch"`
+	PollInterval string `yaml:"pollInterval"` // Go duration, e.g. 30s
+}
+
+// SpecFile configures one spec and the API it describes.
+type SpecFile struct {
+	SpecUrl   string                              `yaml:"specUrl"`
+	Prefix    string                              `yaml:"prefix"`
+	BaseUrl   string                              `yaml:"baseUrl"`
+	Auth      AuthFile                            `yaml:"auth"`
+	Filters   FiltersFile                         `yaml:"filters"`
+	Headers   map[string]string                   `yaml:"headers"`
+	Overrides map[string]models.OperationOverride `yaml:"overrides"`
+
+	ResourceTemplates []string              `yaml:"resourceTemplates"` // GET operations also served as resource templates: operationIds or "GET /path", or *
+	Prompts           []models.PromptConfig `yaml:"prompts"`           // Custom prompts, replacing spec prompts of the same name
+	Lookups           map[string]LookupFile `yaml:"lookups"`           // Operations listing the values of a parameter, keyed by parameter name
+}
+
+// LookupFile names the GET operation whose response lists the valid values of a parameter.
+type LookupFile struct {
+	Operation string `yaml:"operation"` // operationId or "GET /path"
+	Items     string `yaml:"items"`     // Dot-separated path of the list in the response
+	Field     string `yaml:"field"`     // Field of each item holding the value
+	TTL       string `yaml:"ttl"`       // Go duration the values are cached for, e.g. 10m
+}
+
+// AuthFile configures the credentials sent to the API.
+type AuthFile struct {
+	Type    string          `yaml:"type"` // basic, apiKey or bearer
+	Basic   BasicAuthFile   `yaml:"basic"`
+	Bearer  string          `yaml:"bearer"`
+	ApiKeys []models.ApiKey `yaml:"apiKeys"`
+}
+
+// BasicAuthFile holds basic auth credentials.
+type BasicAuthFile struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// FiltersFile selects the operations exposed as tools.
+type FiltersFile struct {
+	IncludePaths   []string `yaml:"includePaths"`
+	ExcludePaths   []string `yaml:"excludePaths"`
+	IncludeMethods []string `yaml:"includeMethods"`
+	ExcludeMethods []string `yaml:"excludeMethods"`
+
+	IncludeTags       []string `yaml:"includeTags"`
+	ExcludeTags       []string `yaml:"excludeTags"`
+	IncludeOperations []string `yaml:"includeOperations"` // operationIds or glob patterns
+	ExcludeOperations []string `yaml:"excludeOperations"`
+	IncludeExtensions []string `yaml:"includeExtensions"` // x- extensions as name or name=value
+	ExcludeExtensions []string `yaml:"excludeExtensions"`
+	ExcludeDeprecated bool     `yaml:"excludeDeprecated"`
+}
+
+// envRef matches ${NAME} and ${NAME:-default}.
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolate replaces environment variable references in the string values of a decoded document, so that
+// variables cannot change its structure. Plain values are resolved again after interpolation, so that
+// ${NAME:-false} can set a boolean. Variables that are unset and have no default are reported.
+func interpolate(node *yaml.Node) error {
+	missing := map[string]bool{}
+	var walk func(n *yaml.Node)
+	walk = func(n *yaml.Node) {
+		switch n.Kind {
+		case yaml.DocumentNode, yaml.SequenceNode:
+			for _, child := range n.Content {
+				walk(child)
+			}
+		case yaml.MappingNode:
+			for i := 1; i < len(n.Content); i += 2 {
+				walk(n.Content[i])
+			}
+		case yaml.ScalarNode:
+			if n.ShortTag() != "!!str" || !envRef.MatchString(n.Value) {
+				return
+			}
+			n.Value = envRef.ReplaceAllStringFunc(n.Value, func(ref string) string {
+				m := envRef.FindStringSubmatch(ref)
+				if val, ok := os.LookupEnv(m[1]); ok {
+					return val
+				}
+				if m[2] != "" {
+					return m[3]
+				}
+				missing[m[1]] = true
+				return ref
+			})
+			if n.Style == 0 {
+				n.Tag = ""
+			}
+		}
+	}
+	walk(node)
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("environment variable not set: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// Parse decodes and validates a configuration file. Unknown fields are rejected.
+func Parse(data []byte) (*File, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("invalid config: %v", err)
+	}
+	if err := interpolate(&node); err != nil {
+		return nil, err
+	}
+	// encode the interpolated document again, as decoding a node cannot reject unknown fields
+	data, err := yaml.Marshal(&node)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %v", err)
+	}
+	var file File
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid config: %v", err)
+	}
+	if err := file.Validate(); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// Load reads, decodes and validates the configuration file at path.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config: %v", err)
+	}
+	file, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return file, nil
+}
+
+// Validate checks the configuration and reports the first problem with the path of the offending field.
+func (f *File) Validate() error {
+	switch strings.ReplaceAll(f.Transport.Mode, " ", "") {
+	case "", "stdio", "sse", "http", "sse,http", "http,sse":
+	default:
+		return fmt.Errorf("transport.mode: must be stdio, sse, http or sse,http, got %q", f.Transport.Mode)
+	}
+	if (f.Transport.TLS.Cert != "") != (f.Transport.TLS.Key != "") {
+		return fmt.Errorf("transport.tls: cert and key must be given together")
+	}
+	if f.Transport.TLS.RequireClientCert && f.Transport.TLS.ClientCA == "" {
+		return fmt.Errorf("transport.tls.requireClientCert: requires clientCA")
+	}
+	if f.Transport.ShutdownTimeout != "" {
+		if d, err := time.ParseDuration(f.Transport.ShutdownTimeout); err != nil || d <= 0 {
+			return fmt.Errorf("transport.shutdownTimeout: must be a positive duration such as 30s, got %q", f.Transport.ShutdownTimeout)
+		}
+	}
+	if f.Reload.PollInterval != "" {
+		if d, err := time.ParseDuration(f.Reload.PollInterval); err != nil || d <= 0 {
+			return fmt.Errorf("reload.pollInterval: must be a positive duration such as 30s, got %q", f.Reload.PollInterval)
+		}
+	}
+	for _, c := range []struct {
+		field       string
+		credentials map[string]string
+	}{{"clientAuth.tokens", f.ClientAuth.Tokens}, {"clientAuth.apiKeys", f.ClientAuth.ApiKeys}} {
+		for name, value := range c.credentials {
+			if value == "" {
+				return fmt.Errorf("%s.%s: must not be empty", c.field, name)
+			}
+			if strings.ContainsAny(name+value, ",=") {
+				return fmt.Errorf("%s.%s: names and values must not contain commas or equals signs", c.field, name)
+			}
+		}
+	}
+	if f.ClientAuth.Jwt.Issuer != "" && !strings.HasPrefix(f.ClientAuth.Jwt.Issuer, "https://") && !strings.HasPrefix(f.ClientAuth.Jwt.Issuer, "http://") {
+		return fmt.Errorf("clientAuth.jwt.issuer: must be an http(s) URL, got %q", f.ClientAuth.Jwt.Issuer)
+	}
+	switch f.Tools.Mode {
+	case "", "operations", "meta", "toolsets":
+	default:
+		return fmt.Errorf("tools.mode: must be operations, meta or toolsets, got %q", f.Tools.Mode)
+	}
+	if len(f.Tools.Core) > 0 && f.Tools.Mode != "toolsets" {
+		return fmt.Errorf("tools.core: requires mode toolsets")
+	}
+	for i, name := range f.Tools.Core {
+		if strings.TrimSpace(name) == "" || strings.Contains(name, ",") {
+			return fmt.Errorf("tools.core[%d]: must not be empty or contain commas", i)
+		}
+	}
+	switch f.Tools.ValidateResponses {
+	case "", "off", "log", "warn":
+	default:
+		return fmt.Errorf("tools.validateResponses: must be off, log or warn, got %q", f.Tools.ValidateResponses)
+	}
+	for i, m := range f.Session.Headers {
+		if m.From == "" {
+			return fmt.Errorf("session.headers[%d].from: is required", i)
+		}
+	}
+	prefixes := map[string]int{}
+	for i, spec := range f.Specs {
+		field := fmt.Sprintf("specs[%d]", i)
+		if err := spec.validate(field); err != nil {
+			return err
+		}
+		if j, ok := prefixes[spec.Prefix]; ok {
+			return fmt.Errorf("%s.prefix: %q is already used by specs[%d], each spec needs a unique prefix", field, spec.Prefix, j)
+		}
+		prefixes[spec.Prefix] = i
+	}
+	return nil
+}
+
+func (s SpecFile) validate(field string) error {
+	if s.SpecUrl == "" {
+		return fmt.Errorf("%s.specUrl: is required", field)
+	}
+	if strings.HasPrefix(s.SpecUrl, "http://") || strings.HasPrefix(s.SpecUrl, "https://") {
+		if _, err := url.ParseRequestURI(s.SpecUrl); err != nil {
+			return fmt.Errorf("%s.specUrl: %v", field, err)
+		}
+	} else if !strings.HasPrefix(s.SpecUrl, "file://") {
+		return fmt.Errorf("%s.specUrl: must be an http(s):// URL or file:// path, got %q", field, s.SpecUrl)
+	}
+	if s.BaseUrl != "" && !strings.HasPrefix(s.BaseUrl, "http://") && !strings.HasPrefix(s.BaseUrl, "https://") {
+		return fmt.Errorf("%s.baseUrl: must start with http:// or https://, got %q", field, s.BaseUrl)
+	}
+
+	switch s.Auth.Type {
+	case "":
+	case "basic":
+		if s.Auth.Basic.Username == "" {
+			return fmt.Errorf("%s.auth.basic.username: is required for basic auth", field)
+		}
+	case "bearer":
+		if s.Auth.Bearer == "" {
+			return fmt.Errorf("%s.auth.bearer: is required for bearer auth", field)
+		}
+	case "apiKey":
+		if len(s.Auth.ApiKeys) == 0 {
+			return fmt.Errorf("%s.auth.apiKeys: at least one key is required for apiKey auth", field)
+		}
+	default:
+		return fmt.Errorf("%s.auth.type: must be basic, apiKey or bearer, got %q", field, s.Auth.Type)
+	}
+	for i, key := range s.Auth.ApiKeys {
+		switch key.In {
+		case "header", "query", "cookie":
+		default:
+			return fmt.Errorf("%s.auth.apiKeys[%d].in: must be header, query or cookie, got %q", field, i, key.In)
+		}
+		if key.Name == "" {
+			return fmt.Errorf("%s.auth.apiKeys[%d].name: is required", field, i)
+		}
+	}
+
+	if err := validateRegexes(field+".filters.includePaths", s.Filters.IncludePaths); err != nil {
+		return err
+	}
+	if err := validateRegexes(field+".filters.excludePaths", s.Filters.ExcludePaths); err != nil {
+		return err
+	}
+	if err := validateMethods(field+".filters.includeMethods", s.Filters.IncludeMethods); err != nil {
+		return err
+	}
+	if err := validateMethods(field+".filters.excludeMethods", s.Filters.ExcludeMethods); err != nil {
+		return err
+	}
+	if err := validateList(field+".filters.includeTags", s.Filters.IncludeTags); err != nil {
+		return err
+	}
+	if err := validateList(field+".filters.excludeTags", s.Filters.ExcludeTags); err != nil {
+		return err
+	}
+	if err := validateGlobs(field+".filters.includeOperations", s.Filters.IncludeOperations); err != nil {
+		return err
+	}
+	if err := validateGlobs(field+".filters.excludeOperations", s.Filters.ExcludeOperations); err != nil {
+		return err
+	}
+	if err := validateExtensions(field+".filters.includeExtensions", s.Filters.IncludeExtensions); err != nil {
+		return err
+	}
+	if err := validateExtensions(field+".filters.excludeExtensions", s.Filters.ExcludeExtensions); err != nil {
+		return err
+	}
+	for key, override := range s.Overrides {
+		if override.Name != "" && strings.ContainsAny(override.Name, " \t\n") {
+			return fmt.Errorf("%s.overrides[%q].name: must not contain whitespace", field, key)
+		}
+		if override.Async != nil {
+			if err := validateAsync(fmt.Sprintf("%s.overrides[%q].async", field, key), *override.Async); err != nil {
+				return err
+			}
+		}
+	}
+	for i, selector := range s.ResourceTemplates {
+		if strings.Contains(selector, ",") {
+			return fmt.Errorf("%s.resourceTemplates[%d]: must not contain commas", field, i)
+		}
+		if method, _, ok := strings.Cut(selector, " "); ok && !strings.EqualFold(method, "GET") {
+			return fmt.Errorf("%s.resourceTemplates[%d]: only GET operations can be resource templates, got %q", field, i, selector)
+		}
+	}
+	names := map[string]bool{}
+	for i, prompt := range s.Prompts {
+		if err := ValidatePrompt(fmt.Sprintf("%s.prompts[%d]", field, i), prompt); err != nil {
+			return err
+		}
+		if names[prompt.Name] {
+			return fmt.Errorf("%s.prompts[%d].name: duplicate prompt %q", field, i, prompt.Name)
+		}
+		names[prompt.Name] = true
+	}
+	for name, lookup := range s.Lookups {
+		if lookup.Operation == "" {
+			return fmt.Errorf("%s.lookups[%q].operation: is required", field, name)
+		}
+		if method, _, ok := strings.Cut(lookup.Operation, " "); ok && !strings.EqualFold(method, "GET") {
+			return fmt.Errorf("%s.lookups[%q].operation: only GET operations can be lookups, got %q", field, name, lookup.Operation)
+		}
+		if lookup.TTL != "" {
+			if d, err := time.ParseDuration(lookup.TTL); err != nil || d <= 0 {
+				return fmt.Errorf("%s.lookups[%q].ttl: must be a positive duration such as 10m, got %q", field, name, lookup.TTL)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidatePrompt checks that a prompt has a name, messages with a known role and named arguments.
+// Errors are prefixed with the path of the prompt, field. It also checks the prompts declared in specs.
+func ValidatePrompt(field string, prompt models.PromptConfig) error {
+	if prompt.Name == "" {
+		return fmt.Errorf("%s.name: is required", field)
+	}
+	if strings.ContainsAny(prompt.Name, " \t\n") {
+		return fmt.Errorf("%s.name: must not contain whitespace", field)
+	}
+	for i, arg := range prompt.Arguments {
+		if arg.Name == "" {
+			return fmt.Errorf("%s.arguments[%d].name: is required", field, i)
+		}
+	}
+	if len(prompt.Messages) == 0 {
+		return fmt.Errorf("%s.messages: at least one message is required", field)
+	}
+	for i, message := range prompt.Messages {
+		switch message.Role {
+		case "", "user", "assistant":
+		default:
+			return fmt.Errorf("%s.messages[%d].role: must be user or assistant, got %q", field, i, message.Role)
+		}
+	}
+	return nil
+}
+
+func validateAsync(field string, async models.AsyncConfig) error {
+	for name, value := range map[string]string{"pollInterval": async.PollInterval, "timeout": async.Timeout} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("%s.%s: must be a positive duration such as 5s, got %q", field, name, value)
+		}
+	}
+	switch strings.ToUpper(async.CancelMethod) {
+	case "", "DELETE", "POST", "PUT", "PATCH":
+	default:
+		return fmt.Errorf("%s.cancelMethod: must be DELETE, POST, PUT or PATCH, got %q", field, async.CancelMethod)
+	}
+	return nil
+}
+
+func validateRegexes(field string, patterns []string) error {
+	for i, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("%s[%d]: invalid regex: %v", field, i, err)
+		}
+		if strings.Contains(pattern, ",") {
+			return fmt.Errorf("%s[%d]: patterns must not contain commas", field, i)
+		}
+	}
+	return nil
+}
+
+// validateList checks the entries of a list joined with commas for the server.
+func validateList(field string, entries []string) error {
+	for i, entry := range entries {
+		if strings.TrimSpace(entry) == "" || strings.Contains(entry, ",") {
+			return fmt.Errorf("%s[%d]: must not be empty or contain commas", field, i)
+		}
+	}
+	return nil
+}
+
+func validateGlobs(field string, patterns []string) error {
+	if err := validateList(field, patterns); err != nil {
+		return err
+	}
+	for i, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%s[%d]: invalid glob pattern %q", field, i, pattern)
+		}
+	}
+	return nil
+}
+
+func validateExtensions(field string, extensions []string) error {
+	if err := validateList(field, extensions); err != nil {
+		return err
+	}
+	for i, extension := range extensions {
+		if !strings.HasPrefix(strings.TrimSpace(extension), "x-") {
+			return fmt.Errorf("%s[%d]: must be an x- extension name, optionally followed by =value, got %q", field, i, extension)
+		}
+	}
+	return nil
+}
+
+func validateMethods(field string, methods []string) error {
+	for i, method := range methods {
+		switch strings.ToUpper(method) {
+		case "GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE":
+		default:
+			return fmt.Errorf("%s[%d]: unknown HTTP method %q", field, i, method)
+		}
+	}
+	return nil
+}
+
+// Credentials returns credentials in the name1=value1,name2=value2 format of the --authTokens and --authApiKeys flags.
+func Credentials(credentials map[string]string) string {
+	names := make([]string, 0, len(credentials))
+	for name := range credentials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+"="+credentials[name])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// SpecConfig converts the spec settings into the model used by the server.
+func (s SpecFile) SpecConfig() models.SpecConfig {
+	apiCfg := models.ApiConfig{
+		BaseUrl:        s.BaseUrl,
+		IncludePaths:   strings.Join(s.Filters.IncludePaths, ","),
+		ExcludePaths:   strings.Join(s.Filters.ExcludePaths, ","),
+		IncludeMethods: strings.Join(s.Filters.IncludeMethods, ","),
+		ExcludeMethods: strings.Join(s.Filters.ExcludeMethods, ","),
+		Security:       s.Auth.Type,
+		BearerAuth:     s.Auth.Bearer,
+		ToolPrefix:     s.Prefix,
+		HeaderValues:   s.Headers,
+		ApiKeys:        s.Auth.ApiKeys,
+		Overrides:      s.Overrides,
+
+		IncludeTags:       strings.Join(s.Filters.IncludeTags, ","),
+		ExcludeTags:       strings.Join(s.Filters.ExcludeTags, ","),
+		IncludeOperations: strings.Join(s.Filters.IncludeOperations, ","),
+		ExcludeOperations: strings.Join(s.Filters.ExcludeOperations, ","),
+		IncludeExtensions: strings.Join(s.Filters.IncludeExtensions, ","),
+		ExcludeExtensions: strings.Join(s.Filters.ExcludeExtensions, ","),
+		ExcludeDeprecated: s.Filters.ExcludeDeprecated,
+		ResourceTemplates: strings.Join(s.ResourceTemplates, ","),
+		Prompts:           s.Prompts,
+	}
+	for name, lookup := range s.Lookups {
+		if apiCfg.Lookups == nil {
+			apiCfg.Lookups = map[string]models.Lookup{}
+		}
+		ttl, _ := time.ParseDuration(lookup.TTL)
+		apiCfg.Lookups[name] = models.Lookup{Operation: lookup.Operation, Items: lookup.Items, Field: lookup.Field, TTL: ttl}
+	}
+	if s.Auth.Basic.Username != "" {
+		apiCfg.BasicAuth = s.Auth.Basic.Username + ":" + s.Auth.Basic.Password
+	}
+	return models.SpecConfig{SpecUrl: s.SpecUrl, ApiCfg: apiCfg}
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleYAML = `
+transport:
+  mode: sse
+  sseAddr: ":9090"
+  sseHeaders: [X-Tenant]
+reload:
+  watch: true
+  pollInterval: 1m
+specs:
+  - specUrl: https://users.example.com/swagger.json
+    prefix: users
+    baseUrl: https://users.example.com
+    auth:
+      type: apiKey
+      apiKeys:
+        - {in: header, name: X-API-Key, value: "${TEST_API_KEY}"}
+    filters:
+      includeMethods: [GET, post]
+      includePaths: ["/users/.*"]
+      excludeOperations: ["*Admin*", deleteUser]
+      excludeExtensions: [x-internal]
+      excludeDeprecated: true
+    headers:
+      X-Tenant: "${TEST_TENANT:-acme}"
+    overrides:
+      getUser:
+        name: get_user
+        description: Fetch one user by id
+      GET /users:
+        resource: true
+      POST /users/import:
+        async: {statusField: state, pollInterval: 5s, cancelMethod: DELETE}
+        annotations: {title: Import users, idempotent: true}
+        confirm: true
+    resourceTemplates: [getUser]
+    prompts:
+      - name: onboard_user
+        description: Onboard a new user
+        arguments:
+          - {name: email, required: true}
+        messages:
+          - text: Create a user for {email} and send the welcome mail.
+    lookups:
+      region: {operation: GET /regions, items: data, field: code, ttl: 10m}
+  - specUrl: file:///specs/orders.json
+    prefix: orders
+    auth:
+      type: basic
+      basic: {username: svc, password: "p:w"}
+`
+
+func TestParse_YAML(t *testing.T) {
+	t.Setenv("TEST_API_KEY", "secret")
+	file, err := Parse([]byte(sampleYAML))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if file.Transport.Mode != "sse" || file.Transport.SseAddr != ":9090" {
+		t.Errorf("unexpected transport: %+v", file.Transport)
+	}
+	if len(file.Specs) != 2 {
+		t.Fatalf("expected 2 specs, got %d", len(file.Specs))
+	}
+
+	users := file.Specs[0].SpecConfig()
+	if users.ApiCfg.ToolPrefix != "users" || users.ApiCfg.IncludeMethods != "GET,post" || users.ApiCfg.Security != "apiKey" {
+		t.Errorf("unexpected api config: %+v", users.ApiCfg)
+	}
+	if users.ApiCfg.ExcludeOperations != "*Admin*,deleteUser" || users.ApiCfg.ExcludeExtensions != "x-internal" || !users.ApiCfg.ExcludeDeprecated {
+		t.Errorf("unexpected operation filters: %+v", users.ApiCfg)
+	}
+	if len(users.ApiCfg.ApiKeys) != 1 || users.ApiCfg.ApiKeys[0].Value != "secret" {
+		t.Errorf("expected interpolated api key, got %+v", users.ApiCfg.ApiKeys)
+	}
+	if users.ApiCfg.HeaderValues["X-Tenant"] != "acme" {
+		t.Errorf("expected default header value, got %+v", users.ApiCfg.HeaderValues)
+	}
+	if users.ApiCfg.Overrides["getUser"].Name != "get_user" || !users.ApiCfg.Overrides["GET /users"].Resource || users.ApiCfg.ResourceTemplates != "getUser" {
+		t.Errorf("unexpected overrides: %+v", users.ApiCfg.Overrides)
+	}
+	if async := users.ApiCfg.Overrides["POST /users/import"].Async; async == nil || async.StatusField != "state" || async.PollInterval != "5s" || async.CancelMethod != "DELETE" {
+		t.Errorf("unexpected async override: %+v", async)
+	}
+	if annotations := users.ApiCfg.Overrides["POST /users/import"].Annotations; annotations == nil || annotations.Title != "Import users" || annotations.Idempotent == nil || !*annotations.Idempotent || annotations.ReadOnly != nil {
+		t.Errorf("unexpected annotations override: %+v", annotations)
+	}
+	if confirm := users.ApiCfg.Overrides["POST /users/import"].Confirm; confirm == nil || !*confirm {
+		t.Errorf("expected the confirm override, got %v", confirm)
+	}
+	if len(users.ApiCfg.Prompts) != 1 || !users.ApiCfg.Prompts[0].Arguments[0].Required || users.ApiCfg.Prompts[0].Messages[0].Text == "" {
+		t.Errorf("unexpected prompts: %+v", users.ApiCfg.Prompts)
+	}
+	if lookup := users.ApiCfg.Lookups["region"]; lookup.Operation != "GET /regions" || lookup.Items != "data" || lookup.Field != "code" || lookup.TTL != 10*time.Minute {
+		t.Errorf("unexpected lookups: %+v", users.ApiCfg.Lookups)
+	}
+
+	orders := file.Specs[1].SpecConfig()
+	if orders.ApiCfg.BasicAuth != "svc:p:w" {
+		t.Errorf("unexpected basic auth: %q", orders.ApiCfg.BasicAuth)
+	}
+}
+
+func TestLoad_JSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"specs":[{"specUrl":"https://api.example.com/openapi.json","auth":{"type":"bearer","bearer":"tok"}}]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := file.Specs[0].SpecConfig().ApiCfg.BearerAuth; got != "tok" {
+		t.Errorf("expected bearer token, got %q", got)
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	if got := Credentials(map[string]string{"bob": "env:BOB", "alice": "abc"}); got != "alice=abc,bob=env:BOB" {
+		t.Errorf("Credentials = %q", got)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	cases := []struct {
+		name, data, want string
+	}{
+		{"unknown field", "specs:\n  - specUrl: https://a.com/s.json\n    colour: red\n", "field colour not found"},
+		{"missing env", "specs:\n  - specUrl: ${TEST_UNSET_SPEC_URL}\n", "environment variable not set: TEST_UNSET_SPEC_URL"},
+		{"missing specUrl", "specs:\n  - prefix: a\n", "specs[0].specUrl: is required"},
+		{"bad transport", "transport:\n  mode: websocket\n", "transport.mode: must be stdio, sse, http or sse,http"},
+		{"bad auth type", "specs:\n  - specUrl: https://a.com/s.json\n    auth: {type: oauth}\n", "specs[0].auth.type"},
+		{"bearer without token", "specs:\n  - specUrl: https://a.com/s.json\n    auth: {type: bearer}\n", "specs[0].auth.bearer: is required"},
+		{"bad api key location", "specs:\n  - specUrl: https://a.com/s.json\n    auth: {type: apiKey, apiKeys: [{in: body, name: k}]}\n", "specs[0].auth.apiKeys[0].in"},
+		{"bad regex", "specs:\n  - specUrl: https://a.com/s.json\n    filters: {includePaths: ['(']}\n", "specs[0].filters.includePaths[0]: invalid regex"},
+		{"bad operation glob", "specs:\n  - specUrl: https://a.com/s.json\n    filters: {includeOperations: ['list[']}\n", "specs[0].filters.includeOperations[0]: invalid glob"},
+		{"bad extension", "specs:\n  - specUrl: https://a.com/s.json\n    filters: {excludeExtensions: [internal]}\n", "specs[0].filters.excludeExtensions[0]"},
+		{"bad method", "specs:\n  - specUrl: https://a.com/s.json\n    filters: {excludeMethods: [FETCH]}\n", "specs[0].filters.excludeMethods[0]"},
+		{"duplicate prefix", "specs:\n  - specUrl: https://a.com/s.json\n  - specUrl: https://b.com/s.json\n", "specs[1].prefix"},
+		{"resource template for POST", "specs:\n  - specUrl: https://a.com/s.json\n    resourceTemplates: ['POST /users']\n", "specs[0].resourceTemplates[0]"},
+		{"prompt without messages", "specs:\n  - specUrl: https://a.com/s.json\n    prompts: [{name: p}]\n", "specs[0].prompts[0].messages"},
+		{"bad prompt role", "specs:\n  - specUrl: https://a.com/s.json\n    prompts: [{name: p, messages: [{role: system, text: hi}]}]\n", "specs[0].prompts[0].messages[0].role"},
+		{"lookup without operation", "specs:\n  - specUrl: https://a.com/s.json\n    lookups: {region: {field: code}}\n", `specs[0].lookups["region"].operation: is required`},
+		{"bad lookup ttl", "specs:\n  - specUrl: https://a.com/s.json\n    lookups: {region: {operation: listRegions, ttl: soon}}\n", `specs[0].lookups["region"].ttl`},
+		{"bad async timeout", "specs:\n  - specUrl: https://a.com/s.json\n    overrides: {importUsers: {async: {timeout: forever}}}\n", `specs[0].overrides["importUsers"].async.timeout`},
+		{"bad async cancel method", "specs:\n  - specUrl: https://a.com/s.json\n    overrides: {importUsers: {async: {cancelMethod: STOP}}}\n", `specs[0].overrides["importUsers"].async.cancelMethod`},
+		{"bad interval", "reload: {pollInterval: soon}\n", "reload.pollInterval"},
+		{"bad shutdown timeout", "transport: {shutdownTimeout: -5s}\n", "transport.shutdownTimeout"},
+		{"bad tool mode", "tools: {mode: lazy}\n", "tools.mode"},
+		{"core toolsets without toolsets mode", "tools: {mode: meta, core: [users]}\n", "tools.core: requires mode toolsets"},
+		{"core toolset with comma", "tools: {mode: toolsets, core: ['a,b']}\n", "tools.core[0]"},
+		{"bad response validation", "tools: {validateResponses: strict}\n", "tools.validateResponses: must be off, log or warn"},
+		{"empty client token", "clientAuth: {tokens: {alice: ''}}\n", "clientAuth.tokens.alice: must not be empty"},
+		{"client token with comma", "clientAuth: {apiKeys: {ci: 'a,b'}}\n", "clientAuth.apiKeys.ci"},
+		{"bad issuer", "clientAuth: {jwt: {issuer: idp.example.com}}\n", "clientAuth.jwt.issuer"},
+	}
+	for _, c := range cases {
+		_, err := Parse([]byte(c.data))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: expected error containing %q, got %v", c.name, c.want, err)
+		}
+	}
+}
+
+func TestParse_Interpolation(t *testing.T) {
+	// a value cannot add fields or change the structure of the file
+	t.Setenv("TEST_TENANT", "acme\n    colour: red")
+	t.Setenv("TEST_WATCH", "true")
+	t.Setenv("TEST_PORT", "9090")
+	data := "reload:\n  watch: ${TEST_WATCH}\ntransport:\n  sseAddr: ':${TEST_PORT}'\nspecs:\n  - specUrl: https://a.com/s.json # ${TEST_UNSET_COMMENT}\n    headers:\n      X-Tenant: ${TEST_TENANT}\n      X-Port: ${TEST_PORT}\n"
+	file, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if !file.Reload.Watch || file.Transport.SseAddr != ":9090" {
+		t.Errorf("unexpected interpolated settings %+v, %+v", file.Reload, file.Transport)
+	}
+	if headers := file.Specs[0].Headers; headers["X-Tenant"] != "acme\n    colour: red" || headers["X-Port"] != "9090" {
+		t.Errorf("expected the values to be kept as strings, got %q", headers)
+	}
+}
@@ -0,0 +1,103 @@
+package mcpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Operation extensions overriding the annotations inferred from the HTTP method.
+const (
+	extensionTitle       = "x-mcp-title"
+	extensionReadOnly    = "x-mcp-read-only"
+	extensionDestructive = "x-mcp-destructive"
+	extensionIdempotent  = "x-mcp-idempotent"
+	extensionOpenWorld   = "x-mcp-open-world"
+)
+
+// operationAnnotations returns the annotations of an operation's tool. They are inferred from the HTTP method:
+// the safe methods (GET, HEAD, OPTIONS and TRACE) only read, PUT and DELETE are idempotent, PUT, PATCH and DELETE may destroy data
+// and every call reaches an external API. The title is the operation summary. x-mcp-* extensions of the
+// operation override them, and the annotations of its override in the config file override both. Invalid
+// extensions are ignored and reported in the returned error.
+func operationAnnotations(method string, override models.OperationOverride, details models.Endpoint) (mcp.ToolAnnotation, error) {
+	readOnly, destructive, idempotent := false, false, false
+	method = strings.ToUpper(method)
+	switch {
+	case safeMethods[method]:
+		readOnly, idempotent = true, true
+	case method == "PUT" || method == "DELETE":
+		destructive, idempotent = true, true
+	case method == "PATCH":
+		destructive = true
+	}
+	if safeOperation(method, override, details) {
+		// POST operations marked safe only read, like a search
+		readOnly, destructive, idempotent = true, false, true
+	}
+	annotations := mcp.ToolAnnotation{
+		Title:           details.Summary,
+		ReadOnlyHint:    mcp.ToBoolPtr(readOnly),
+		DestructiveHint: mcp.ToBoolPtr(destructive),
+		IdempotentHint:  mcp.ToBoolPtr(idempotent),
+		OpenWorldHint:   mcp.ToBoolPtr(true),
+	}
+
+	var err error
+	if raw, ok := details.Extensions[extensionTitle]; ok {
+		var title string
+		if json.Unmarshal(raw, &title) != nil {
+			err = fmt.Errorf("%s: must be a string", extensionTitle)
+		} else {
+			annotations.Title = title
+		}
+	}
+	hints := []struct {
+		extension string
+		hint      **bool
+	}{
+		{extensionReadOnly, &annotations.ReadOnlyHint},
+		{extensionDestructive, &annotations.DestructiveHint},
+		{extensionIdempotent, &annotations.IdempotentHint},
+		{extensionOpenWorld, &annotations.OpenWorldHint},
+	}
+	for _, h := range hints {
+		raw, ok := details.Extensions[h.extension]
+		if !ok {
+			continue
+		}
+		var value bool
+		if json.Unmarshal(raw, &value) != nil {
+			err = fmt.Errorf("%s: must be true or false", h.extension)
+			continue
+		}
+		*h.hint = mcp.ToBoolPtr(value)
+	}
+
+	if configured := override.Annotations; configured != nil {
+		if configured.Title != "" {
+			annotations.Title = configured.Title
+		}
+		for _, h := range []struct {
+			value *bool
+			hint  **bool
+		}{
+			{configured.ReadOnly, &annotations.ReadOnlyHint},
+			{configured.Destructive, &annotations.DestructiveHint},
+			{configured.Idempotent, &annotations.IdempotentHint},
+			{configured.OpenWorld, &annotations.OpenWorldHint},
+		} {
+			if h.value != nil {
+				*h.hint = mcp.ToBoolPtr(*h.value)
+			}
+		}
+	}
+	if *annotations.ReadOnlyHint {
+		// a tool that only reads cannot destroy anything
+		annotations.DestructiveHint = mcp.ToBoolPtr(false)
+	}
+	return annotations, err
+}
@@ -0,0 +1,77 @@
+package mcpserver
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const annotationsSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Shop", "version": "1.0.0"},
+  "paths": {
+    "/orders": {
+      "get": {"summary": "List orders", "responses": {"200": {"description": "Orders"}}},
+      "post": {"summary": "Create an order", "responses": {"201": {"description": "Created"}}}
+    },
+    "/orders/{id}": {
+      "put": {"summary": "Replace an order", "responses": {"200": {"description": "Order"}}},
+      "patch": {"responses": {"200": {"description": "Order"}}},
+      "delete": {"summary": "Delete an order", "responses": {"204": {"description": "Deleted"}}}
+    },
+    "/orders/search": {
+      "post": {"summary": "Search orders", "x-mcp-read-only": true, "x-mcp-idempotent": true, "x-mcp-title": "Find orders", "responses": {"200": {"description": "Orders"}}}
+    },
+    "/orders/{id}/archive": {
+      "post": {"x-mcp-destructive": "yes", "responses": {"204": {"description": "Archived"}}}
+    }
+  }
+}`
+
+// annotationString formats annotations as title readOnly destructive idempotent openWorld.
+func annotationString(a mcp.ToolAnnotation) string {
+	hint := func(value *bool) string {
+		if value == nil {
+			return "-"
+		}
+		return fmt.Sprint(*value)
+	}
+	return fmt.Sprintf("%q %s %s %s %s", a.Title, hint(a.ReadOnlyHint), hint(a.DestructiveHint), hint(a.IdempotentHint), hint(a.OpenWorldHint))
+}
+
+func TestOperationAnnotations(t *testing.T) {
+	yes, no := true, false
+	apiCfg := models.ApiConfig{Overrides: map[string]models.OperationOverride{
+		"DELETE /orders/{id}": {Annotations: &models.ToolAnnotations{Title: "Remove an order", Destructive: &no}},
+		"PUT /orders/{id}":    {Annotations: &models.ToolAnnotations{OpenWorld: &no, ReadOnly: &yes}},
+	}}
+	annotations := map[string]string{}
+	for _, tool := range BuildSwaggerTools(parseTestSpec(t, annotationsSpec), apiCfg) {
+		annotations[tool.Tool.Name] = annotationString(tool.Tool.Annotations)
+	}
+
+	tests := []struct {
+		tool string
+		want string
+	}{
+		{"get_/orders", `"List orders" true false true true`},
+		{"post_/orders", `"Create an order" false false false true`},
+		{"put_/orders/id", `"Replace an order" true false true false`},
+		{"patch_/orders/id", `"" false true false true`},
+		{"delete_/orders/id", `"Remove an order" false false true true`},
+		{"post_/orders/search", `"Find orders" true false true true`},
+		{"post_/orders/id/archive", `"" false false false true`},
+	}
+	for _, tt := range tests {
+		if got := annotations[tt.tool]; got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.tool, got, tt.want)
+		}
+	}
+
+	_, err := operationAnnotations("post", models.OperationOverride{}, parseTestSpec(t, annotationsSpec).Paths["/orders/{id}/archive"]["post"])
+	if err == nil || err.Error() != "x-mcp-destructive: must be true or false" {
+		t.Errorf("expected an invalid extension error, got %v", err)
+	}
+}
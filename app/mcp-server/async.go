@@ -0,0 +1,374 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// DefaultAsyncPollInterval is how often the status of a long-running operation is checked when no interval is configured.
+	DefaultAsyncPollInterval = 2 * time.Second
+	// DefaultAsyncTimeout is how long a tool call waits for a long-running operation when no timeout is configured.
+	DefaultAsyncTimeout = 30 * time.Minute
+	// asyncCancelTimeout bounds the request cancelling a long-running operation.
+	asyncCancelTimeout = 10 * time.Second
+)
+
+var (
+	defaultDoneValues   = []string{"succeeded", "completed", "done"}
+	defaultFailedValues = []string{"failed", "error", "cancelled", "canceled"}
+)
+
+// progressNotification reports the progress of a request to the client that asked for it with a progress token.
+const progressNotification = "notifications/progress"
+
+// statusURLField matches the {field} placeholders of a status URL pattern.
+var statusURLField = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// asyncOperation follows a long-running operation that answers 202 Accepted until it completes.
+type asyncOperation struct {
+	models.AsyncConfig
+	baseURL      string // base URL of the API, for status URL patterns given as a path
+	pollInterval time.Duration
+	timeout      time.Duration
+}
+
+// operationAsync returns how to follow an operation that answers 202 Accepted: the async override, or else
+// the x-mcp-async extension of the operation, true for the defaults or an object in the format of
+// models.AsyncConfig. It returns nil for operations that are not long-running.
+func operationAsync(override models.OperationOverride, details models.Endpoint) (*models.AsyncConfig, error) {
+	if override.Async != nil {
+		return override.Async, nil
+	}
+	if len(details.Async) == 0 {
+		return nil, nil
+	}
+	var enabled bool
+	if err := json.Unmarshal(details.Async, &enabled); err == nil {
+		if !enabled {
+			return nil, nil
+		}
+		return &models.AsyncConfig{}, nil
+	}
+	async := &models.AsyncConfig{}
+	if err := json.Unmarshal(details.Async, async); err != nil {
+		return nil, fmt.Errorf("invalid x-mcp-async: %v", err)
+	}
+	return async, nil
+}
+
+func newAsyncOperation(cfg models.AsyncConfig, baseURL string) (asyncOperation, error) {
+	a := asyncOperation{AsyncConfig: cfg, baseURL: baseURL, pollInterval: DefaultAsyncPollInterval, timeout: DefaultAsyncTimeout}
+	if cfg.PollInterval != "" {
+		d, err := time.ParseDuration(cfg.PollInterval)
+		if err != nil || d <= 0 {
+			return a, fmt.Errorf("pollInterval: must be a positive duration, got %q", cfg.PollInterval)
+		}
+		a.pollInterval = d
+	}
+	if cfg.Timeout != "" {
+		d, err := time.ParseDuration(cfg.Timeout)
+		if err != nil || d <= 0 {
+			return a, fmt.Errorf("timeout: must be a positive duration, got %q", cfg.Timeout)
+		}
+		a.timeout = d
+	}
+	if len(a.DoneValues) == 0 {
+		a.DoneValues = defaultDoneValues
+	}
+	if len(a.FailedValues) == 0 {
+		a.FailedValues = defaultFailedValues
+	}
+	return a, nil
+}
+
+// createAsyncToolHandler works like createToolHandler, but when the API answers 202 Accepted the call polls the
+// status URL until the operation completes, sending progress notifications if the client asked for them, and
+// returns the final resource. Cancelling the call stops polling and, if configured, cancels the operation.
+// The final resource is checked against the responses of the operation if it declares one for its status.
+func createAsyncToolHandler(
+	async asyncOperation,
+	reqPathParam []string,
+	reqQueryParam []string,
+	reqURL string,
+	reqBody map[string]string,
+	reqMethod string,
+	reqHeader []string,
+	apiCfg models.ApiConfig,
+	responses *responseSchemas,
+) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		resp, err := callAPI(ctx, request.GetArguments(), reqPathParam, reqQueryParam, reqURL, reqBody, reqMethod, reqHeader, apiCfg)
+		accepted := err == nil && resp.status == http.StatusAccepted
+		if accepted {
+			resp, err = async.wait(ctx, request, reqURL, resp, apiCfg)
+		}
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("[Error] %v", err)), nil
+		}
+		result := mcp.NewToolResultText(string(resp.body))
+		if responses != nil && accepted {
+			// the final resource comes from the status or result URL, which the spec may not describe
+			if _, ok := responses.declared(resp.status); !ok {
+				return result, nil
+			}
+		}
+		checkResponse(ctx, responses, apiCfg.ValidateResponses, resp, result)
+		return result, nil
+	}
+}
+
+// wait polls the status of an accepted operation until it completes, fails, times out or the call is cancelled.
+func (a asyncOperation) wait(ctx context.Context, request mcp.CallToolRequest, reqURL string, accepted apiResponse, apiCfg models.ApiConfig) (apiResponse, error) {
+	statusURL, err := a.statusURL(reqURL, accepted)
+	if err != nil {
+		return apiResponse{}, err
+	}
+	progress := newProgressReporter(ctx, request)
+	progress.report(0, 0, "Operation accepted")
+	deadline := time.Now().Add(a.timeout)
+	delay := retryAfter(accepted.header, a.pollInterval)
+	for polls := 1; ; polls++ {
+		if time.Now().Add(delay).After(deadline) {
+			return apiResponse{}, fmt.Errorf("operation did not complete within %s, its status is at %s", a.timeout, statusURL)
+		}
+		select {
+		case <-ctx.Done():
+			a.cancel(ctx, statusURL, apiCfg)
+			return apiResponse{}, fmt.Errorf("operation cancelled, its status is at %s", statusURL)
+		case <-time.After(delay):
+		}
+
+		resp, err := callAPI(ctx, nil, nil, nil, statusURL, nil, "get", nil, apiCfg)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			return apiResponse{}, err
+		}
+		if resp.status == http.StatusAccepted {
+			progress.report(float64(polls), 0, "Operation running")
+			delay = retryAfter(resp.header, a.pollInterval)
+			continue
+		}
+		if resp.status < 200 || resp.status >= 300 {
+			return apiResponse{}, fmt.Errorf("status URL returned %d: %s", resp.status, truncate(string(resp.body), 200))
+		}
+
+		var doc interface{}
+		json.Unmarshal(resp.body, &doc)
+		if a.StatusField != "" {
+			value := jsonField(doc, a.StatusField)
+			if value == nil {
+				// no state, e.g. the status URL redirected to the final resource
+				return resp, nil
+			}
+			state := scalarString(value)
+			if containsFold(a.FailedValues, state) {
+				return apiResponse{}, fmt.Errorf("operation %s: %s", state, truncate(string(resp.body), 500))
+			}
+			if !containsFold(a.DoneValues, state) {
+				done, total := float64(polls), 0.0
+				if percent, ok := jsonField(doc, a.ProgressField).(float64); a.ProgressField != "" && ok {
+					done, total = percent, 100
+				}
+				progress.report(done, total, "Operation "+state)
+				delay = retryAfter(resp.header, a.pollInterval)
+				continue
+			}
+		}
+
+		resultURL := resp.header.Get("Location")
+		if a.ResultField != "" {
+			if value, ok := jsonField(doc, a.ResultField).(string); ok && value != "" {
+				resultURL = value
+			}
+		}
+		if resultURL == "" {
+			return resp, nil
+		}
+		return a.result(ctx, reqURL, statusURL, resultURL, apiCfg)
+	}
+}
+
+// statusURL returns where the status of an accepted operation is polled: the configured pattern filled in
+// from the 202 response, or else its Location header.
+func (a asyncOperation) statusURL(reqURL string, accepted apiResponse) (string, error) {
+	if a.StatusUrl == "" {
+		location := accepted.header.Get("Location")
+		if location == "" {
+			return "", fmt.Errorf("API answered 202 Accepted without a Location header to poll")
+		}
+		statusURL, err := resolveURL(reqURL, location)
+		if err != nil {
+			return "", err
+		}
+		if err := checkSameOrigin(reqURL, statusURL); err != nil {
+			return "", fmt.Errorf("not polling the status URL: %v", err)
+		}
+		return statusURL, nil
+	}
+	var doc interface{}
+	json.Unmarshal(accepted.body, &doc)
+	var missing []string
+	statusURL := statusURLField.ReplaceAllStringFunc(a.StatusUrl, func(placeholder string) string {
+		field := strings.Trim(placeholder, "{}")
+		value := jsonField(doc, field)
+		if value == nil {
+			missing = append(missing, field)
+			return ""
+		}
+		return url.PathEscape(scalarString(value))
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("202 Accepted response has no %s for the status URL", strings.Join(missing, ", "))
+	}
+	if !strings.HasPrefix(statusURL, "http://") && !strings.HasPrefix(statusURL, "https://") {
+		statusURL = strings.TrimSuffix(a.baseURL, "/") + "/" + strings.TrimPrefix(statusURL, "/")
+	}
+	if err := checkSameOrigin(reqURL, statusURL); err != nil {
+		return "", fmt.Errorf("not polling the status URL: %v", err)
+	}
+	return statusURL, nil
+}
+
+// result fetches the final resource of a completed operation.
+func (a asyncOperation) result(ctx context.Context, reqURL, statusURL, resultURL string, apiCfg models.ApiConfig) (apiResponse, error) {
+	resultURL, err := resolveURL(statusURL, resultURL)
+	if err != nil {
+		return apiResponse{}, err
+	}
+	if err := checkSameOrigin(reqURL, resultURL); err != nil {
+		return apiResponse{}, fmt.Errorf("operation completed, but not fetching its result: %v", err)
+	}
+	resp, err := callAPI(ctx, nil, nil, nil, resultURL, nil, "get", nil, apiCfg)
+	if err != nil {
+		return apiResponse{}, err
+	}
+	if resp.status < 200 || resp.status >= 300 {
+		return apiResponse{}, fmt.Errorf("operation completed, but its result at %s returned %d: %s", resultURL, resp.status, truncate(string(resp.body), 200))
+	}
+	return resp, nil
+}
+
+// cancel asks the API to cancel the operation, if a cancel method is configured.
+func (a asyncOperation) cancel(ctx context.Context, statusURL string, apiCfg models.ApiConfig) {
+	if a.CancelMethod == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), asyncCancelTimeout)
+	defer cancel()
+	resp, err := callAPI(ctx, nil, nil, nil, statusURL, nil, a.CancelMethod, nil, apiCfg)
+	if err != nil {
+		log.Printf("Error cancelling operation %s: %v", statusURL, err)
+	} else if resp.status >= 300 {
+		log.Printf("Error cancelling operation %s: status %d", statusURL, resp.status)
+	}
+}
+
+// resolveURL resolves a URL from a response header or body against the URL of the request.
+func resolveURL(base, ref string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %s: %v", base, err)
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %s: %v", ref, err)
+	}
+	return baseURL.ResolveReference(refURL).String(), nil
+}
+
+// checkSameOrigin refuses a URL taken from a response that is not on the scheme and host of the request:
+// polls, result fetches and cancels are sent with the API credentials, which must not leak to another origin.
+func checkSameOrigin(reqURL, target string) error {
+	origin, err := url.Parse(reqURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %s: %v", reqURL, err)
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("invalid URL %s: %v", target, err)
+	}
+	if !strings.EqualFold(u.Scheme, origin.Scheme) || !strings.EqualFold(u.Host, origin.Host) {
+		return fmt.Errorf("%s is not on the API origin %s://%s", traceURL(target), origin.Scheme, origin.Host)
+	}
+	return nil
+}
+
+// retryAfter returns the delay asked for by a Retry-After header in seconds, or else the fallback.
+func retryAfter(header http.Header, fallback time.Duration) time.Duration {
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return fallback
+}
+
+// jsonField returns the value at a dot-separated path of a decoded JSON document, or nil if there is none.
+func jsonField(doc interface{}, path string) interface{} {
+	if path == "" {
+		return doc
+	}
+	for _, key := range strings.Split(path, ".") {
+		object, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		doc = object[key]
+	}
+	return doc
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// progressReporter sends progress notifications for a request, if the client sent a progress token with it.
+type progressReporter struct {
+	ctx       context.Context
+	mcpServer *server.MCPServer
+	token     mcp.ProgressToken
+	sent      bool
+	last      float64
+}
+
+func newProgressReporter(ctx context.Context, request mcp.CallToolRequest) *progressReporter {
+	p := &progressReporter{ctx: ctx, mcpServer: server.ServerFromContext(ctx)}
+	if request.Params.Meta != nil {
+		p.token = request.Params.Meta.ProgressToken
+	}
+	return p
+}
+
+// report sends a progress notification; total is left out if zero. Progress must increase with every
+// notification, so reports that do not advance it are dropped.
+func (p *progressReporter) report(progress, total float64, message string) {
+	if p.token == nil || p.mcpServer == nil || p.sent && progress <= p.last {
+		return
+	}
+	p.sent, p.last = true, progress
+	params := map[string]any{"progressToken": p.token, "progress": progress, "message": message}
+	if total > 0 {
+		params["total"] = total
+	}
+	if err := p.mcpServer.SendNotificationToClient(p.ctx, progressNotification, params); err != nil {
+		log.Printf("Error sending progress notification: %v", err)
+	}
+}
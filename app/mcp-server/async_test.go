@@ -0,0 +1,270 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// testSession is a client session that keeps the notifications sent to it.
+type testSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+	level         mcp.LoggingLevel
+}
+
+func newTestSession(id string) *testSession {
+	return &testSession{id: id, notifications: make(chan mcp.JSONRPCNotification, 100)}
+}
+
+func (s *testSession) SessionID() string                                   { return s.id }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return s.notifications }
+func (s *testSession) Initialize()                                         {}
+func (s *testSession) Initialized() bool                                   { return true }
+func (s *testSession) SetLogLevel(level mcp.LoggingLevel)                  { s.level = level }
+
+func (s *testSession) GetLogLevel() mcp.LoggingLevel {
+	if s.level == "" {
+		return mcp.LoggingLevelError
+	}
+	return s.level
+}
+
+// asyncAPI serves POST /imports, which is accepted and completes after the given number of status polls.
+type asyncAPI struct {
+	*httptest.Server
+	mu        sync.Mutex
+	polls     int
+	cancelled bool
+}
+
+func newAsyncAPI(t *testing.T, running int, status func(w http.ResponseWriter, done bool)) *asyncAPI {
+	api := &asyncAPI{}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/imports":
+			w.Header().Set("Location", "/jobs/7")
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"id": "7"}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/jobs/7":
+			api.cancelled = true
+		case r.URL.Path == "/jobs/7":
+			api.polls++
+			status(w, api.polls > running)
+		case r.URL.Path == "/results/7":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"imported": 42}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(api.Close)
+	return api
+}
+
+func asyncSpec(extension string) models.SwaggerSpec {
+	var spec models.SwaggerSpec
+	json.Unmarshal([]byte(fmt.Sprintf(`{
+	  "openapi": "3.0.0",
+	  "paths": {"/imports": {"post": {"operationId": "importUsers", %s "responses": {"202": {"description": "Accepted"}}}}}
+	}`, extension)), &spec)
+	return spec
+}
+
+func callImport(t *testing.T, tools []server.ServerTool, ctx context.Context) (string, bool) {
+	t.Helper()
+	if len(tools) != 1 {
+		t.Fatalf("expected one tool, got %d", len(tools))
+	}
+	result, err := tools[0].Handler(ctx, mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Content[0].(mcp.TextContent).Text, result.IsError
+}
+
+func TestAsyncTool_Location(t *testing.T) {
+	api := newAsyncAPI(t, 2, func(w http.ResponseWriter, done bool) {
+		if done {
+			w.Header().Set("Location", "/results/7")
+			w.WriteHeader(http.StatusSeeOther)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	tools := BuildSwaggerTools(asyncSpec(""), models.ApiConfig{
+		BaseUrl:   api.URL,
+		Overrides: map[string]models.OperationOverride{"importUsers": {Async: &models.AsyncConfig{PollInterval: "10ms"}}},
+	})
+	text, isError := callImport(t, tools, context.Background())
+	if isError || text != `{"imported": 42}` || api.polls != 3 {
+		t.Errorf("unexpected result %q (error %v) after %d polls", text, isError, api.polls)
+	}
+
+	// Without the override the 202 response is returned as it is
+	tools = BuildSwaggerTools(asyncSpec(""), models.ApiConfig{BaseUrl: api.URL})
+	if text, _ = callImport(t, tools, context.Background()); text != `{"id": "7"}` {
+		t.Errorf("expected the accepted response, got %q", text)
+	}
+}
+
+func TestAsyncTool_StatusFieldAndProgress(t *testing.T) {
+	api := newAsyncAPI(t, 2, func(w http.ResponseWriter, done bool) {
+		if done {
+			w.Write([]byte(`{"job": {"state": "Succeeded"}, "result": "/results/7"}`))
+			return
+		}
+		w.Write([]byte(`{"job": {"state": "running", "percent": 40}}`))
+	})
+	spec := asyncSpec(`"x-mcp-async": {"statusUrl": "/jobs/{id}", "statusField": "job.state", "progressField": "job.percent", "resultField": "result", "pollInterval": "10ms"},`)
+
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+	mcpServer.AddTools(BuildSwaggerTools(spec, models.ApiConfig{BaseUrl: api.URL})...)
+	session := newTestSession("s1")
+	if err := mcpServer.RegisterSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	ctx := mcpServer.WithContext(context.Background(), session)
+	resp := mcpServer.HandleMessage(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"post_/imports","_meta":{"progressToken":"p1"}}}`))
+	data, _ := json.Marshal(resp)
+	if !strings.Contains(string(data), `{\"imported\": 42}`) {
+		t.Errorf("expected the final resource, got %s", data)
+	}
+
+	progress := []string{}
+	for len(session.notifications) > 0 {
+		notification := <-session.notifications
+		params := notification.Params.AdditionalFields
+		if notification.Method != progressNotification || params["progressToken"] != "p1" {
+			t.Errorf("unexpected notification %+v", notification)
+		}
+		progress = append(progress, fmt.Sprintf("%v/%v %v", params["progress"], params["total"], params["message"]))
+	}
+	// The second running poll reports the same percentage and is left out
+	want := "[0/<nil> Operation accepted 40/100 Operation running]"
+	if fmt.Sprint(progress) != want {
+		t.Errorf("got progress %v, want %s", progress, want)
+	}
+}
+
+func TestAsyncTool_Failed(t *testing.T) {
+	api := newAsyncAPI(t, 0, func(w http.ResponseWriter, done bool) {
+		w.Write([]byte(`{"status": "failed", "error": "bad CSV"}`))
+	})
+	tools := BuildSwaggerTools(asyncSpec(`"x-mcp-async": {"statusField": "status", "pollInterval": "10ms"},`), models.ApiConfig{BaseUrl: api.URL})
+	if text, isError := callImport(t, tools, context.Background()); !isError || !strings.Contains(text, "operation failed") || !strings.Contains(text, "bad CSV") {
+		t.Errorf("expected the failure to be reported, got %q", text)
+	}
+}
+
+func TestAsyncTool_Timeout(t *testing.T) {
+	api := newAsyncAPI(t, 1000, func(w http.ResponseWriter, done bool) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	tools := BuildSwaggerTools(asyncSpec(`"x-mcp-async": {"pollInterval": "10ms", "timeout": "50ms"},`), models.ApiConfig{BaseUrl: api.URL})
+	if text, isError := callImport(t, tools, context.Background()); !isError || !strings.Contains(text, "did not complete within 50ms") || !strings.Contains(text, api.URL+"/jobs/7") {
+		t.Errorf("expected a timeout with the status URL, got %q", text)
+	}
+}
+
+func TestAsyncTool_CancelledByClient(t *testing.T) {
+	api := newAsyncAPI(t, 1000, func(w http.ResponseWriter, done bool) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	spec := asyncSpec(`"x-mcp-async": {"pollInterval": "10ms", "cancelMethod": "DELETE"},`)
+	mcpServer := newMCPServer("1.0.0", newSessionStore(models.Config{}), newLifecycle(), newCompletionSet())
+	mcpServer.AddTools(BuildSwaggerTools(spec, models.ApiConfig{BaseUrl: api.URL})...)
+	session := newTestSession("s1")
+	mcpServer.RegisterSession(context.Background(), session)
+	ctx := mcpServer.WithContext(context.Background(), session)
+
+	done := make(chan string)
+	go func() {
+		resp := mcpServer.HandleMessage(ctx, json.RawMessage(`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"post_/imports"}}`))
+		data, _ := json.Marshal(resp)
+		done <- string(data)
+	}()
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		api.mu.Lock()
+		polls := api.polls
+		api.mu.Unlock()
+		if polls > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the status URL was never polled")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// A cancellation from another session does not affect the call
+	other := newTestSession("s2")
+	mcpServer.RegisterSession(context.Background(), other)
+	mcpServer.HandleMessage(mcpServer.WithContext(context.Background(), other), json.RawMessage(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":5}}`))
+	select {
+	case data := <-done:
+		t.Fatalf("call ended after another session's cancellation: %s", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	mcpServer.HandleMessage(ctx, json.RawMessage(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":5,"reason":"user abort"}}`))
+	select {
+	case data := <-done:
+		if !strings.Contains(data, "operation cancelled") {
+			t.Errorf("expected the call to be cancelled, got %s", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("call was not cancelled")
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if !api.cancelled {
+		t.Error("expected the operation to be cancelled with DELETE on the status URL")
+	}
+}
+
+func TestAsyncTool_CrossOriginURLs(t *testing.T) {
+	leaked := []string{}
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = append(leaked, r.URL.Path+" "+r.Header.Get("Authorization"))
+		w.Write([]byte(`{"status": "done"}`))
+	}))
+	defer other.Close()
+
+	// a Location on another host is not polled
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", other.URL+"/jobs/7")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer api.Close()
+	apiCfg := models.ApiConfig{BaseUrl: api.URL, Security: "bearer", BearerAuth: "s3cr3t-token"}
+	tools := BuildSwaggerTools(asyncSpec(`"x-mcp-async": {"pollInterval": "10ms"},`), apiCfg)
+	if text, isError := callImport(t, tools, context.Background()); !isError || !strings.Contains(text, "is not on the API origin "+api.URL) {
+		t.Errorf("expected the status URL to be refused, got %q", text)
+	}
+
+	// neither is a result on another host
+	jobs := newAsyncAPI(t, 0, func(w http.ResponseWriter, done bool) {
+		w.Write([]byte(`{"status": "done", "result": "` + other.URL + `/results/7"}`))
+	})
+	apiCfg.BaseUrl = jobs.URL
+	tools = BuildSwaggerTools(asyncSpec(`"x-mcp-async": {"statusField": "status", "resultField": "result", "pollInterval": "10ms"},`), apiCfg)
+	if text, isError := callImport(t, tools, context.Background()); !isError || !strings.Contains(text, "not fetching its result") {
+		t.Errorf("expected the result URL to be refused, got %q", text)
+	}
+	if len(leaked) > 0 {
+		t.Errorf("expected no request to the other host, got %v", leaked)
+	}
+}
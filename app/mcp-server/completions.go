@@ -0,0 +1,370 @@
+package mcpserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// DefaultLookupTTL is how long the values returned by a lookup operation are cached when no TTL is configured.
+const DefaultLookupTTL = 5 * time.Minute
+
+// maxCompletionValues is the most values a completion may return.
+const maxCompletionValues = 100
+
+// completionSet completes the prompt arguments and resource template variables of every spec served by one
+// MCP server, with the enum of the matching parameter or the values returned by its lookup operation.
+// It is the server's server.PromptCompletionProvider and server.ResourceCompletionProvider.
+type completionSet struct {
+	mu      sync.RWMutex
+	bySpec  map[*specReloader]specCompletions
+	lookups *lookupCache
+}
+
+func newCompletionSet() *completionSet {
+	return &completionSet{bySpec: map[*specReloader]specCompletions{}, lookups: newLookupCache()}
+}
+
+// specCompletions holds where the values of the prompt arguments and template variables of one spec come from.
+type specCompletions struct {
+	prompts   map[string]map[string]valueSource // prompt name -> argument -> values
+	templates map[string]map[string]valueSource // URI template -> variable -> values
+	vars      map[string]map[string]string      // URI template -> variable -> parameter name
+}
+
+// valueSource lists the values of a parameter: the values returned by its lookup operation, or its enum.
+type valueSource struct {
+	enum   []string
+	lookup *lookupCall
+}
+
+// lookupCall is a lookup operation ready to be called.
+type lookupCall struct {
+	name        string // parameter completed by the lookup
+	reqURL      string
+	pathParams  []string
+	queryParams []string
+	required    map[string]bool // query parameters that are always sent
+	lookup      models.Lookup
+	apiCfg      models.ApiConfig
+}
+
+// set replaces the completions of a spec.
+func (c *completionSet) set(spec *specReloader, completions specCompletions) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bySpec[spec] = completions
+}
+
+// CompletePromptArgument completes an argument of a prompt.
+func (c *completionSet) CompletePromptArgument(ctx context.Context, promptName string, argument mcp.CompleteArgument, context mcp.CompleteContext) (*mcp.Completion, error) {
+	c.mu.RLock()
+	var source valueSource
+	found := false
+	for _, completions := range c.bySpec {
+		if source, found = completions.prompts[promptName][argument.Name]; found {
+			break
+		}
+	}
+	c.mu.RUnlock()
+	if !found {
+		return &mcp.Completion{Values: []string{}}, nil
+	}
+	return c.complete(ctx, source, argument.Value, context.Arguments)
+}
+
+// CompleteResourceArgument completes a variable of a resource template. The other variables already filled in
+// are passed to the lookup operation under the name of their parameter.
+func (c *completionSet) CompleteResourceArgument(ctx context.Context, uri string, argument mcp.CompleteArgument, context mcp.CompleteContext) (*mcp.Completion, error) {
+	c.mu.RLock()
+	var source valueSource
+	var vars map[string]string
+	found := false
+	for _, completions := range c.bySpec {
+		if source, found = completions.templates[uri][argument.Name]; found {
+			vars = completions.vars[uri]
+			break
+		}
+	}
+	c.mu.RUnlock()
+	if !found {
+		return &mcp.Completion{Values: []string{}}, nil
+	}
+	args := map[string]string{}
+	for v, value := range context.Arguments {
+		if param, ok := vars[v]; ok {
+			args[param] = value
+		}
+	}
+	return c.complete(ctx, source, argument.Value, args)
+}
+
+// complete returns the values of a source starting with the prefix typed so far, ignoring case.
+func (c *completionSet) complete(ctx context.Context, source valueSource, prefix string, args map[string]string) (*mcp.Completion, error) {
+	values := source.enum
+	if source.lookup != nil {
+		var err error
+		if values, err = c.lookups.values(ctx, source.lookup, args); err != nil {
+			return nil, fmt.Errorf("lookup of %s values failed: %v", source.lookup.name, err)
+		}
+	}
+	matches := []string{}
+	for _, value := range values {
+		if strings.HasPrefix(strings.ToLower(value), strings.ToLower(prefix)) {
+			matches = append(matches, value)
+		}
+	}
+	completion := &mcp.Completion{Values: matches, Total: len(matches)}
+	if len(matches) > maxCompletionValues {
+		completion.Values = matches[:maxCompletionValues]
+		completion.HasMore = true
+	}
+	return completion, nil
+}
+
+// buildSpecCompletions finds the values of the prompt arguments and template variables of a spec. A template
+// variable is completed with the lookup configured for its parameter, or else the parameter's enum. A prompt
+// argument is completed like the parameters of the same name.
+func buildSpecCompletions(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig, templates []resourceTemplate, prompts []models.PromptConfig) specCompletions {
+	lookups := specLookups(swaggerSpec, apiCfg)
+	enums := map[string][]string{}
+	for _, methods := range swaggerSpec.Paths {
+		for _, details := range methods {
+			for _, param := range details.Parameters {
+				enums[param.Name] = appendUnique(enums[param.Name], paramEnum(param)...)
+			}
+		}
+	}
+	source := func(name string, enum []string) (valueSource, bool) {
+		if lookup, ok := lookups[name]; ok {
+			return valueSource{lookup: lookup}, true
+		}
+		return valueSource{enum: enum}, len(enum) > 0
+	}
+
+	completions := specCompletions{
+		prompts:   map[string]map[string]valueSource{},
+		templates: map[string]map[string]valueSource{},
+		vars:      map[string]map[string]string{},
+	}
+	for _, template := range templates {
+		uri := template.Template.URITemplate.Raw()
+		sources := map[string]valueSource{}
+		vars := map[string]string{}
+		for v, param := range template.params {
+			vars[v] = param.Name
+			if s, ok := source(param.Name, paramEnum(param)); ok {
+				sources[v] = s
+			}
+		}
+		completions.templates[uri] = sources
+		completions.vars[uri] = vars
+	}
+	for _, prompt := range prompts {
+		sources := map[string]valueSource{}
+		for _, arg := range prompt.Arguments {
+			if s, ok := source(arg.Name, enums[arg.Name]); ok {
+				sources[arg.Name] = s
+			}
+		}
+		completions.prompts[buildOverrideToolName(apiCfg.ToolPrefix, prompt.Name)] = sources
+	}
+	return completions
+}
+
+// specLookups resolves the lookup operations configured for a spec, keyed by the parameter they complete.
+// Lookups naming an operation that is not in the spec are logged and skipped.
+func specLookups(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig) map[string]*lookupCall {
+	if apiCfg.SessionApiKey.Name == "" {
+		apiCfg.SessionApiKey = specApiKeyLocation(swaggerSpec)
+	}
+	names := make([]string, 0, len(apiCfg.Lookups))
+	for name := range apiCfg.Lookups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	calls := map[string]*lookupCall{}
+	for _, name := range names {
+		lookup := apiCfg.Lookups[name]
+		call := findLookupCall(swaggerSpec, apiCfg, lookup)
+		if call == nil {
+			log.Printf("Ignoring lookup for %s: no GET operation %s in the spec", name, lookup.Operation)
+			continue
+		}
+		call.name = name
+		calls[name] = call
+	}
+	return calls
+}
+
+// findLookupCall finds the GET operation of a lookup, by operationId or "GET /path".
+func findLookupCall(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig, lookup models.Lookup) *lookupCall {
+	for path, methods := range swaggerSpec.Paths {
+		for method, details := range methods {
+			if !strings.EqualFold(method, "get") || !selectsOperation([]string{lookup.Operation}, details.OperationID, path, false) {
+				continue
+			}
+			call := &lookupCall{
+				reqURL:   strings.TrimSuffix(specBaseURL(swaggerSpec, apiCfg), "/") + "/" + strings.TrimPrefix(path, "/"),
+				required: map[string]bool{},
+				lookup:   lookup,
+				apiCfg:   apiCfg,
+			}
+			override := findOverride(apiCfg.Overrides, details.OperationID, method, path)
+			if len(override.Headers) > 0 {
+				call.apiCfg.HeaderValues = mergeHeaders(apiCfg.HeaderValues, override.Headers)
+			}
+			for _, param := range details.Parameters {
+				switch param.In {
+				case "path":
+					call.pathParams = append(call.pathParams, param.Name)
+				case "query":
+					call.queryParams = append(call.queryParams, param.Name)
+					call.required[param.Name] = param.Required
+				}
+			}
+			return call
+		}
+	}
+	return nil
+}
+
+// lookupCache caches the values returned by lookup operations, per operation, arguments and client session.
+type lookupCache struct {
+	mu      sync.Mutex
+	entries map[string]lookupEntry
+	now     func() time.Time
+}
+
+type lookupEntry struct {
+	values  []string
+	expires time.Time
+}
+
+func newLookupCache() *lookupCache {
+	return &lookupCache{entries: map[string]lookupEntry{}, now: time.Now}
+}
+
+// values returns the values listed by a lookup operation, calling it unless a cached response is still fresh.
+// Arguments matching a parameter of the operation are passed to it.
+func (c *lookupCache) values(ctx context.Context, call *lookupCall, args map[string]string) ([]string, error) {
+	reqArgs := map[string]interface{}{}
+	queries := []string{}
+	for _, param := range call.pathParams {
+		if value, ok := args[param]; ok {
+			reqArgs[param] = value
+		}
+	}
+	for _, param := range call.queryParams {
+		if value, ok := args[param]; ok && value != "" {
+			reqArgs[param] = value
+			queries = append(queries, param)
+		} else if call.required[param] {
+			queries = append(queries, param)
+		}
+	}
+	key, err := lookupKey(ctx, call, reqArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.values, nil
+	}
+
+	resp, err := callAPI(ctx, reqArgs, call.pathParams, queries, call.reqURL, nil, "get", nil, call.apiCfg)
+	if err != nil {
+		return nil, err
+	}
+	if resp.status < 200 || resp.status >= 300 {
+		return nil, fmt.Errorf("API returned status %d: %s", resp.status, truncate(string(resp.body), 200))
+	}
+	values, err := lookupValues(resp.body, call.lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := call.lookup.TTL
+	if ttl <= 0 {
+		ttl = DefaultLookupTTL
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = lookupEntry{values: values, expires: now.Add(ttl)}
+	return values, nil
+}
+
+// lookupKey identifies a lookup response in the cache. Clients with their own credentials may be allowed to
+// see different values, so the key includes the session of the caller.
+func lookupKey(ctx context.Context, call *lookupCall, args map[string]interface{}) (string, error) {
+	session, _ := ctx.Value(sessionKey).(Session)
+	data, err := json.Marshal([]interface{}{call.reqURL, call.lookup, args, session})
+	if err != nil {
+		return "", fmt.Errorf("error encoding lookup: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// lookupValues extracts the values from the JSON response of a lookup operation: the list at the items path,
+// and of each item the field, if set.
+func lookupValues(body []byte, lookup models.Lookup) ([]string, error) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("response is not JSON: %v", err)
+	}
+	items, ok := jsonField(doc, lookup.Items).([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a list at %q in the response", lookup.Items)
+	}
+	values := []string{}
+	seen := map[string]bool{}
+	for _, item := range items {
+		item = jsonField(item, lookup.Field)
+		switch item.(type) {
+		case string, float64, bool:
+			if value := scalarString(item); !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+	}
+	return values, nil
+}
+
+// appendUnique appends the values that are not in the list yet.
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -0,0 +1,225 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const completionsSpec = `{
+  "openapi": "3.0.0",
+  "paths": {
+    "/regions": {"get": {
+      "operationId": "listRegions",
+      "parameters": [{"name": "cloud", "in": "query"}],
+      "responses": {"200": {"description": "Regions"}}
+    }},
+    "/servers/{region}": {"get": {
+      "operationId": "getServers",
+      "parameters": [
+        {"name": "region", "in": "path", "required": true},
+        {"name": "status", "in": "query", "schema": {"type": "string", "enum": ["running", "stopped", "starting"]}}
+      ],
+      "responses": {"200": {"description": "Servers"}}
+    }}
+  }
+}`
+
+func newCompletionServer(t *testing.T, apiCfg models.ApiConfig) *server.MCPServer {
+	t.Helper()
+	completions := newCompletionSet()
+	mcpServer := server.NewMCPServer("test", "1.0.0",
+		server.WithCompletions(),
+		server.WithPromptCompletionProvider(completions),
+		server.WithResourceCompletionProvider(completions),
+	)
+	r := newSpecReloader(mcpServer, models.SpecConfig{ApiCfg: apiCfg})
+	r.completions = completions
+	if _, err := r.apply(parseTestSpec(t, completionsSpec)); err != nil {
+		t.Fatal(err)
+	}
+	return mcpServer
+}
+
+func complete(t *testing.T, mcpServer *server.MCPServer, ref string, name, value string, args map[string]string) (mcp.Completion, bool) {
+	t.Helper()
+	params, _ := json.Marshal(map[string]interface{}{
+		"ref":      json.RawMessage(ref),
+		"argument": map[string]string{"name": name, "value": value},
+		"context":  map[string]interface{}{"arguments": args},
+	})
+	resp := mcpServer.HandleMessage(context.Background(), json.RawMessage(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"completion/complete","params":%s}`, params)))
+	data, _ := json.Marshal(resp)
+	var decoded struct {
+		Result *mcp.CompleteResult `json:"result"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Result == nil {
+		return mcp.Completion{}, false
+	}
+	return decoded.Result.Completion, true
+}
+
+func TestBuildSwaggerTools_Enum(t *testing.T) {
+	tools := BuildSwaggerTools(parseTestSpec(t, completionsSpec), models.ApiConfig{})
+	for _, tool := range tools {
+		if tool.Tool.Name != "get_/servers/region" {
+			continue
+		}
+		status, _ := tool.Tool.InputSchema.Properties["status"].(map[string]interface{})
+		if fmt.Sprint(status["enum"]) != "[running stopped starting]" {
+			t.Errorf("expected the enum in the tool schema, got %v", status)
+		}
+		return
+	}
+	t.Fatal("tool not found")
+}
+
+func TestCompletions_Enum(t *testing.T) {
+	mcpServer := newCompletionServer(t, models.ApiConfig{
+		ResourceTemplates: "getServers",
+		Prompts: []models.PromptConfig{{
+			Name:      "restart",
+			Arguments: []models.PromptArgument{{Name: "status"}, {Name: "reason"}},
+			Messages:  []models.PromptMessage{{Text: "Restart the {status} servers because {reason}"}},
+		}},
+	})
+
+	completion, ok := complete(t, mcpServer, `{"type":"ref/resource","uri":"api://servers/{region}{?status}"}`, "status", "st", nil)
+	if !ok || fmt.Sprint(completion.Values) != "[stopped starting]" || completion.Total != 2 {
+		t.Errorf("unexpected template completion %+v", completion)
+	}
+	completion, ok = complete(t, mcpServer, `{"type":"ref/prompt","name":"restart"}`, "status", "R", nil)
+	if !ok || fmt.Sprint(completion.Values) != "[running]" {
+		t.Errorf("unexpected prompt completion %+v", completion)
+	}
+	// Arguments without an enum or lookup have no completions
+	if completion, ok = complete(t, mcpServer, `{"type":"ref/prompt","name":"restart"}`, "reason", "", nil); !ok || len(completion.Values) != 0 {
+		t.Errorf("expected no completions, got %+v", completion)
+	}
+}
+
+func TestCompletions_Lookup(t *testing.T) {
+	requests := []string{}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.String())
+		if r.URL.Query().Get("cloud") == "gov" {
+			w.Write([]byte(`{"data": [{"code": "us-gov-west"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data": [{"code": "eu-west"}, {"code": "eu-north"}, {"code": "us-east"}, {"name": "no code"}]}`))
+	}))
+	defer api.Close()
+
+	mcpServer := newCompletionServer(t, models.ApiConfig{
+		BaseUrl:           api.URL,
+		ResourceTemplates: "getServers",
+		Lookups:           map[string]models.Lookup{"region": {Operation: "listRegions", Items: "data", Field: "code"}},
+		Prompts: []models.PromptConfig{{
+			Name:      "deploy",
+			Arguments: []models.PromptArgument{{Name: "region", Required: true}, {Name: "cloud"}},
+			Messages:  []models.PromptMessage{{Text: "Deploy to {region}"}},
+		}},
+	})
+
+	ref := `{"type":"ref/prompt","name":"deploy"}`
+	completion, ok := complete(t, mcpServer, ref, "region", "eu", nil)
+	if !ok || fmt.Sprint(completion.Values) != "[eu-west eu-north]" {
+		t.Errorf("unexpected lookup completion %+v", completion)
+	}
+	// The lookup response is cached
+	if completion, _ = complete(t, mcpServer, ref, "region", "us", nil); fmt.Sprint(completion.Values) != "[us-east]" || len(requests) != 1 {
+		t.Errorf("expected a cached lookup, got %+v after requests %v", completion, requests)
+	}
+	// Arguments already filled in are passed to the lookup operation
+	if completion, _ = complete(t, mcpServer, ref, "region", "", map[string]string{"cloud": "gov"}); fmt.Sprint(completion.Values) != "[us-gov-west]" {
+		t.Errorf("unexpected lookup completion %+v", completion)
+	}
+	if requests[len(requests)-1] != "/regions?cloud=gov" {
+		t.Errorf("unexpected lookup request %v", requests)
+	}
+
+	completion, ok = complete(t, mcpServer, `{"type":"ref/resource","uri":"api://servers/{region}{?status}"}`, "region", "EU-N", nil)
+	if !ok || fmt.Sprint(completion.Values) != "[eu-north]" {
+		t.Errorf("unexpected template completion %+v", completion)
+	}
+}
+
+func TestCompletions_LookupError(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer api.Close()
+	mcpServer := newCompletionServer(t, models.ApiConfig{
+		BaseUrl:           api.URL,
+		ResourceTemplates: "getServers",
+		Lookups:           map[string]models.Lookup{"region": {Operation: "GET /regions"}},
+	})
+	if _, ok := complete(t, mcpServer, `{"type":"ref/resource","uri":"api://servers/{region}{?status}"}`, "region", "", nil); ok {
+		t.Error("expected an error when the lookup fails")
+	}
+}
+
+func TestLookupCache_Expiry(t *testing.T) {
+	calls := 0
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`["a", "b"]`))
+	}))
+	defer api.Close()
+
+	now := time.Now()
+	cache := newLookupCache()
+	cache.now = func() time.Time { return now }
+	call := &lookupCall{name: "x", reqURL: api.URL, lookup: models.Lookup{TTL: time.Minute}}
+	for i := 0; i < 2; i++ {
+		if values, err := cache.values(context.Background(), call, nil); err != nil || fmt.Sprint(values) != "[a b]" {
+			t.Fatalf("unexpected values %v, %v", values, err)
+		}
+	}
+	now = now.Add(2 * time.Minute)
+	cache.values(context.Background(), call, nil)
+	if calls != 2 {
+		t.Errorf("expected the expired entry to be fetched again, got %d calls", calls)
+	}
+
+	// Sessions with their own credentials do not share cached values
+	ctx := context.WithValue(context.Background(), sessionKey, Session{BearerToken: "other"})
+	cache.values(ctx, call, nil)
+	if calls != 3 {
+		t.Errorf("expected a separate cache entry per session, got %d calls", calls)
+	}
+}
+
+func TestLookupValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		lookup models.Lookup
+		want   string
+	}{
+		{"list of values", `["a", 2, true, null, "a"]`, models.Lookup{}, "[a 2 true]"},
+		{"nested items", `{"result": {"items": [{"id": 1.5}, {"id": 7}]}}`, models.Lookup{Items: "result.items", Field: "id"}, "[1.5 7]"},
+		{"not a list", `{"items": {}}`, models.Lookup{Items: "items"}, "error"},
+		{"not JSON", `<html>`, models.Lookup{}, "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := lookupValues([]byte(tt.body), tt.lookup)
+			got := fmt.Sprint(values)
+			if err != nil {
+				got = "error"
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
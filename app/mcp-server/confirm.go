@@ -0,0 +1,172 @@
+package mcpserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// extensionConfirm requires (true) or skips (false) the confirmation of an operation's calls.
+	extensionConfirm = "x-mcp-confirm"
+	// confirmTokenArgument is the argument a call is repeated with to confirm it when the client cannot be asked.
+	confirmTokenArgument = "_confirmToken"
+	// confirmTokenTTL is how long a confirmation token can be used.
+	confirmTokenTTL = 5 * time.Minute
+)
+
+// operationConfirm reports whether the calls of an operation must be confirmed by the user: if its override or
+// its x-mcp-confirm extension says so, otherwise if confirmation of destructive operations is enabled and the
+// operation's tool is annotated destructive.
+func operationConfirm(apiCfg models.ApiConfig, override models.OperationOverride, details models.Endpoint, annotations mcp.ToolAnnotation) bool {
+	if override.Confirm != nil {
+		return *override.Confirm
+	}
+	if raw, ok := details.Extensions[extensionConfirm]; ok {
+		var confirm bool
+		if json.Unmarshal(raw, &confirm) == nil {
+			return confirm
+		}
+	}
+	return apiCfg.ConfirmDestructive && annotations.DestructiveHint != nil && *annotations.DestructiveHint
+}
+
+// pendingConfirmation is a call waiting to be repeated with its confirmation token.
+type pendingConfirmation struct {
+	sessionID string
+	tool      string
+	args      string // JSON of the call arguments
+	expires   time.Time
+}
+
+// confirmationStore holds the confirmation tokens handed out for the calls of a spec's tools.
+type confirmationStore struct {
+	mu      sync.Mutex
+	pending map[string]pendingConfirmation // token -> call it confirms
+}
+
+func newConfirmationStore() *confirmationStore {
+	return &confirmationStore{pending: map[string]pendingConfirmation{}}
+}
+
+// issue returns a new token confirming a call, valid for confirmTokenTTL.
+func (s *confirmationStore) issue(call pendingConfirmation) (string, error) {
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(data)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for t, pending := range s.pending {
+		if now.After(pending.expires) {
+			delete(s.pending, t)
+		}
+	}
+	call.expires = now.Add(confirmTokenTTL)
+	s.pending[token] = call
+	return token, nil
+}
+
+// redeem consumes a token and reports whether it was issued for this call and has not expired.
+func (s *confirmationStore) redeem(token string, call pendingConfirmation) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending, ok := s.pending[token]
+	if !ok {
+		return false
+	}
+	delete(s.pending, token)
+	return time.Now().Before(pending.expires) && pending.sessionID == call.sessionID && pending.tool == call.tool && pending.args == call.args
+}
+
+// confirmToolHandler wraps the handler of an operation whose calls must be confirmed. The resolved request is shown
+// to the user through MCP elicitation and only sent once they approve it. Clients that do not support elicitation
+// get the request back with a token instead, and the call is sent when it is repeated with that token.
+func confirmToolHandler(
+	toolName string,
+	confirmations *confirmationStore,
+	preview func(ctx context.Context, args map[string]interface{}) (requestPreview, error),
+	handler server.ToolHandlerFunc,
+) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := map[string]interface{}{}
+		for name, value := range request.GetArguments() {
+			args[name] = value
+		}
+		token, _ := args[confirmTokenArgument].(string)
+		delete(args, confirmTokenArgument)
+		request.Params.Arguments = args
+
+		req, err := preview(ctx, args)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("[Error] %v", err)), nil
+		}
+		encoded, _ := json.Marshal(args)
+		call := pendingConfirmation{tool: toolName, args: string(encoded)}
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			call.sessionID = session.SessionID()
+		}
+
+		if token != "" {
+			if !confirmations.redeem(token, call) {
+				return mcp.NewToolResultError(fmt.Sprintf("[Error] invalid or expired %s, call %s again without it to confirm the request", confirmTokenArgument, toolName)), nil
+			}
+			return handler(ctx, request)
+		}
+
+		approved, err := elicitConfirmation(ctx, req)
+		switch {
+		case errors.Is(err, server.ErrElicitationNotSupported):
+			token, err := confirmations.issue(call)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("[Error] %v", err)), nil
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("This request needs the user's confirmation and was not sent:\n\n%s\n\nShow it to the user. Only if they explicitly approve it, call %s again with the same arguments and %q: %q within %v.",
+				req, toolName, confirmTokenArgument, token, confirmTokenTTL)), nil
+		case err != nil:
+			return mcp.NewToolResultError(fmt.Sprintf("[Error] could not confirm the request: %v", err)), nil
+		case !approved:
+			return mcp.NewToolResultError(fmt.Sprintf("[Error] the user did not approve the request, it was not sent:\n\n%s", req)), nil
+		}
+		return handler(ctx, request)
+	}
+}
+
+// elicitConfirmation asks the user of the calling session to approve a request. It returns
+// server.ErrElicitationNotSupported if the client did not declare the elicitation capability.
+func elicitConfirmation(ctx context.Context, req requestPreview) (bool, error) {
+	mcpServer := server.ServerFromContext(ctx)
+	session := server.ClientSessionFromContext(ctx)
+	info, ok := session.(server.SessionWithClientInfo)
+	if mcpServer == nil || !ok || info.GetClientCapabilities().Elicitation == nil {
+		return false, server.ErrElicitationNotSupported
+	}
+	result, err := mcpServer.RequestElicitation(ctx, mcp.ElicitationRequest{
+		Params: mcp.ElicitationParams{
+			Message: fmt.Sprintf("Send this request?\n\n%s", req),
+			RequestedSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"confirm": map[string]interface{}{"type": "boolean", "title": "Send the request", "description": "Check to send the request to the API"},
+				},
+				"required": []string{"confirm"},
+			},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	content, _ := result.Content.(map[string]interface{})
+	return result.Action == mcp.ElicitationResponseActionAccept && content["confirm"] == true, nil
+}
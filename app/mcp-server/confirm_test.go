@@ -0,0 +1,163 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const confirmSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Shop", "version": "1.0.0"},
+  "paths": {
+    "/orders": {
+      "get": {"operationId": "listOrders", "responses": {"200": {"description": "Orders"}}},
+      "post": {"operationId": "createOrder", "responses": {"201": {"description": "Created"}}}
+    },
+    "/orders/{id}": {
+      "get": {"operationId": "getOrder", "x-mcp-confirm": true, "parameters": [{"name": "id", "in": "path", "required": true}],
+        "responses": {"200": {"description": "Order"}}},
+      "put": {"operationId": "replaceOrder", "x-mcp-confirm": false, "parameters": [{"name": "id", "in": "path", "required": true}],
+        "responses": {"200": {"description": "Order"}}},
+      "delete": {"operationId": "deleteOrder", "parameters": [{"name": "id", "in": "path", "required": true}],
+        "responses": {"204": {"description": "Deleted"}}}
+    }
+  }
+}`
+
+// confirmSession is a client session answering elicitation requests, if it declares the capability.
+type confirmSession struct {
+	*testSession
+	capabilities mcp.ClientCapabilities
+	answer       *mcp.ElicitationResult
+	messages     []string
+}
+
+func (s *confirmSession) GetClientInfo() mcp.Implementation              { return mcp.Implementation{} }
+func (s *confirmSession) SetClientInfo(mcp.Implementation)               {}
+func (s *confirmSession) GetClientCapabilities() mcp.ClientCapabilities  { return s.capabilities }
+func (s *confirmSession) SetClientCapabilities(c mcp.ClientCapabilities) { s.capabilities = c }
+
+func (s *confirmSession) RequestElicitation(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+	s.messages = append(s.messages, request.Params.Message)
+	return s.answer, nil
+}
+
+func newConfirmServer(t *testing.T, apiCfg models.ApiConfig, session server.ClientSession) (*server.MCPServer, context.Context) {
+	t.Helper()
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true), server.WithElicitation())
+	mcpServer.AddTools(BuildSwaggerTools(parseTestSpec(t, confirmSpec), apiCfg)...)
+	if err := mcpServer.RegisterSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	return mcpServer, mcpServer.WithContext(context.Background(), session)
+}
+
+func TestOperationConfirm(t *testing.T) {
+	spec := parseTestSpec(t, confirmSpec)
+	yes, no := true, false
+	tests := []struct {
+		method, path string
+		apiCfg       models.ApiConfig
+		override     models.OperationOverride
+		want         bool
+	}{
+		{"delete", "/orders/{id}", models.ApiConfig{}, models.OperationOverride{}, false},
+		{"delete", "/orders/{id}", models.ApiConfig{ConfirmDestructive: true}, models.OperationOverride{}, true},
+		{"delete", "/orders/{id}", models.ApiConfig{ConfirmDestructive: true}, models.OperationOverride{Confirm: &no}, false},
+		{"post", "/orders", models.ApiConfig{ConfirmDestructive: true}, models.OperationOverride{}, false},
+		{"post", "/orders", models.ApiConfig{}, models.OperationOverride{Confirm: &yes}, true},
+		{"get", "/orders/{id}", models.ApiConfig{}, models.OperationOverride{}, true},
+		{"put", "/orders/{id}", models.ApiConfig{ConfirmDestructive: true}, models.OperationOverride{}, false},
+	}
+	for _, tt := range tests {
+		details := spec.Paths[tt.path][tt.method]
+		annotations, _ := operationAnnotations(tt.method, tt.override, details)
+		if got := operationConfirm(tt.apiCfg, tt.override, details, annotations); got != tt.want {
+			t.Errorf("%s %s: got %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestConfirm_Token(t *testing.T) {
+	requests := []string{}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer api.Close()
+	mcpServer, ctx := newConfirmServer(t, models.ApiConfig{BaseUrl: api.URL, ConfirmDestructive: true}, newTestSession("token"))
+
+	text, isError := callToolsetTool(t, mcpServer, ctx, "delete_/orders/id", map[string]interface{}{"id": "7"})
+	if isError || !strings.Contains(text, "DELETE "+api.URL+"/orders/7") {
+		t.Fatalf("expected the request to be shown for confirmation, got %q", text)
+	}
+	if len(requests) != 0 {
+		t.Fatalf("expected no request before the confirmation, got %v", requests)
+	}
+	token := regexp.MustCompile(`"_confirmToken": "([0-9a-f]+)"`).FindStringSubmatch(text)
+	if token == nil {
+		t.Fatalf("expected a confirmation token in %q", text)
+	}
+
+	// The token only confirms the call it was issued for.
+	if text, isError := callToolsetTool(t, mcpServer, ctx, "delete_/orders/id", map[string]interface{}{"id": "8", "_confirmToken": token[1]}); !isError || !strings.Contains(text, "invalid or expired _confirmToken") {
+		t.Errorf("expected a token for other arguments to be refused, got %q", text)
+	}
+	text, isError = callToolsetTool(t, mcpServer, ctx, "delete_/orders/id", map[string]interface{}{"id": "7", "_confirmToken": token[1]})
+	if !isError || !strings.Contains(text, "invalid or expired _confirmToken") {
+		t.Errorf("expected a token to be used once, got %q", text)
+	}
+
+	text, _ = callToolsetTool(t, mcpServer, ctx, "delete_/orders/id", map[string]interface{}{"id": "7"})
+	token = regexp.MustCompile(`"_confirmToken": "([0-9a-f]+)"`).FindStringSubmatch(text)
+	if text, isError := callToolsetTool(t, mcpServer, ctx, "delete_/orders/id", map[string]interface{}{"id": "7", "_confirmToken": token[1]}); isError {
+		t.Errorf("expected the confirmed call to succeed, got %q", text)
+	}
+	if got := fmt.Sprint(requests); got != "[DELETE /orders/7]" {
+		t.Errorf("unexpected requests %s", got)
+	}
+}
+
+func TestConfirm_Elicitation(t *testing.T) {
+	requests := 0
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer api.Close()
+
+	tests := []struct {
+		answer   mcp.ElicitationResult
+		approved bool
+	}{
+		{mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionAccept, Content: map[string]interface{}{"confirm": true}}}, true},
+		{mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionAccept, Content: map[string]interface{}{"confirm": false}}}, false},
+		{mcp.ElicitationResult{ElicitationResponse: mcp.ElicitationResponse{Action: mcp.ElicitationResponseActionDecline}}, false},
+	}
+	for i, tt := range tests {
+		session := &confirmSession{
+			testSession:  newTestSession(fmt.Sprintf("elicit-%d", i)),
+			capabilities: mcp.ClientCapabilities{Elicitation: &mcp.ElicitationCapability{}},
+			answer:       &tt.answer,
+		}
+		mcpServer, ctx := newConfirmServer(t, models.ApiConfig{BaseUrl: api.URL, ConfirmDestructive: true}, session)
+		before := requests
+
+		text, isError := callToolsetTool(t, mcpServer, ctx, "delete_/orders/id", map[string]interface{}{"id": "7"})
+		if len(session.messages) != 1 || !strings.Contains(session.messages[0], "DELETE "+api.URL+"/orders/7") {
+			t.Errorf("%d: expected the user to be asked about the request, got %v", i, session.messages)
+		}
+		if sent := requests > before; sent != tt.approved || isError == tt.approved {
+			t.Errorf("%d: expected the request to be sent only if approved, got %q", i, text)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// dryRunArgument is the argument asking a tool call to return its request instead of sending it.
+const dryRunArgument = "_dryRun"
+
+// dryRunToolHandler wraps the handler of an operation so that calls made in dry-run mode, or with _dryRun set,
+// resolve the request and return it as a curl command and as JSON without contacting the API. The arguments are
+// checked like for a call that is sent, and requests that would be refused are refused the same way.
+func dryRunToolHandler(
+	dryRun bool,
+	preview func(ctx context.Context, args map[string]interface{}) (requestPreview, error),
+	handler server.ToolHandlerFunc,
+) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := map[string]interface{}{}
+		for name, value := range request.GetArguments() {
+			args[name] = value
+		}
+		callDryRun, _ := args[dryRunArgument].(bool)
+		delete(args, dryRunArgument)
+		request.Params.Arguments = args
+		if !dryRun && !callDryRun {
+			return handler(ctx, request)
+		}
+
+		req, err := preview(ctx, args)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("[Error] %v", err)), nil
+		}
+		data, err := json.MarshalIndent(req, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("[Error] %v", err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Dry run, the request was not sent:\n\n```sh\n%s\n```\n\n```json\n%s\n```\n", req.curl(), data)), nil
+	}
+}
@@ -0,0 +1,122 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const dryRunSpec = `{
+  "swagger": "2.0",
+  "info": {"title": "Shop", "version": "1.0.0"},
+  "paths": {
+    "/orders/{id}": {
+      "put": {"operationId": "replaceOrder",
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "type": "string"},
+          {"name": "notify", "in": "query", "required": true, "type": "string"},
+          {"name": "body", "in": "body", "schema": {"$ref": "#/definitions/Order"}}
+        ],
+        "responses": {"200": {"description": "Order"}}}
+    }
+  },
+  "definitions": {
+    "Order": {"type": "object", "properties": {"note": {"type": "string"}, "quantity": {"type": "integer"}}}
+  }
+}`
+
+func dryRunTool(t *testing.T, apiCfg models.ApiConfig) server.ServerTool {
+	t.Helper()
+	tools := BuildSwaggerTools(parseTestSpec(t, dryRunSpec), apiCfg)
+	if len(tools) != 1 {
+		t.Fatalf("expected one tool, got %d", len(tools))
+	}
+	return tools[0]
+}
+
+func callDryRun(t *testing.T, tool server.ServerTool, args map[string]interface{}) (string, bool) {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Name = tool.Tool.Name
+	request.Params.Arguments = args
+	result, err := tool.Handler(context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Content[0].(mcp.TextContent).Text, result.IsError
+}
+
+func TestDryRun(t *testing.T) {
+	sent := 0
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+	args := map[string]interface{}{"id": "7", "notify": "it's done", "note": "gift", "quantity": "2"}
+
+	tool := dryRunTool(t, models.ApiConfig{BaseUrl: api.URL, Security: "bearer", BearerAuth: "s3cr3t-token", DryRun: true})
+	text, isError := callDryRun(t, tool, args)
+	if isError || sent != 0 {
+		t.Fatalf("expected the request to be returned without being sent, got %q", text)
+	}
+	for _, want := range []string{
+		`curl -X PUT '` + api.URL + `/orders/7?notify=it%27s+done'`,
+		`-H 'Authorization: [REDACTED]'`,
+		`-H 'Content-Type: application/json'`,
+		`--data '{"note":"gift","quantity":2}'`,
+		`"method": "PUT"`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %s in %q", want, text)
+		}
+	}
+	if strings.Contains(text, "s3cr3t-token") {
+		t.Errorf("expected the credentials to be redacted, got %q", text)
+	}
+	var preview requestPreview
+	data := text[strings.Index(text, "```json\n")+len("```json\n") : strings.LastIndex(text, "```")]
+	if err := json.Unmarshal([]byte(data), &preview); err != nil || preview.Headers["Authorization"] != redacted || preview.Body != `{"note":"gift","quantity":2}` {
+		t.Errorf("unexpected JSON request %+v, %v", preview, err)
+	}
+
+	// invalid arguments are still refused
+	if text, isError := callDryRun(t, tool, map[string]interface{}{"id": "7", "notify": "yes", "note": "gift", "quantity": "two"}); !isError || !strings.Contains(text, "quantity: must be an integer") {
+		t.Errorf("expected an invalid argument error, got %q", text)
+	}
+
+	// per call
+	tool = dryRunTool(t, models.ApiConfig{BaseUrl: api.URL})
+	if _, ok := tool.Tool.InputSchema.Properties[dryRunArgument]; !ok {
+		t.Errorf("expected a %s argument, got %v", dryRunArgument, tool.Tool.InputSchema.Properties)
+	}
+	args[dryRunArgument] = true
+	if text, isError := callDryRun(t, tool, args); isError || sent != 0 || !strings.Contains(text, "Dry run") {
+		t.Errorf("expected a dry run, got %q", text)
+	}
+	args[dryRunArgument] = false
+	if text, isError := callDryRun(t, tool, args); isError || sent != 1 {
+		t.Errorf("expected the request to be sent, got %q", text)
+	}
+}
+
+func TestDryRun_SkipsConfirmation(t *testing.T) {
+	tool := dryRunTool(t, models.ApiConfig{BaseUrl: "https://shop.example", ConfirmDestructive: true})
+	text, isError := callDryRun(t, tool, map[string]interface{}{"id": "7", "notify": "no", "note": "gift", "quantity": "2", dryRunArgument: true})
+	if isError || !strings.Contains(text, "Dry run") || strings.Contains(text, confirmTokenArgument) {
+		t.Errorf("expected a dry run without confirmation, got %q", text)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if got := shellQuote(`it's "done"`); got != `'it'\''s "done"'` {
+		t.Errorf("unexpected quoting %s", got)
+	}
+}
@@ -0,0 +1,179 @@
+package mcpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+)
+
+// splitFilterList splits a comma-separated filter setting, dropping empty entries.
+func splitFilterList(value string) []string {
+	var list []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// includeOperation reports whether an operation passes the read-only, tag, operationId, extension and deprecation filters.
+func (f operationFilter) includeOperation(method string, override models.OperationOverride, details models.Endpoint) bool {
+	return f.operationSkipReason(method, override, details) == ""
+}
+
+// operationSkipReason returns why the read-only, tag, operationId, extension or deprecation filters drop an operation,
+// or "" if they keep it.
+func (f operationFilter) operationSkipReason(method string, override models.OperationOverride, details models.Endpoint) string {
+	if f.readOnly && !safeOperation(method, override, details) {
+		return "not safe in read-only mode"
+	}
+	if f.excludeDeprecated && details.Deprecated {
+		return "deprecated"
+	}
+	if len(f.includeTags) > 0 && !matchTags(f.includeTags, details.Tags) {
+		return "no included tag"
+	}
+	for _, tag := range details.Tags {
+		if matchTags(f.excludeTags, []string{tag}) {
+			return fmt.Sprintf("tag %s excluded", tag)
+		}
+	}
+	if len(f.includeOperations) > 0 && !matchOperationID(f.includeOperations, details.OperationID) {
+		return "operationId not included"
+	}
+	if matchOperationID(f.excludeOperations, details.OperationID) {
+		return fmt.Sprintf("operationId %s excluded", details.OperationID)
+	}
+	if len(f.includeExtensions) > 0 && matchExtension(f.includeExtensions, details.Extensions) == "" {
+		return "no included extension"
+	}
+	if filter := matchExtension(f.excludeExtensions, details.Extensions); filter != "" {
+		return fmt.Sprintf("extension %s excluded", filter)
+	}
+	return ""
+}
+
+// matchTags reports whether one of the tags is in the list, ignoring case.
+func matchTags(list, tags []string) bool {
+	for _, tag := range tags {
+		for _, entry := range list {
+			if strings.EqualFold(entry, tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchOperationID reports whether an operationId matches one of the patterns, exactly or as a glob (listUsers, *Admin*).
+// Operations without an operationId match none.
+func matchOperationID(patterns []string, operationID string) bool {
+	if operationID == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, operationID); matched || err != nil && pattern == operationID {
+			return true
+		}
+	}
+	return false
+}
+
+// matchExtension returns the first filter matching the extensions of an operation, or "" if none does.
+// A filter is an extension name, matching when the extension is set and neither false nor null, or name=value,
+// matching when the extension is that string or that JSON value (x-audience=public, x-internal=true).
+func matchExtension(filters []string, extensions map[string]json.RawMessage) string {
+	for _, filter := range filters {
+		name, value, hasValue := strings.Cut(filter, "=")
+		raw, ok := extensions[strings.TrimSpace(name)]
+		if !ok {
+			continue
+		}
+		actual := strings.TrimSpace(string(raw))
+		if !hasValue {
+			if actual != "false" && actual != "null" {
+				return filter
+			}
+			continue
+		}
+		var text string
+		if json.Unmarshal(raw, &text) == nil {
+			actual = text
+		}
+		if actual == strings.TrimSpace(value) {
+			return filter
+		}
+	}
+	return ""
+}
+
+// filterDecision records whether an operation of a spec got a tool.
+type filterDecision struct {
+	method, path string
+	toolName     string // tool of a kept operation
+	reason       string // why a dropped operation was filtered out, "" if it was kept
+}
+
+// filterReport returns what the filters and overrides of apiCfg decided for every operation of a spec, sorted by path and method.
+func filterReport(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig) []filterDecision {
+	filter := newOperationFilter(apiCfg)
+	paths := make([]string, 0, len(swaggerSpec.Paths))
+	for path := range swaggerSpec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	decisions := []filterDecision{}
+	for _, path := range paths {
+		for _, method := range httpMethods {
+			details, ok := swaggerSpec.Paths[path][method]
+			if !ok {
+				continue
+			}
+			decision := filterDecision{method: strings.ToUpper(method), path: path}
+			override := findOverride(apiCfg.Overrides, details.OperationID, method, path)
+			switch {
+			case !filter.includePath(path):
+				decision.reason = "path filtered out"
+			case !filter.includeMethod(method):
+				decision.reason = "method filtered out"
+			case override.Disabled:
+				decision.reason = "disabled by an override"
+			default:
+				decision.reason = filter.operationSkipReason(method, override, details)
+			}
+			if decision.reason == "" {
+				decision.toolName = buildToolName(apiCfg.ToolPrefix, method, path)
+				if override.Name != "" {
+					decision.toolName = buildOverrideToolName(apiCfg.ToolPrefix, override.Name)
+				}
+			}
+			decisions = append(decisions, decision)
+		}
+	}
+	return decisions
+}
+
+// logFilterReport logs the tools kept from a spec and the operations dropped, with the reason.
+func logFilterReport(title string, decisions []filterDecision) {
+	kept := 0
+	for _, decision := range decisions {
+		if decision.reason == "" {
+			kept++
+		}
+	}
+	log.Printf("Tools of %s: %d kept, %d dropped", title, kept, len(decisions)-kept)
+	for _, decision := range decisions {
+		if decision.reason == "" {
+			log.Printf("  kept    %s %s as %s", decision.method, decision.path, decision.toolName)
+		} else {
+			log.Printf("  dropped %s %s: %s", decision.method, decision.path, decision.reason)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package mcpserver
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+)
+
+const filtersSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Shop", "version": "1.0.0"},
+  "paths": {
+    "/orders": {
+      "get": {"operationId": "listOrders", "tags": ["Orders"], "x-audience": "public", "responses": {"200": {"description": "Orders"}}},
+      "post": {"operationId": "createOrder", "tags": ["orders"], "deprecated": true, "responses": {"201": {"description": "Created"}}}
+    },
+    "/admin/orders": {
+      "delete": {"operationId": "purgeOrdersAdmin", "tags": ["orders", "admin"], "x-internal": true, "responses": {"204": {"description": "Purged"}}}
+    },
+    "/health": {
+      "get": {"x-internal": false, "x-tier": 2, "responses": {"200": {"description": "OK"}}}
+    }
+  }
+}`
+
+func TestOperationFilter(t *testing.T) {
+	spec := parseTestSpec(t, filtersSpec)
+	tests := []struct {
+		name   string
+		apiCfg models.ApiConfig
+		want   string
+	}{
+		{"no filters", models.ApiConfig{}, "[delete_/admin/orders get_/health get_/orders post_/orders]"},
+		{"include tags ignore case", models.ApiConfig{IncludeTags: "orders"}, "[delete_/admin/orders get_/orders post_/orders]"},
+		{"exclude tags", models.ApiConfig{ExcludeTags: "admin"}, "[get_/health get_/orders post_/orders]"},
+		{"include operations", models.ApiConfig{IncludeOperations: "listOrders, createOrder"}, "[get_/orders post_/orders]"},
+		{"exclude operation glob", models.ApiConfig{ExcludeOperations: "*Admin"}, "[get_/health get_/orders post_/orders]"},
+		{"include extension value", models.ApiConfig{IncludeExtensions: "x-audience=public"}, "[get_/orders]"},
+		{"include extension number", models.ApiConfig{IncludeExtensions: "x-tier=2"}, "[get_/health]"},
+		{"exclude extension set", models.ApiConfig{ExcludeExtensions: "x-internal"}, "[get_/health get_/orders post_/orders]"},
+		{"exclude extension value", models.ApiConfig{ExcludeExtensions: "x-internal=false"}, "[delete_/admin/orders get_/orders post_/orders]"},
+		{"exclude deprecated", models.ApiConfig{ExcludeDeprecated: true}, "[delete_/admin/orders get_/health get_/orders]"},
+		{"combined", models.ApiConfig{IncludeTags: "orders", ExcludeDeprecated: true, ExcludeMethods: "DELETE"}, "[get_/orders]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := []string{}
+			for _, tool := range BuildSwaggerTools(spec, tt.apiCfg) {
+				names = append(names, tool.Tool.Name)
+			}
+			sort.Strings(names)
+			if got := fmt.Sprint(names); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+
+			// resources and the other views of the spec use the same filters
+			_, doc, _ := specDocument(spec)
+			ids := []string{}
+			for _, op := range specOperations(doc, tt.apiCfg) {
+				ids = append(ids, op.toolName)
+			}
+			sort.Strings(ids)
+			if got := fmt.Sprint(ids); got != tt.want {
+				t.Errorf("specOperations = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterReport(t *testing.T) {
+	apiCfg := models.ApiConfig{
+		ToolPrefix:        "shop",
+		ExcludePaths:      "/health",
+		ExcludeTags:       "admin",
+		ExcludeDeprecated: true,
+		Overrides:         map[string]models.OperationOverride{"listOrders": {Name: "list_orders"}},
+	}
+	got := []string{}
+	for _, decision := range filterReport(parseTestSpec(t, filtersSpec), apiCfg) {
+		got = append(got, fmt.Sprintf("%s %s %s%s", decision.method, decision.path, decision.toolName, decision.reason))
+	}
+	want := "[DELETE /admin/orders tag admin excluded GET /health path filtered out GET /orders shop_list_orders POST /orders deprecated]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("got %v, want %s", got, want)
+	}
+}
@@ -0,0 +1,380 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// DefaultShutdownTimeout is how long in-flight tool calls may run after a shutdown signal when no timeout is configured.
+const DefaultShutdownTimeout = 30 * time.Second
+
+const (
+	// readyCheckTTL is how long the result of the readiness check is reused, so probes do not hammer the upstream APIs.
+	readyCheckTTL = 5 * time.Second
+	// upstreamCheckTimeout bounds the request made to check that an upstream API is reachable.
+	upstreamCheckTimeout = 2 * time.Second
+)
+
+// lifecycle tracks in-flight tool calls and coordinates graceful shutdown: once draining, new tool calls are
+// rejected, running ones may finish until the grace period ends and are then cancelled.
+type lifecycle struct {
+	mu       sync.Mutex
+	active   int
+	draining bool
+	idle     chan struct{} // closed when draining and no tool call is running
+
+	aborted context.Context // cancelled when the grace period ends
+	abort   context.CancelFunc
+	streams context.Context // cancelled to end SSE and Streamable HTTP streams
+	closeFn context.CancelFunc
+
+	callsMu sync.Mutex
+	pending map[*mcp.Meta]string          // tool calls about to run -> call key, see trackCall
+	running map[string]context.CancelFunc // call key (client session and request id) -> cancels the call
+}
+
+// cancelledNotification is sent by clients to cancel a request they made.
+const cancelledNotification = "notifications/cancelled"
+
+func newLifecycle() *lifecycle {
+	l := &lifecycle{idle: make(chan struct{}), pending: map[*mcp.Meta]string{}, running: map[string]context.CancelFunc{}}
+	l.aborted, l.abort = context.WithCancel(context.Background())
+	l.streams, l.closeFn = context.WithCancel(context.Background())
+	return l
+}
+
+// begin registers a tool call. It returns false once the server is draining.
+func (l *lifecycle) begin() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.draining {
+		return false
+	}
+	l.active++
+	return true
+}
+
+func (l *lifecycle) end() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	if l.draining && l.active == 0 {
+		close(l.idle)
+	}
+}
+
+// startDrain stops new tool calls from being accepted.
+func (l *lifecycle) startDrain() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.draining {
+		return
+	}
+	l.draining = true
+	if l.active == 0 {
+		close(l.idle)
+	}
+}
+
+func (l *lifecycle) isDraining() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.draining
+}
+
+// drain stops accepting tool calls and waits for the running ones until ctx is done.
+// Calls still running then are cancelled. It reports whether all calls finished in time.
+func (l *lifecycle) drain(ctx context.Context) bool {
+	l.startDrain()
+	select {
+	case <-l.idle:
+		return true
+	case <-ctx.Done():
+		l.abort()
+		return false
+	}
+}
+
+// toolMiddleware tracks tool calls for drain. Shutdown cancels the contexts of client requests, but a call
+// that is already running keeps going until the grace period ends; other cancellations are passed on.
+func (l *lifecycle) toolMiddleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !l.begin() {
+			return mcp.NewToolResultError("[Error] server is shutting down"), nil
+		}
+		defer l.end()
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		defer cancel()
+		stopParent := context.AfterFunc(ctx, func() {
+			if !l.isDraining() {
+				cancel()
+			}
+		})
+		defer stopParent()
+		stopAbort := context.AfterFunc(l.aborted, cancel)
+		defer stopAbort()
+		if key, ok := l.startCall(request.Params.Meta, cancel); ok {
+			defer l.finishCall(key)
+		}
+		return next(callCtx, request)
+	}
+}
+
+// addHooks registers the hooks that let clients cancel tool calls with notifications/cancelled.
+func (l *lifecycle) addHooks(hooks *server.Hooks) {
+	hooks.AddBeforeCallTool(l.trackCall)
+	hooks.AddOnError(func(ctx context.Context, id any, method mcp.MCPMethod, message any, err error) {
+		if request, ok := message.(*mcp.CallToolRequest); ok {
+			l.callsMu.Lock()
+			delete(l.pending, request.Params.Meta)
+			l.callsMu.Unlock()
+		}
+	})
+}
+
+// trackCall remembers the request id of a tool call. Tool handlers only see the request, not its id, so the
+// call is identified by its Meta, which the handler receives as the same pointer.
+func (l *lifecycle) trackCall(ctx context.Context, id any, request *mcp.CallToolRequest) {
+	if request.Params.Meta == nil {
+		request.Params.Meta = &mcp.Meta{}
+	}
+	l.callsMu.Lock()
+	defer l.callsMu.Unlock()
+	l.pending[request.Params.Meta] = callKey(ctx, id)
+}
+
+// startCall registers the cancel function of a tracked tool call.
+func (l *lifecycle) startCall(meta *mcp.Meta, cancel context.CancelFunc) (string, bool) {
+	l.callsMu.Lock()
+	defer l.callsMu.Unlock()
+	key, ok := l.pending[meta]
+	if !ok {
+		return "", false
+	}
+	delete(l.pending, meta)
+	l.running[key] = cancel
+	return key, true
+}
+
+func (l *lifecycle) finishCall(key string) {
+	l.callsMu.Lock()
+	defer l.callsMu.Unlock()
+	delete(l.running, key)
+}
+
+// cancelCall handles notifications/cancelled by cancelling the tool call it names, if the call is still running.
+func (l *lifecycle) cancelCall(ctx context.Context, notification mcp.JSONRPCNotification) {
+	id, ok := notification.Params.AdditionalFields["requestId"]
+	if !ok {
+		return
+	}
+	key := callKey(ctx, id)
+	l.callsMu.Lock()
+	cancel, ok := l.running[key]
+	l.callsMu.Unlock()
+	if ok {
+		reason, _ := notification.Params.AdditionalFields["reason"].(string)
+		log.Printf("Tool call %s cancelled by the client: %s", key, reason)
+		cancel()
+	}
+}
+
+// callKey identifies a request by the client session that made it and its id, which is only unique per session.
+func callKey(ctx context.Context, id any) string {
+	requestID, ok := id.(mcp.RequestId)
+	if !ok {
+		requestID = mcp.NewRequestId(id)
+	}
+	sessionID := ""
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		sessionID = session.SessionID()
+	}
+	return sessionID + "/" + requestID.String()
+}
+
+// streamMiddleware ends long-lived GET streams (SSE connections and Streamable HTTP listeners) when
+// closeStreams is called, so that the HTTP server can shut down.
+func (l *lifecycle) streamMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			stop := context.AfterFunc(l.streams, cancel)
+			defer stop()
+			r = r.WithContext(ctx)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (l *lifecycle) closeStreams() {
+	l.closeFn()
+}
+
+// shutdownHTTP drains tool calls, then closes client streams and stops the HTTP server, all within timeout.
+func (l *lifecycle) shutdownHTTP(httpServer *http.Server, timeout time.Duration) {
+	log.Printf("Shutting down, waiting up to %s for in-flight tool calls", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if !l.drain(ctx) {
+		log.Printf("Grace period ended, cancelled the remaining tool calls")
+	}
+	l.closeStreams()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		httpServer.Close()
+	}
+	log.Printf("Server stopped")
+}
+
+// serveStdio serves on stdin/stdout until stdin is closed or ctx is cancelled by a shutdown signal,
+// then lets in-flight tool calls finish within timeout.
+func (l *lifecycle) serveStdio(ctx context.Context, mcpServer *server.MCPServer, stdin io.Reader, stdout io.Writer, timeout time.Duration) error {
+	listenCtx, cancelListen := context.WithCancel(context.Background())
+	defer cancelListen()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			log.Printf("Shutting down, waiting up to %s for in-flight tool calls", timeout)
+			l.startDrain()
+			time.AfterFunc(timeout, l.abort)
+			cancelListen()
+		case <-done:
+		}
+	}()
+
+	err := server.NewStdioServer(mcpServer).Listen(listenCtx, stdin, stdout)
+	if ctx.Err() == nil {
+		log.Printf("stdin closed, shutting down")
+	}
+	drainCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	l.drain(drainCtx)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// health serves the /healthz and /readyz probes.
+type health struct {
+	life      *lifecycle
+	reloaders []*specReloader
+	client    *http.Client
+
+	mu      sync.Mutex
+	checked time.Time
+	err     error
+	running chan struct{} // closed when the running check ends, nil if none is running
+}
+
+func newHealth(life *lifecycle, reloaders []*specReloader) *health {
+	return &health{life: life, reloaders: reloaders, client: &http.Client{Timeout: upstreamCheckTimeout}}
+}
+
+// healthz reports that the process is up.
+func (h *health) healthz(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok\n"))
+}
+
+// readyz reports whether the server can take traffic: it is not shutting down, a spec is loaded
+// and the APIs of the loaded specs are reachable. The probe is unauthenticated, so why the server
+// is not ready is only logged.
+func (h *health) readyz(w http.ResponseWriter, r *http.Request) {
+	if h.life.isDraining() || h.check() != nil {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ready\n"))
+}
+
+// check returns the result of the readiness checks, reusing the previous result for readyCheckTTL.
+// Concurrent probes share a single run of the checks.
+func (h *health) check() error {
+	h.mu.Lock()
+	if !h.checked.IsZero() && time.Since(h.checked) < readyCheckTTL {
+		defer h.mu.Unlock()
+		return h.err
+	}
+	if running := h.running; running != nil {
+		h.mu.Unlock()
+		<-running
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return h.err
+	}
+	running := make(chan struct{})
+	h.running = running
+	h.mu.Unlock()
+
+	err := h.runChecks()
+	if err != nil {
+		log.Printf("Not ready: %v", err)
+	}
+	h.mu.Lock()
+	h.checked, h.err, h.running = time.Now(), err, nil
+	h.mu.Unlock()
+	close(running)
+	return err
+}
+
+// runChecks checks that a spec is loaded and that the APIs of the loaded specs are reachable. The APIs are
+// checked in parallel, each within upstreamCheckTimeout and independently of the probe that asked.
+func (h *health) runChecks() error {
+	problems := make([]string, len(h.reloaders))
+	loaded := 0
+	var wg sync.WaitGroup
+	for i, r := range h.reloaders {
+		swaggerSpec, ok := r.currentSpec()
+		if !ok {
+			continue
+		}
+		loaded++
+		baseURL := specBaseURL(swaggerSpec, r.spec.ApiCfg)
+		if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, r *specReloader) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), upstreamCheckTimeout)
+			defer cancel()
+			// Any response means the API is reachable, even an error status
+			req, err := http.NewRequestWithContext(ctx, http.MethodHead, baseURL, nil)
+			if err == nil {
+				var resp *http.Response
+				if resp, err = h.client.Do(req); err == nil {
+					resp.Body.Close()
+				}
+			}
+			if err != nil {
+				problems[i] = fmt.Sprintf("API of spec %s is unreachable", specLabel(r.spec))
+			}
+		}(i, r)
+	}
+	wg.Wait()
+	if loaded == 0 {
+		return errors.New("no spec loaded")
+	}
+	unreachable := []string{}
+	for _, problem := range problems {
+		if problem != "" {
+			unreachable = append(unreachable, problem)
+		}
+	}
+	if len(unreachable) > 0 {
+		return errors.New(strings.Join(unreachable, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package mcpserver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestLifecycle_DrainWaitsForToolCalls(t *testing.T) {
+	life := newLifecycle()
+	started, release := make(chan struct{}), make(chan struct{})
+	var callErr error
+	handler := life.toolMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		close(started)
+		<-release
+		callErr = ctx.Err()
+		return mcp.NewToolResultText("done"), nil
+	})
+
+	// Shutdown cancels the request, the call keeps running
+	reqCtx, cancelReq := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+	go func() {
+		handler(reqCtx, mcp.CallToolRequest{})
+		close(finished)
+	}()
+	<-started
+	life.startDrain()
+	cancelReq()
+
+	if result, _ := handler(context.Background(), mcp.CallToolRequest{}); !result.IsError {
+		t.Error("expected new tool calls to be rejected while draining")
+	}
+
+	drained := make(chan bool)
+	go func() { drained <- life.drain(context.Background()) }()
+	select {
+	case <-drained:
+		t.Fatal("drain returned while a tool call was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if !<-drained {
+		t.Error("expected drain to report that all calls finished")
+	}
+	<-finished
+	if callErr != nil {
+		t.Errorf("expected the call context to outlive the shutdown, got %v", callErr)
+	}
+}
+
+func TestLifecycle_GracePeriodCancelsCalls(t *testing.T) {
+	life := newLifecycle()
+	started := make(chan struct{})
+	handler := life.toolMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		return mcp.NewToolResultError("[Error] cancelled"), nil
+	})
+	go handler(context.Background(), mcp.CallToolRequest{})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if life.drain(ctx) {
+		t.Error("expected drain to report the grace period ended")
+	}
+	select {
+	case <-life.idle:
+	case <-time.After(time.Second):
+		t.Error("expected the running call to be cancelled")
+	}
+}
+
+func TestLifecycle_ClientCancellationIsPassedOn(t *testing.T) {
+	life := newLifecycle()
+	handler := life.toolMiddleware(func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, err := handler(ctx, mcp.CallToolRequest{}); err == nil {
+		t.Error("expected the call to be cancelled with its request")
+	}
+}
+
+func TestLifecycle_ServeStdioReturnsOnEOF(t *testing.T) {
+	life := newLifecycle()
+	mcpServer := newMCPServer("1.0.0", newSessionStore(models.Config{}), life, newCompletionSet())
+	stdin := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n")
+	var stdout strings.Builder
+	if err := life.serveStdio(context.Background(), mcpServer, stdin, &stdout, time.Second); err != nil {
+		t.Fatalf("serveStdio returned error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), `"id":1`) {
+		t.Errorf("expected a response to the ping, got %q", stdout.String())
+	}
+	if !life.isDraining() {
+		t.Error("expected the server to be draining after stdin closed")
+	}
+}
+
+func TestLifecycle_StreamsEndOnClose(t *testing.T) {
+	life := newLifecycle()
+	ended := make(chan struct{})
+	h := life.streamMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(ended)
+	}))
+	go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/sse", nil))
+	life.closeStreams()
+	select {
+	case <-ended:
+	case <-time.After(time.Second):
+		t.Error("expected the stream to end")
+	}
+}
+
+func TestHealth_Probes(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	reachable := newSpecReloader(mcpServer, models.SpecConfig{SpecUrl: "file:///a.json", ApiCfg: models.ApiConfig{BaseUrl: api.URL}})
+	unloaded := newSpecReloader(mcpServer, models.SpecConfig{SpecUrl: "file:///b.json"})
+	spec := models.SwaggerSpec{OpenAPI: "3.0.0", Paths: map[string]map[string]models.Endpoint{}}
+	if _, err := reachable.apply(spec); err != nil {
+		t.Fatal(err)
+	}
+
+	probe := func(h *health, handler func(http.ResponseWriter, *http.Request)) (int, string) {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		body, _ := io.ReadAll(w.Body)
+		return w.Code, string(body)
+	}
+
+	life := newLifecycle()
+	h := newHealth(life, []*specReloader{reachable, unloaded})
+	if code, _ := probe(h, h.healthz); code != http.StatusOK {
+		t.Errorf("healthz = %d", code)
+	}
+	if code, body := probe(h, h.readyz); code != http.StatusOK {
+		t.Errorf("expected ready with a reachable API, got %d %s", code, body)
+	}
+
+	// the checks do not depend on the request of the probe that runs them
+	h = newHealth(life, []*specReloader{reachable})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	h.readyz(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected a cancelled probe not to fail the check, got %d", w.Code)
+	}
+
+	api.Close()
+	h = newHealth(life, []*specReloader{reachable})
+	if code, body := probe(h, h.readyz); code != http.StatusServiceUnavailable || body != "not ready\n" {
+		t.Errorf("expected not ready with an unreachable API, got %d %s", code, body)
+	}
+	h = newHealth(life, []*specReloader{unloaded})
+	if code, body := probe(h, h.readyz); code != http.StatusServiceUnavailable || body != "not ready\n" {
+		t.Errorf("expected not ready without a spec, got %d %s", code, body)
+	}
+
+	life.startDrain()
+	if code, _ := probe(h, h.readyz); code != http.StatusServiceUnavailable {
+		t.Errorf("expected not ready while shutting down, got %d", code)
+	}
+	if code, _ := probe(h, h.healthz); code != http.StatusOK {
+		t.Errorf("expected healthz to stay up while shutting down, got %d", code)
+	}
+}
@@ -0,0 +1,170 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// traceLogger is the logger name of the log notifications sent for upstream requests.
+	traceLogger = "swagger-mcp"
+	// maxTracedBody is how many bytes of a request or response body are included in a trace.
+	maxTracedBody = 2048
+	// minSecretLength is the length below which a credential value is not redacted:
+	// replacing such short values would mangle the trace without hiding anything.
+	minSecretLength = 4
+	// redacted replaces secrets in traces.
+	redacted = "[REDACTED]"
+)
+
+// credentialHeaders are request headers whose values are always redacted.
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// upstreamTrace is an outbound request made for a tool call, resource read or completion.
+type upstreamTrace struct {
+	method   string
+	url      string // Templated URL, with path parameters left as {name}
+	status   int
+	latency  time.Duration
+	reqBody  []byte
+	respBody []byte
+	err      error
+	secrets  []string // Credential values to redact, longest first
+}
+
+// sendTrace reports an upstream request to the calling client as MCP log notifications:
+// a summary at info level (warning for 4xx, error for 5xx and failed requests) and the
+// truncated bodies at debug level. Clients choose what they see with logging/setLevel.
+func sendTrace(ctx context.Context, trace upstreamTrace) {
+	mcpServer := server.ServerFromContext(ctx)
+	if mcpServer == nil || server.ClientSessionFromContext(ctx) == nil {
+		return
+	}
+	summary := map[string]interface{}{
+		"method":    trace.method,
+		"url":       trace.redact(traceURL(trace.url)),
+		"latencyMs": trace.latency.Milliseconds(),
+	}
+	level := mcp.LoggingLevelInfo
+	switch {
+	case trace.err != nil:
+		level = mcp.LoggingLevelError
+		summary["error"] = trace.redact(trace.err.Error())
+	case trace.status >= 500:
+		level = mcp.LoggingLevelError
+	case trace.status >= 400:
+		level = mcp.LoggingLevelWarning
+	}
+	if trace.err == nil {
+		summary["status"] = trace.status
+	}
+	// Errors only mean the session does not want or support log notifications
+	mcpServer.SendLogMessageToClient(ctx, mcp.NewLoggingMessageNotification(level, traceLogger, summary))
+
+	details := map[string]interface{}{"method": summary["method"], "url": summary["url"]}
+	if len(trace.reqBody) > 0 {
+		details["requestBody"] = trace.redact(truncateBody(trace.reqBody))
+	}
+	if trace.err == nil {
+		details["responseBody"] = trace.redact(truncateBody(trace.respBody))
+	}
+	mcpServer.SendLogMessageToClient(ctx, mcp.NewLoggingMessageNotification(mcp.LoggingLevelDebug, traceLogger, details))
+}
+
+// redact replaces every credential value in s.
+func (t upstreamTrace) redact(s string) string {
+	for _, secret := range t.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+// traceURL drops the user info and the query values of a URL; parameter names are kept.
+func traceURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.User = nil
+	if u.RawQuery != "" {
+		query := u.Query()
+		for name := range query {
+			query[name] = []string{redacted}
+		}
+		u.RawQuery = query.Encode()
+	}
+	// Keep the {name} placeholders of templated URLs readable
+	return strings.NewReplacer("%7B", "{", "%7D", "}", "%5BREDACTED%5D", redacted).Replace(u.String())
+}
+
+// truncateBody returns the body as text, cut after maxTracedBody bytes.
+func truncateBody(body []byte) string {
+	if len(body) <= maxTracedBody {
+		return string(body)
+	}
+	return string(body[:maxTracedBody]) + "... (truncated)"
+}
+
+// traceSecrets collects the credential values of a request: the configured credentials, those of
+// the client session and the values of the credential headers, longest first so that a secret
+// containing another is replaced whole.
+func traceSecrets(ctx context.Context, req *http.Request, apiCfg models.ApiConfig) []string {
+	values := []string{apiCfg.BearerAuth}
+	if apiCfg.BasicAuth != "" {
+		values = append(values, apiCfg.BasicAuth, base64.StdEncoding.EncodeToString([]byte(apiCfg.BasicAuth)))
+		if _, password, ok := strings.Cut(apiCfg.BasicAuth, ":"); ok {
+			values = append(values, password)
+		}
+	}
+	for _, part := range strings.Split(apiCfg.ApiKeyAuth, ",") {
+		if _, value, ok := strings.Cut(part, "="); ok {
+			values = append(values, strings.TrimSpace(value))
+		}
+	}
+	for _, key := range apiCfg.ApiKeys {
+		values = append(values, key.Value)
+	}
+	for _, value := range apiCfg.HeaderValues {
+		values = append(values, value)
+	}
+	if session, ok := ctx.Value(sessionKey).(Session); ok {
+		values = append(values, session.BearerToken, session.ApiKey, session.Cookies)
+		for _, c := range session.Specs {
+			values = append(values, c.BearerToken, c.ApiKey, c.Cookies)
+		}
+		for _, value := range session.Headers {
+			values = append(values, value)
+		}
+	}
+	for _, name := range credentialHeaders {
+		for _, value := range req.Header.Values(name) {
+			values = append(values, value)
+			if _, token, ok := strings.Cut(value, " "); ok && name != "Cookie" {
+				values = append(values, token)
+			}
+		}
+	}
+	for _, cookie := range req.Cookies() {
+		values = append(values, cookie.Value)
+	}
+
+	secrets := []string{}
+	seen := map[string]bool{}
+	for _, value := range values {
+		if len(value) >= minSecretLength && !seen[value] {
+			seen[value] = true
+			secrets = append(secrets, value)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	return secrets
+}
@@ -0,0 +1,325 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// ToolModeOperations registers one tool per operation.
+	ToolModeOperations = "operations"
+	// ToolModeMeta registers search_operations, describe_operation and call_operation instead,
+	// for specs with too many operations to list as tools.
+	ToolModeMeta = "meta"
+)
+
+// defaultSearchLimit is how many operations search_operations returns when no limit is given.
+const defaultSearchLimit = 20
+
+// metaOperation is an operation reachable through the meta tools.
+type metaOperation struct {
+	id       string // operationId, prefixed like tool names, or the tool name if the operation has none
+	method   string
+	path     string
+	summary  string
+	tags     []string
+	toolsets []string          // Toolsets of the operation in toolsets mode
+	markdown string            // Documentation of the operation, as served by its resource
+	tool     server.ServerTool // Tool the operation would have in operations mode, whose handler makes the call
+	document searchDocument    // The operation in the search index
+}
+
+// operationCatalog holds the operations of every spec of a server. In meta mode they are only reachable through
+// the meta tools; in the other modes the catalog backs search_operations, which finds the tool of an operation.
+type operationCatalog struct {
+	mode string // tool mode of the server
+
+	mu     sync.RWMutex
+	bySpec map[*specReloader][]metaOperation
+	index  *SearchIndex // index of the operations of every spec
+}
+
+func newOperationCatalog(mode string) *operationCatalog {
+	return &operationCatalog{mode: mode, bySpec: map[*specReloader][]metaOperation{}, index: newSearchIndex(nil)}
+}
+
+// set replaces the operations of a spec and rebuilds the search index.
+func (c *operationCatalog) set(spec *specReloader, operations []metaOperation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bySpec[spec] = operations
+	docs := []searchDocument{}
+	for _, specOperations := range c.bySpec {
+		for _, op := range specOperations {
+			docs = append(docs, op.document)
+		}
+	}
+	c.index = newSearchIndex(docs)
+}
+
+// all returns the operations of every spec, sorted by id.
+func (c *operationCatalog) all() []metaOperation {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	operations := []metaOperation{}
+	for _, specOperations := range c.bySpec {
+		operations = append(operations, specOperations...)
+	}
+	sort.Slice(operations, func(i, j int) bool { return operations[i].id < operations[j].id })
+	return operations
+}
+
+// find returns the operation with the given id.
+func (c *operationCatalog) find(id string) (metaOperation, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, specOperations := range c.bySpec {
+		for _, op := range specOperations {
+			if op.id == id {
+				return op, true
+			}
+		}
+	}
+	return metaOperation{}, false
+}
+
+// newToolCatalog registers the meta tools and returns the catalog behind them when the tool mode is meta.
+// In the other modes every operation has its own tool, and only search_operations is registered to find them.
+func newToolCatalog(mcpServer *server.MCPServer, toolMode string) *operationCatalog {
+	catalog := newOperationCatalog(toolMode)
+	if toolMode == ToolModeMeta {
+		mcpServer.AddTools(catalog.metaTools()...)
+	} else {
+		mcpServer.AddTools(catalog.searchTool())
+	}
+	return catalog
+}
+
+// meta reports whether the operations of the catalog are only reachable through the meta tools.
+func (c *operationCatalog) meta() bool {
+	return c != nil && c.mode == ToolModeMeta
+}
+
+// buildMetaOperations pairs the operations of a spec with the tools built for them.
+func buildMetaOperations(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig, tools []server.ServerTool) []metaOperation {
+	_, doc, err := specDocument(swaggerSpec)
+	if err != nil {
+		return nil
+	}
+	byName := make(map[string]server.ServerTool, len(tools))
+	for _, tool := range tools {
+		byName[tool.Tool.Name] = tool
+	}
+	operations := []metaOperation{}
+	for _, op := range specOperations(doc, apiCfg) {
+		tool, ok := byName[op.toolName]
+		if !ok {
+			continue
+		}
+		details := swaggerSpec.Paths[op.path][op.method]
+		id := operationRef(apiCfg, details.OperationID, op.toolName)
+		toolsets := []string{}
+		for _, tag := range details.Tags {
+			toolsets = append(toolsets, toolsetName(apiCfg.ToolPrefix, tag))
+		}
+		if len(toolsets) == 0 {
+			toolsets = append(toolsets, toolsetName(apiCfg.ToolPrefix, untaggedToolset))
+		}
+		operations = append(operations, metaOperation{
+			id:       id,
+			method:   strings.ToUpper(op.method),
+			path:     op.path,
+			summary:  details.Summary,
+			tags:     details.Tags,
+			toolsets: toolsets,
+			markdown: operationMarkdown(apiCfg.ToolPrefix, op.method, op.path, op.toolName, op.details),
+			tool:     tool,
+			document: newSearchDocument(swaggerSpec, id, op.method, op.path, details),
+		})
+	}
+	return operations
+}
+
+// searchTool returns search_operations. Outside meta mode it also returns the tool of each operation and, in
+// toolsets mode, the toolsets to enable to get it.
+func (c *operationCatalog) searchTool() server.ServerTool {
+	description := "Search the API operations by keywords matched against their ids, paths, summaries, descriptions, tags, parameters and schema fields, best match first, optionally limited to a tag."
+	switch c.mode {
+	case ToolModeMeta:
+		description += " Use describe_operation to learn the arguments of an operation and call_operation to call it."
+	case ToolModeToolsets:
+		description += " Each operation comes with the tool that calls it and the toolsets containing that tool; enable one of them with enable_toolset if the tool is not available."
+	default:
+		description += " Each operation comes with the tool that calls it."
+	}
+	return server.ServerTool{
+		Tool: mcp.NewTool("search_operations",
+			mcp.WithDescription(description),
+			mcp.WithString("query", mcp.Description("Keywords describing what you want to do, e.g. 'list orders'")),
+			mcp.WithString("tag", mcp.Description("Only return operations with this tag")),
+			mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Maximum number of operations returned, %d by default", defaultSearchLimit))),
+		),
+		Handler: c.search,
+	}
+}
+
+// metaTools returns the tools giving access to the operations of the catalog.
+func (c *operationCatalog) metaTools() []server.ServerTool {
+	return []server.ServerTool{
+		c.searchTool(),
+		{
+			Tool: mcp.NewTool("describe_operation",
+				mcp.WithDescription("Describe an API operation found with search_operations: its parameters, request body, responses and the arguments call_operation expects."),
+				mcp.WithString("operationId", mcp.Required(), mcp.Description("Id of the operation as returned by search_operations")),
+			),
+			Handler: c.describe,
+		},
+		{
+			Tool: mcp.NewTool("call_operation",
+				mcp.WithDescription("Call an API operation with the arguments listed by describe_operation. If you dont have any of the required arguments then always ask user for it, *Dont fill any paramter on your own or keep it empty*. If there is [Error], only state that error in your reponse and stop the reponse there itself."),
+				mcp.WithString("operationId", mcp.Required(), mcp.Description("Id of the operation as returned by search_operations")),
+				mcp.WithObject("arguments", mcp.Description("Arguments of the operation, as described by describe_operation")),
+			),
+			Handler: c.call,
+		},
+	}
+}
+
+// search handles search_operations: operations are ranked by the search index, or listed by id without a query.
+func (c *operationCatalog) search(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query := request.GetString("query", "")
+	tag := request.GetString("tag", "")
+	limit := request.GetInt("limit", defaultSearchLimit)
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	found := []SearchResult{}
+	if strings.TrimSpace(query) == "" {
+		for _, op := range c.all() {
+			found = append(found, op.document.result)
+		}
+	} else {
+		c.mu.RLock()
+		index := c.index
+		c.mu.RUnlock()
+		found = index.Search(query, 0)
+	}
+
+	results := []map[string]interface{}{}
+	for _, op := range found {
+		if tag != "" && !containsFold(op.Tags, tag) {
+			continue
+		}
+		if len(results) == limit {
+			break
+		}
+		result := map[string]interface{}{"operationId": op.ID, "method": op.Method, "path": op.Path}
+		if op.Summary != "" {
+			result["summary"] = op.Summary
+		}
+		if len(op.Tags) > 0 {
+			result["tags"] = op.Tags
+		}
+		if operation, ok := c.find(op.ID); ok && c.mode != ToolModeMeta {
+			result["tool"] = operation.tool.Tool.Name
+			if c.mode == ToolModeToolsets {
+				result["toolsets"] = operation.toolsets
+			}
+		}
+		results = append(results, result)
+	}
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("[Error] %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// describe handles describe_operation.
+func (c *operationCatalog) describe(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id := request.GetString("operationId", "")
+	op, ok := c.find(id)
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("[Error] unknown operation %q, use search_operations to find it", id)), nil
+	}
+	schema, err := json.MarshalIndent(op.tool.Tool.InputSchema, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("[Error] %v", err)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s\n## Arguments of call_operation\n\n```json\n%s\n```\n", op.markdown, schema)), nil
+}
+
+// call handles call_operation: the arguments are checked against the input schema of the operation's tool,
+// then the tool handler makes the request like a tool call in operations mode.
+func (c *operationCatalog) call(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id := request.GetString("operationId", "")
+	op, ok := c.find(id)
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("[Error] unknown operation %q, use search_operations to find it", id)), nil
+	}
+	args := map[string]interface{}{}
+	if value, ok := request.GetArguments()["arguments"]; ok && value != nil {
+		if args, ok = value.(map[string]interface{}); !ok {
+			return mcp.NewToolResultError("[Error] arguments must be an object"), nil
+		}
+	}
+	if err := checkArguments(op.tool.Tool.InputSchema, args); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("[Error] invalid arguments for %s: %v", op.id, err)), nil
+	}
+	call := request
+	call.Params.Name = op.tool.Tool.Name
+	call.Params.Arguments = args
+	return op.tool.Handler(ctx, call)
+}
+
+// checkArguments checks that the arguments of an operation are the string or boolean properties of its tool
+// input schema, that the required ones are present and that values are among the enum of the property.
+func checkArguments(schema mcp.ToolInputSchema, args map[string]interface{}) error {
+	for _, name := range schema.Required {
+		if _, ok := args[name]; !ok {
+			return fmt.Errorf("missing required argument %s", name)
+		}
+	}
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := schema.Properties[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("unknown argument %s", name)
+		}
+		if property["type"] == "boolean" {
+			if _, ok := args[name].(bool); !ok {
+				return fmt.Errorf("argument %s must be a boolean", name)
+			}
+			continue
+		}
+		value, ok := args[name].(string)
+		if !ok {
+			return fmt.Errorf("argument %s must be a string", name)
+		}
+		enum, _ := property["enum"].([]string)
+		if len(enum) == 0 {
+			continue
+		}
+		allowed := false
+		for _, v := range enum {
+			allowed = allowed || v == value
+		}
+		if !allowed {
+			return fmt.Errorf("argument %s must be one of %s", name, strings.Join(enum, ", "))
+		}
+	}
+	return nil
+}
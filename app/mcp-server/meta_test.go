@@ -0,0 +1,206 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const metaSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Shop", "version": "1.0.0"},
+  "paths": {
+    "/orders": {
+      "get": {"operationId": "listOrders", "summary": "List orders", "tags": ["orders"],
+        "parameters": [{"name": "status", "in": "query", "schema": {"type": "string", "enum": ["open", "shipped"]}}],
+        "responses": {"200": {"description": "Orders"}}}
+    },
+    "/orders/{id}": {
+      "get": {"operationId": "getOrder", "summary": "Get an order", "description": "Returns one order with its lines", "tags": ["orders"],
+        "parameters": [{"name": "id", "in": "path", "required": true}],
+        "responses": {"200": {"description": "Order"}}}
+    },
+    "/customers": {
+      "get": {"summary": "List customers", "tags": ["customers"], "responses": {"200": {"description": "Customers"}}}
+    }
+  }
+}`
+
+func newMetaServer(t *testing.T, apiCfg models.ApiConfig) *server.MCPServer {
+	t.Helper()
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+	r := newSpecReloader(mcpServer, models.SpecConfig{ApiCfg: apiCfg})
+	r.catalog = newToolCatalog(mcpServer, ToolModeMeta)
+	if _, err := r.apply(parseTestSpec(t, metaSpec)); err != nil {
+		t.Fatal(err)
+	}
+	return mcpServer
+}
+
+func callTool(t *testing.T, mcpServer *server.MCPServer, name string, args map[string]interface{}) (string, bool) {
+	t.Helper()
+	params, _ := json.Marshal(map[string]interface{}{"name": name, "arguments": args})
+	resp := mcpServer.HandleMessage(context.Background(), json.RawMessage(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":%s}`, params)))
+	data, _ := json.Marshal(resp)
+	var decoded struct {
+		Result struct {
+			Content []mcp.TextContent `json:"content"`
+			IsError bool              `json:"isError"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Result.Content) == 0 {
+		t.Fatalf("unexpected response %s", data)
+	}
+	return decoded.Result.Content[0].Text, decoded.Result.IsError
+}
+
+func TestMetaMode_Tools(t *testing.T) {
+	mcpServer := newMetaServer(t, models.ApiConfig{})
+	resp := mcpServer.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	data, _ := json.Marshal(resp)
+	var decoded struct {
+		Result mcp.ListToolsResult `json:"result"`
+	}
+	json.Unmarshal(data, &decoded)
+	names := []string{}
+	for _, tool := range decoded.Result.Tools {
+		names = append(names, tool.Name)
+	}
+	if fmt.Sprint(names) != "[call_operation describe_operation search_operations]" {
+		t.Errorf("expected only the meta tools, got %v", names)
+	}
+}
+
+func TestMetaMode_Search(t *testing.T) {
+	mcpServer := newMetaServer(t, models.ApiConfig{ToolPrefix: "shop"})
+	ids := func(text string) string {
+		var results []struct {
+			OperationId string `json:"operationId"`
+		}
+		if err := json.Unmarshal([]byte(text), &results); err != nil {
+			t.Fatalf("unexpected search result %s", text)
+		}
+		found := []string{}
+		for _, result := range results {
+			found = append(found, result.OperationId)
+		}
+		return fmt.Sprint(found)
+	}
+
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"all operations", map[string]interface{}{}, "[shop_getOrder shop_get_/customers shop_listOrders]"},
+		{"best match first", map[string]interface{}{"query": "order lines"}, "[shop_getOrder shop_listOrders]"},
+		{"by path", map[string]interface{}{"query": "/customers"}, "[shop_get_/customers]"},
+		{"by tag", map[string]interface{}{"tag": "Orders"}, "[shop_getOrder shop_listOrders]"},
+		{"limit", map[string]interface{}{"query": "list", "limit": 1}, "[shop_listOrders]"},
+		{"no match", map[string]interface{}{"query": "invoices"}, "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, isError := callTool(t, mcpServer, "search_operations", tt.args)
+			if got := ids(text); isError || got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchOperations_ToolModes(t *testing.T) {
+	for _, mode := range []string{ToolModeOperations, ToolModeToolsets} {
+		mcpServer := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+		r := newSpecReloader(mcpServer, models.SpecConfig{ApiCfg: models.ApiConfig{ToolPrefix: "shop"}})
+		r.catalog = newToolCatalog(mcpServer, mode)
+		r.toolsets = newToolsetSet(mcpServer, newSessionStore(models.Config{}), mode, nil)
+		if _, err := r.apply(parseTestSpec(t, metaSpec)); err != nil {
+			t.Fatal(err)
+		}
+		if mode == ToolModeOperations && mcpServer.GetTool("shop_get_/orders") == nil {
+			t.Errorf("expected the operation tools to be registered")
+		}
+
+		text, isError := callTool(t, mcpServer, "search_operations", map[string]interface{}{"query": "order lines", "limit": 1})
+		var results []struct {
+			OperationId string   `json:"operationId"`
+			Tool        string   `json:"tool"`
+			Toolsets    []string `json:"toolsets"`
+		}
+		if err := json.Unmarshal([]byte(text), &results); err != nil || isError || len(results) != 1 {
+			t.Fatalf("%s: unexpected search result %s", mode, text)
+		}
+		want := "{shop_getOrder shop_get_/orders/id []}"
+		if mode == ToolModeToolsets {
+			want = "{shop_getOrder shop_get_/orders/id [shop_orders]}"
+		}
+		if got := fmt.Sprint(results[0]); got != want {
+			t.Errorf("%s: got %s, want %s", mode, got, want)
+		}
+	}
+}
+
+func TestMetaMode_Describe(t *testing.T) {
+	mcpServer := newMetaServer(t, models.ApiConfig{})
+	text, isError := callTool(t, mcpServer, "describe_operation", map[string]interface{}{"operationId": "listOrders"})
+	for _, part := range []string{"GET /orders", "List orders", "## Arguments of call_operation", `"enum": [`} {
+		if isError || !strings.Contains(text, part) {
+			t.Errorf("expected the description to contain %q, got:\n%s", part, text)
+		}
+	}
+	if text, isError = callTool(t, mcpServer, "describe_operation", map[string]interface{}{"operationId": "deleteOrder"}); !isError || !strings.Contains(text, "unknown operation") {
+		t.Errorf("expected an unknown operation error, got %q", text)
+	}
+}
+
+func TestMetaMode_Call(t *testing.T) {
+	requests := []string{}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.String())
+		w.Write([]byte(`{"id": "42"}`))
+	}))
+	defer api.Close()
+	mcpServer := newMetaServer(t, models.ApiConfig{BaseUrl: api.URL})
+
+	text, isError := callTool(t, mcpServer, "call_operation", map[string]interface{}{"operationId": "getOrder", "arguments": map[string]interface{}{"id": "42"}})
+	if isError || text != `{"id": "42"}` || fmt.Sprint(requests) != "[/orders/42]" {
+		t.Errorf("unexpected result %q (error %v) after requests %v", text, isError, requests)
+	}
+
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"missing required", map[string]interface{}{"operationId": "getOrder"}, "missing required argument id"},
+		{"unknown argument", map[string]interface{}{"operationId": "getOrder", "arguments": map[string]interface{}{"id": "1", "expand": "lines"}}, "unknown argument expand"},
+		{"not a string", map[string]interface{}{"operationId": "getOrder", "arguments": map[string]interface{}{"id": 1}}, "argument id must be a string"},
+		{"not a boolean", map[string]interface{}{"operationId": "getOrder", "arguments": map[string]interface{}{"id": "1", "_dryRun": "yes"}}, "argument _dryRun must be a boolean"},
+		{"not in enum", map[string]interface{}{"operationId": "listOrders", "arguments": map[string]interface{}{"status": "lost"}}, "must be one of open, shipped"},
+		{"not an object", map[string]interface{}{"operationId": "listOrders", "arguments": "status=open"}, "arguments must be an object"},
+		{"unknown operation", map[string]interface{}{"operationId": "cancelOrder"}, "unknown operation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if text, isError := callTool(t, mcpServer, "call_operation", tt.args); !isError || !strings.Contains(text, tt.want) {
+				t.Errorf("expected an error containing %q, got %q", tt.want, text)
+			}
+		})
+	}
+	text, isError = callTool(t, mcpServer, "call_operation", map[string]interface{}{"operationId": "getOrder", "arguments": map[string]interface{}{"id": "42", "_dryRun": true}})
+	if isError || !strings.Contains(text, "curl -X GET '"+api.URL+"/orders/42'") {
+		t.Errorf("expected a dry run, got %q", text)
+	}
+	if len(requests) != 1 {
+		t.Errorf("expected invalid calls and dry runs not to reach the API, got %v", requests)
+	}
+}
@@ -0,0 +1,81 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+)
+
+// requestPreview is a request of a tool call as it would be sent, with its credentials redacted.
+type requestPreview struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// previewAPIRequest resolves the request of an endpoint for the call arguments like callAPI, without sending it.
+func previewAPIRequest(
+	ctx context.Context,
+	args map[string]interface{},
+	reqPathParam []string,
+	reqQueryParam []string,
+	reqURL string,
+	reqBody map[string]string,
+	reqMethod string,
+	reqHeader []string,
+	apiCfg models.ApiConfig,
+) (requestPreview, error) {
+	req, body, apiCfg, err := newAPIRequest(ctx, args, reqPathParam, reqQueryParam, reqURL, reqBody, reqMethod, reqHeader, apiCfg)
+	if err != nil {
+		return requestPreview{}, err
+	}
+	trace := upstreamTrace{secrets: traceSecrets(ctx, req, apiCfg)}
+	preview := requestPreview{Method: req.Method, URL: trace.redact(req.URL.String()), Headers: map[string]string{}}
+	for name, values := range req.Header {
+		preview.Headers[name] = trace.redact(strings.Join(values, ", "))
+	}
+	for _, name := range credentialHeaders {
+		if _, ok := preview.Headers[name]; ok {
+			preview.Headers[name] = redacted
+		}
+	}
+	if len(body) > 0 && string(body) != "{}" {
+		preview.Body = trace.redact(string(body))
+	}
+	return preview, nil
+}
+
+// String formats the request as its method and URL, followed by its body.
+func (p requestPreview) String() string {
+	text := fmt.Sprintf("%s %s", p.Method, p.URL)
+	if p.Body != "" {
+		text += "\n\n" + strings.TrimSpace(p.Body)
+	}
+	return text
+}
+
+// curl formats the request as a curl command, with its headers sorted by name.
+func (p requestPreview) curl() string {
+	names := make([]string, 0, len(p.Headers))
+	for name := range p.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := []string{"curl -X " + p.Method + " " + shellQuote(p.URL)}
+	for _, name := range names {
+		parts = append(parts, "-H "+shellQuote(name+": "+p.Headers[name]))
+	}
+	if p.Body != "" {
+		parts = append(parts, "--data "+shellQuote(p.Body))
+	}
+	return strings.Join(parts, " \\\n  ")
+}
+
+// shellQuote quotes a value for a POSIX shell.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
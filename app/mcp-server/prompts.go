@@ -0,0 +1,187 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	appconfig "github.com/danishjsheikh/swagger-mcp/app/config"
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// promptsExtension is the spec extension declaring custom prompts, a list in the format of models.PromptConfig.
+const promptsExtension = "x-mcp-prompts"
+
+// BuildSwaggerPrompts builds the prompts of a spec without registering them (see specPrompts).
+func BuildSwaggerPrompts(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig) []server.ServerPrompt {
+	prompts := []server.ServerPrompt{}
+	for _, prompt := range specPrompts(swaggerSpec, apiCfg, ToolModeOperations) {
+		prompts = append(prompts, newServerPrompt(apiCfg.ToolPrefix, prompt))
+	}
+	return prompts
+}
+
+// specPrompts returns the prompts of a spec: one per tag summarising the operations that have a tool, the prompts
+// declared in the x-mcp-prompts extension and the prompts of the config. A prompt declared in the config replaces
+// a spec prompt of the same name, which replaces a tag prompt. Tag prompts refer to the operations the way the
+// tool mode exposes them.
+func specPrompts(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig, toolMode string) []models.PromptConfig {
+	_, doc, err := specDocument(swaggerSpec)
+	if err != nil {
+		return nil
+	}
+	byName := map[string]models.PromptConfig{}
+	for _, prompt := range tagPrompts(doc, apiCfg, specTitle(swaggerSpec), toolMode) {
+		byName[prompt.Name] = prompt
+	}
+	for _, prompt := range extensionPrompts(doc) {
+		byName[prompt.Name] = prompt
+	}
+	for _, prompt := range apiCfg.Prompts {
+		byName[prompt.Name] = prompt
+	}
+	prompts := make([]models.PromptConfig, 0, len(byName))
+	for _, prompt := range byName {
+		prompts = append(prompts, prompt)
+	}
+	sort.Slice(prompts, func(i, j int) bool { return prompts[i].Name < prompts[j].Name })
+	return prompts
+}
+
+// tagPrompts returns a prompt for every tag of the operations that have a tool, listing the tools of the tag.
+// In meta mode the operation ids to pass to call_operation are listed instead, and in toolsets mode the prompt
+// names the toolset to enable. The optional task argument is appended to the message.
+func tagPrompts(doc map[string]interface{}, apiCfg models.ApiConfig, title, toolMode string) []models.PromptConfig {
+	descriptions := map[string]string{}
+	tagList, _ := doc["tags"].([]interface{})
+	for _, entry := range tagList {
+		tag, _ := entry.(map[string]interface{})
+		name, _ := tag["name"].(string)
+		description, _ := tag["description"].(string)
+		descriptions[name] = description
+	}
+
+	tools := map[string][]string{}
+	tags := []string{}
+	for _, op := range specOperations(doc, apiCfg) {
+		opTags, _ := op.details["tags"].([]interface{})
+		for _, entry := range opTags {
+			tag, ok := entry.(string)
+			if !ok || tag == "" {
+				continue
+			}
+			if _, seen := tools[tag]; !seen {
+				tags = append(tags, tag)
+			}
+			name := op.toolName
+			if toolMode == ToolModeMeta {
+				operationID, _ := op.details["operationId"].(string)
+				name = operationRef(apiCfg, operationID, op.toolName)
+			}
+			line := fmt.Sprintf("- `%s` (%s %s)", name, strings.ToUpper(op.method), op.path)
+			if summary, _ := op.details["summary"].(string); summary != "" {
+				line += ": " + summary
+			}
+			tools[tag] = append(tools[tag], line)
+		}
+	}
+	sort.Strings(tags)
+
+	prompts := []models.PromptConfig{}
+	for _, tag := range tags {
+		description := descriptions[tag]
+		if description == "" {
+			description = fmt.Sprintf("Work with the %s operations of the %s API", tag, title)
+		}
+		var text strings.Builder
+		kind := "tools"
+		if toolMode == ToolModeMeta {
+			kind = "operations"
+		}
+		fmt.Fprintf(&text, "Use the %s of the %s API for %s.\n\n", kind, title, tag)
+		if descriptions[tag] != "" {
+			fmt.Fprintf(&text, "%s\n\n", descriptions[tag])
+		}
+		switch toolMode {
+		case ToolModeMeta:
+			fmt.Fprintf(&text, "Available operations, to call with call_operation after learning their arguments with describe_operation:\n%s\n\n{task}", strings.Join(tools[tag], "\n"))
+		case ToolModeToolsets:
+			fmt.Fprintf(&text, "Available tools, in the `%s` toolset; enable it with enable_toolset if they are not listed:\n%s\n\n{task}", toolsetName(apiCfg.ToolPrefix, tag), strings.Join(tools[tag], "\n"))
+		default:
+			fmt.Fprintf(&text, "Available tools:\n%s\n\n{task}", strings.Join(tools[tag], "\n"))
+		}
+		prompts = append(prompts, models.PromptConfig{
+			Name:        strings.Join(strings.Fields(tag), "_"),
+			Description: description,
+			Arguments:   []models.PromptArgument{{Name: "task", Description: "What you want to do"}},
+			Messages:    []models.PromptMessage{{Text: text.String()}},
+		})
+	}
+	return prompts
+}
+
+// extensionPrompts returns the prompts declared in the x-mcp-prompts extension of a spec document.
+// Invalid prompts are logged and skipped.
+func extensionPrompts(doc map[string]interface{}) []models.PromptConfig {
+	value, ok := doc[promptsExtension]
+	if !ok {
+		return nil
+	}
+	data, _ := json.Marshal(value)
+	declared := []models.PromptConfig{}
+	if err := json.Unmarshal(data, &declared); err != nil {
+		log.Printf("Ignoring %s: %v", promptsExtension, err)
+		return nil
+	}
+	prompts := []models.PromptConfig{}
+	for i, prompt := range declared {
+		if err := appconfig.ValidatePrompt(fmt.Sprintf("%s[%d]", promptsExtension, i), prompt); err != nil {
+			log.Printf("Ignoring %v", err)
+			continue
+		}
+		prompts = append(prompts, prompt)
+	}
+	return prompts
+}
+
+// newServerPrompt builds a prompt whose name is namespaced by the prefix. Getting the prompt fills in
+// the {argument} placeholders of its messages; placeholders of arguments that were not given are left empty.
+func newServerPrompt(prefix string, prompt models.PromptConfig) server.ServerPrompt {
+	opts := []mcp.PromptOption{mcp.WithPromptDescription(prompt.Description)}
+	for _, arg := range prompt.Arguments {
+		argOpts := []mcp.ArgumentOption{mcp.ArgumentDescription(arg.Description)}
+		if arg.Required {
+			argOpts = append(argOpts, mcp.RequiredArgument())
+		}
+		opts = append(opts, mcp.WithArgument(arg.Name, argOpts...))
+	}
+	return server.ServerPrompt{
+		Prompt: mcp.NewPrompt(buildOverrideToolName(prefix, prompt.Name), opts...),
+		Handler: func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			replacements := []string{}
+			for _, arg := range prompt.Arguments {
+				value := request.Params.Arguments[arg.Name]
+				if arg.Required && value == "" {
+					return nil, fmt.Errorf("missing required argument %s", arg.Name)
+				}
+				replacements = append(replacements, "{"+arg.Name+"}", value)
+			}
+			replacer := strings.NewReplacer(replacements...)
+			messages := []mcp.PromptMessage{}
+			for _, message := range prompt.Messages {
+				role := mcp.RoleUser
+				if message.Role == string(mcp.RoleAssistant) {
+					role = mcp.RoleAssistant
+				}
+				text := strings.TrimSpace(replacer.Replace(message.Text))
+				messages = append(messages, mcp.NewPromptMessage(role, mcp.NewTextContent(text)))
+			}
+			return mcp.NewGetPromptResult(prompt.Description, messages), nil
+		},
+	}
+}
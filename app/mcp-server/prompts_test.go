@@ -0,0 +1,167 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const promptsSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Pets", "version": "1.0.0"},
+  "tags": [{"name": "pets", "description": "Everything about your pets"}],
+  "x-mcp-prompts": [
+    {"name": "adopt", "description": "Adopt a pet", "arguments": [{"name": "species", "required": true}],
+     "messages": [{"text": "Find an available {species} and adopt it."}, {"role": "assistant", "text": "Looking for a {species}."}]},
+    {"name": "bad name", "messages": [{"text": "ignored"}]}
+  ],
+  "paths": {
+    "/pets": {
+      "get": {"operationId": "listPets", "summary": "List pets", "tags": ["pets"], "responses": {"200": {"description": "Pets"}}},
+      "post": {"operationId": "createPet", "tags": ["pets"], "responses": {"201": {"description": "Created"}}}
+    },
+    "/stores": {"get": {"operationId": "listStores", "tags": ["pet stores"], "responses": {"200": {"description": "Stores"}}}}
+  }
+}`
+
+func promptNames(prompts []server.ServerPrompt) string {
+	names := []string{}
+	for _, prompt := range prompts {
+		names = append(names, prompt.Prompt.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func getPrompt(t *testing.T, prompt server.ServerPrompt, args map[string]string) (*mcp.GetPromptResult, error) {
+	t.Helper()
+	request := mcp.GetPromptRequest{}
+	request.Params.Name = prompt.Prompt.Name
+	request.Params.Arguments = args
+	return prompt.Handler(context.Background(), request)
+}
+
+func TestBuildSwaggerPrompts(t *testing.T) {
+	prompts := BuildSwaggerPrompts(parseTestSpec(t, promptsSpec), models.ApiConfig{ExcludeMethods: "POST"})
+	if got := promptNames(prompts); got != "adopt pet_stores pets" {
+		t.Fatalf("unexpected prompts %s", got)
+	}
+
+	pets := prompts[2]
+	if pets.Prompt.Description != "Everything about your pets" || len(pets.Prompt.Arguments) != 1 || pets.Prompt.Arguments[0].Required {
+		t.Errorf("unexpected tag prompt %+v", pets.Prompt)
+	}
+	result, err := getPrompt(t, pets, map[string]string{"task": "Find all cats"})
+	if err != nil || len(result.Messages) != 1 {
+		t.Fatalf("unexpected result %+v, %v", result, err)
+	}
+	text := result.Messages[0].Content.(mcp.TextContent).Text
+	for _, part := range []string{"Use the tools of the Pets API for pets.", "- `get_/pets` (GET /pets): List pets", "Find all cats"} {
+		if !strings.Contains(text, part) {
+			t.Errorf("expected tag prompt to contain %q, got:\n%s", part, text)
+		}
+	}
+	if strings.Contains(text, "post_/pets") {
+		t.Errorf("expected operations without a tool to be left out, got:\n%s", text)
+	}
+
+	adopt := prompts[0]
+	if _, err := getPrompt(t, adopt, nil); err == nil {
+		t.Error("expected an error without the required argument")
+	}
+	result, err = getPrompt(t, adopt, map[string]string{"species": "cat"})
+	if err != nil || len(result.Messages) != 2 {
+		t.Fatalf("unexpected result %+v, %v", result, err)
+	}
+	if text := result.Messages[0].Content.(mcp.TextContent).Text; text != "Find an available cat and adopt it." {
+		t.Errorf("unexpected message %q", text)
+	}
+	if result.Messages[1].Role != mcp.RoleAssistant {
+		t.Errorf("expected an assistant message, got %s", result.Messages[1].Role)
+	}
+}
+
+func TestSpecPrompts_ToolModes(t *testing.T) {
+	spec := parseTestSpec(t, promptsSpec)
+	apiCfg := models.ApiConfig{ToolPrefix: "shop"}
+	tests := []struct {
+		mode string
+		want []string
+	}{
+		{ToolModeOperations, []string{"Use the tools of the Pets API for pets.", "- `shop_get_/pets` (GET /pets): List pets"}},
+		{ToolModeMeta, []string{"Use the operations of the Pets API for pets.", "call_operation", "- `shop_listPets` (GET /pets): List pets"}},
+		{ToolModeToolsets, []string{"in the `shop_pets` toolset; enable it with enable_toolset", "- `shop_get_/pets` (GET /pets): List pets"}},
+	}
+	for _, tt := range tests {
+		for _, prompt := range specPrompts(spec, apiCfg, tt.mode) {
+			if prompt.Name != "pets" {
+				continue
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(prompt.Messages[0].Text, want) {
+					t.Errorf("%s: expected %q in:\n%s", tt.mode, want, prompt.Messages[0].Text)
+				}
+			}
+		}
+	}
+}
+
+func TestBuildSwaggerPrompts_ConfigOverridesSpec(t *testing.T) {
+	prompts := BuildSwaggerPrompts(parseTestSpec(t, promptsSpec), models.ApiConfig{
+		ToolPrefix: "shop",
+		Prompts: []models.PromptConfig{
+			{Name: "adopt", Description: "Adopt from the shelter", Messages: []models.PromptMessage{{Text: "Adopt a pet from the shelter."}}},
+			{Name: "restock", Messages: []models.PromptMessage{{Text: "Restock the stores."}}},
+		},
+	})
+	if got := promptNames(prompts); got != "shop_adopt shop_pet_stores shop_pets shop_restock" {
+		t.Fatalf("unexpected prompts %s", got)
+	}
+	if prompts[0].Prompt.Description != "Adopt from the shelter" || len(prompts[0].Prompt.Arguments) != 0 {
+		t.Errorf("expected the config prompt to replace the spec prompt, got %+v", prompts[0].Prompt)
+	}
+}
+
+func TestSpecReloader_Prompts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.json")
+	writeSpecFile(t, path, promptsSpec)
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithPromptCapabilities(true))
+	r := newSpecReloader(mcpServer, models.SpecConfig{SpecUrl: "file://" + path})
+	if _, err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	listPrompts := func() string {
+		resp := mcpServer.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"prompts/list"}`))
+		data, _ := json.Marshal(resp)
+		var decoded struct {
+			Result mcp.ListPromptsResult `json:"result"`
+		}
+		json.Unmarshal(data, &decoded)
+		names := []string{}
+		for _, prompt := range decoded.Result.Prompts {
+			names = append(names, prompt.Name)
+		}
+		return fmt.Sprint(names)
+	}
+	if got := listPrompts(); got != "[adopt pet_stores pets]" {
+		t.Fatalf("unexpected prompts %s", got)
+	}
+
+	// Prompts of tags no longer in the spec are removed
+	writeSpecFile(t, path, strings.Replace(promptsSpec, `"tags": ["pet stores"], `, "", 1))
+	if changed, err := r.Reload(); err != nil || !changed {
+		t.Fatalf("expected change, got changed=%v err=%v", changed, err)
+	}
+	if got := listPrompts(); got != "[adopt pets]" {
+		t.Errorf("unexpected prompts after reload %s", got)
+	}
+	if changed, err := r.Reload(); err != nil || changed {
+		t.Errorf("expected no change, got changed=%v err=%v", changed, err)
+	}
+}
@@ -0,0 +1,49 @@
+package mcpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+)
+
+// extensionSafe marks a POST operation that only reads, such as a search, as safe to call in read-only mode.
+const extensionSafe = "x-mcp-safe"
+
+// safeMethods are the HTTP methods that do not change data (the safe methods of RFC 9110). Their tools are
+// annotated read-only, and they are the only ones sent in read-only mode besides the POST operations
+// allow-listed as safe.
+var safeMethods = map[string]bool{http.MethodGet: true, http.MethodHead: true, http.MethodOptions: true, http.MethodTrace: true}
+
+// safeOperation reports whether an operation cannot change data: it uses a safe method, or it is a POST
+// operation marked safe by its override or its x-mcp-safe extension.
+func safeOperation(method string, override models.OperationOverride, details models.Endpoint) bool {
+	method = strings.ToUpper(method)
+	if safeMethods[method] {
+		return true
+	}
+	if method != http.MethodPost {
+		return false
+	}
+	if override.Safe {
+		return true
+	}
+	var safe bool
+	if raw, ok := details.Extensions[extensionSafe]; ok {
+		json.Unmarshal(raw, &safe)
+	}
+	return safe
+}
+
+// checkReadOnly refuses a request that could change data when apiCfg is in read-only mode. Tools of
+// unsafe operations are not registered in that mode, so this only stops requests that slipped through,
+// such as the cancellation of a long-running operation.
+func checkReadOnly(method string, apiCfg models.ApiConfig) error {
+	method = strings.ToUpper(method)
+	if !apiCfg.ReadOnly || safeMethods[method] || method == http.MethodPost && apiCfg.SafeOperation {
+		return nil
+	}
+	return fmt.Errorf("%s requests are not allowed in read-only mode", method)
+}
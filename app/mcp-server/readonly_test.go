@@ -0,0 +1,105 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const readOnlySpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Shop", "version": "1.0.0"},
+  "paths": {
+    "/orders": {
+      "get": {"operationId": "listOrders", "responses": {"200": {"description": "Orders"}}},
+      "post": {"operationId": "createOrder", "responses": {"201": {"description": "Created"}}}
+    },
+    "/orders/{id}": {
+      "delete": {"operationId": "deleteOrder", "x-mcp-safe": true, "responses": {"204": {"description": "Deleted"}}}
+    },
+    "/orders/search": {
+      "post": {"operationId": "searchOrders", "x-mcp-safe": true, "responses": {"200": {"description": "Orders"}}}
+    },
+    "/reports": {
+      "post": {"operationId": "runReport", "responses": {"200": {"description": "Report"}}}
+    },
+    "/echo": {
+      "trace": {"operationId": "traceEcho", "responses": {"200": {"description": "Echo"}}}
+    }
+  }
+}`
+
+func TestReadOnly_Tools(t *testing.T) {
+	requests := []string{}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Write([]byte(`[]`))
+	}))
+	defer api.Close()
+	apiCfg := models.ApiConfig{
+		BaseUrl:   api.URL,
+		ReadOnly:  true,
+		Overrides: map[string]models.OperationOverride{"runReport": {Safe: true}},
+	}
+
+	tools := map[string]server.ServerTool{}
+	names := []string{}
+	for _, tool := range BuildSwaggerTools(parseTestSpec(t, readOnlySpec), apiCfg) {
+		tools[tool.Tool.Name] = tool
+		names = append(names, tool.Tool.Name)
+	}
+	sort.Strings(names)
+	// x-mcp-safe only allows POST operations
+	if got := fmt.Sprint(names); got != "[get_/orders post_/orders/search post_/reports trace_/echo]" {
+		t.Fatalf("expected only the safe operations, got %s", got)
+	}
+	if annotations := tools["post_/orders/search"].Tool.Annotations; !*annotations.ReadOnlyHint || *annotations.DestructiveHint {
+		t.Errorf("expected a safe POST operation to be annotated read-only, got %+v", annotations)
+	}
+
+	if annotations := tools["trace_/echo"].Tool.Annotations; !*annotations.ReadOnlyHint {
+		t.Errorf("expected a TRACE operation to be annotated read-only, got %+v", annotations)
+	}
+
+	for _, name := range []string{"get_/orders", "post_/orders/search", "post_/reports", "trace_/echo"} {
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		if result, err := tools[name].Handler(context.Background(), request); err != nil || result.IsError {
+			t.Errorf("%s: unexpected result %+v, %v", name, result, err)
+		}
+	}
+	if got := fmt.Sprint(requests); got != "[GET /orders POST /orders/search POST /reports TRACE /echo]" {
+		t.Errorf("unexpected requests %s", got)
+	}
+}
+
+func TestReadOnly_RefusesUnsafeRequests(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected %s request in read-only mode", r.Method)
+	}))
+	defer api.Close()
+
+	tests := []struct {
+		method string
+		apiCfg models.ApiConfig
+	}{
+		{"delete", models.ApiConfig{ReadOnly: true}},
+		{"put", models.ApiConfig{ReadOnly: true, SafeOperation: true}},
+		{"post", models.ApiConfig{ReadOnly: true}},
+	}
+	for _, tt := range tests {
+		handler := CreateMCPToolHandler(nil, nil, api.URL+"/orders", nil, tt.method, nil, tt.apiCfg)
+		result, _ := handler(context.Background(), mcp.CallToolRequest{})
+		if text := result.Content[0].(mcp.TextContent).Text; !result.IsError || !strings.Contains(text, "not allowed in read-only mode") {
+			t.Errorf("%s: expected the request to be refused, got %q", tt.method, text)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package mcpserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/danishjsheikh/swagger-mcp/app/swagger"
+	"github.com/fsnotify/fsnotify"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// DefaultPollInterval is how often HTTP specs are polled for changes when no interval is configured.
+const DefaultPollInterval = 30 * time.Second
+
+// reloadDebounce groups bursts of file events (editors and Kubernetes write files in several steps) into one reload.
+const reloadDebounce = 250 * time.Millisecond
+
+// specReloader keeps the tools, resources, prompts and resource templates registered for one spec in sync with the spec source.
+type specReloader struct {
+	mcpServer   *server.MCPServer
+	spec        models.SpecConfig
+	templates   *templateSet      // resource templates of the server, shared by the specs it serves
+	completions *completionSet    // completions of the server, shared by the specs it serves
+	catalog     *operationCatalog // operations behind the meta tools or search_operations, nil if the server has neither
+	toolsets    *toolsetSet       // toolsets of the server, nil unless the server is in toolsets mode
+
+	mu            sync.Mutex
+	tools         map[string]string // tool name -> fingerprint of the tool definition and its handler inputs
+	resources     map[string]string // resource URI -> JSON encoding of the resource and its contents
+	prompts       map[string]string // prompt name -> JSON encoding of the prompt declaration
+	templatesData string            // JSON encoding of the resource templates
+	version       swagger.SpecVersion
+	current       *models.SwaggerSpec // last spec applied, nil until one is
+}
+
+func newSpecReloader(mcpServer *server.MCPServer, spec models.SpecConfig) *specReloader {
+	return &specReloader{
+		mcpServer:     mcpServer,
+		spec:          spec,
+		templates:     newTemplateSet(mcpServer),
+		completions:   newCompletionSet(),
+		tools:         map[string]string{},
+		resources:     map[string]string{},
+		prompts:       map[string]string{},
+		templatesData: "[]",
+	}
+}
+
+// toolMode returns how the operations of the spec are exposed.
+func (r *specReloader) toolMode() string {
+	switch {
+	case r.catalog.meta():
+		return ToolModeMeta
+	case r.toolsets != nil:
+		return ToolModeToolsets
+	}
+	return ToolModeOperations
+}
+
+// buildSpecTools builds the tools of a spec, turning a panic caused by a malformed spec into an error.
+func buildSpecTools(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig) (tools []server.ServerTool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("error registering tools: %v", r)
+		}
+	}()
+	return BuildSwaggerTools(swaggerSpec, apiCfg), nil
+}
+
+// apply updates the server so that its tools, resources, prompts and resource templates match the spec: new and modified ones are (re)registered
+// and the ones no longer generated from the spec are removed. Clients are notified of resources whose contents changed.
+// It returns whether any tool or resource changed.
+func (r *specReloader) apply(swaggerSpec models.SwaggerSpec) (bool, error) {
+	tools, err := buildSpecTools(swaggerSpec, r.spec.ApiCfg)
+	if err != nil {
+		return false, err
+	}
+	resources := buildSpecResources(swaggerSpec, r.spec.ApiCfg)
+	resourceData, err := resourceFingerprints(resources)
+	if err != nil {
+		return false, err
+	}
+	specTemplates := buildSpecTemplates(swaggerSpec, r.spec.ApiCfg)
+	templates := make([]server.ServerResourceTemplate, len(specTemplates))
+	for i, template := range specTemplates {
+		templates[i] = template.ServerResourceTemplate
+	}
+	templatesData, err := encodeTemplates(templates)
+	if err != nil {
+		return false, err
+	}
+	prompts := specPrompts(swaggerSpec, r.spec.ApiCfg, r.toolMode())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.catalog != nil {
+		r.catalog.set(r, buildMetaOperations(swaggerSpec, r.spec.ApiCfg, tools))
+	}
+	if r.toolsets != nil {
+		// in toolsets mode only the tools of the core toolsets are registered for every session
+		tools = r.toolsets.set(r, buildToolsets(swaggerSpec, r.spec.ApiCfg, tools))
+	}
+	next, err := toolFingerprints(swaggerSpec, r.spec.ApiCfg, tools)
+	if err != nil {
+		return false, err
+	}
+	updated := []server.ServerTool{}
+	for _, tool := range tools {
+		if r.tools[tool.Tool.Name] != next[tool.Tool.Name] {
+			updated = append(updated, tool)
+		}
+	}
+	removed := []string{}
+	for name := range r.tools {
+		if _, ok := next[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+
+	// in meta mode the tools are only called through call_operation
+	if !r.catalog.meta() {
+		if len(removed) > 0 {
+			r.mcpServer.DeleteTools(removed...)
+		}
+		if len(updated) > 0 {
+			r.mcpServer.AddTools(updated...)
+		}
+	}
+	r.tools = next
+	resourcesChanged := r.applyResources(resources, resourceData)
+	promptsChanged, err := r.applyPrompts(prompts)
+	if err != nil {
+		return false, err
+	}
+	r.completions.set(r, buildSpecCompletions(swaggerSpec, r.spec.ApiCfg, specTemplates, prompts))
+	templatesChanged := templatesData != r.templatesData
+	if templatesChanged {
+		r.templates.set(r, templates)
+		r.templatesData = templatesData
+	}
+	if r.current == nil {
+		// report which operations got a tool when the spec is first loaded
+		logFilterReport(specTitle(swaggerSpec), filterReport(swaggerSpec, r.spec.ApiCfg))
+	}
+	r.current = &swaggerSpec
+	return len(removed) > 0 || len(updated) > 0 || resourcesChanged || promptsChanged || templatesChanged, nil
+}
+
+// toolFingerprints returns a fingerprint of every tool, keyed by name, to detect changes. Besides the tool
+// definition it covers what the handler is built from: the API config, the operation and the parts of the spec
+// shared by all operations (servers, host, basePath, schemas), so a tool whose URL, headers or schemas changed gets a
+// new handler even if its definition did not.
+func toolFingerprints(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig, tools []server.ServerTool) (map[string]string, error) {
+	_, doc, err := specDocument(swaggerSpec)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding spec: %v", err)
+	}
+	paths, _ := doc["paths"].(map[string]interface{})
+	shared := make(map[string]interface{}, len(doc))
+	for key, value := range doc {
+		if key != "paths" {
+			shared[key] = value
+		}
+	}
+	common, err := json.Marshal([]interface{}{apiCfg, shared})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding spec: %v", err)
+	}
+	operations := map[string]interface{}{}
+	for _, op := range specOperations(doc, apiCfg) {
+		// the whole path item, as its parameters apply to the operation
+		operations[op.toolName] = []interface{}{op.method, op.path, paths[op.path]}
+	}
+
+	fingerprints := make(map[string]string, len(tools))
+	for _, tool := range tools {
+		data, err := json.Marshal([]interface{}{tool.Tool, operations[tool.Tool.Name]})
+		if err != nil {
+			return nil, fmt.Errorf("error encoding tool %s: %v", tool.Tool.Name, err)
+		}
+		hash := sha256.New()
+		hash.Write(common)
+		hash.Write(data)
+		fingerprints[tool.Tool.Name] = hex.EncodeToString(hash.Sum(nil))
+	}
+	return fingerprints, nil
+}
+
+// applyPrompts registers new and modified prompts and removes stale ones. It must be called with r.mu held.
+func (r *specReloader) applyPrompts(prompts []models.PromptConfig) (bool, error) {
+	next := make(map[string]string, len(prompts))
+	updated := []server.ServerPrompt{}
+	for _, prompt := range prompts {
+		data, err := json.Marshal(prompt)
+		if err != nil {
+			return false, fmt.Errorf("error encoding prompt %s: %v", prompt.Name, err)
+		}
+		next[prompt.Name] = string(data)
+		if r.prompts[prompt.Name] != string(data) {
+			updated = append(updated, newServerPrompt(r.spec.ApiCfg.ToolPrefix, prompt))
+		}
+	}
+	removed := []string{}
+	for name := range r.prompts {
+		if _, ok := next[name]; !ok {
+			removed = append(removed, buildOverrideToolName(r.spec.ApiCfg.ToolPrefix, name))
+		}
+	}
+
+	if len(removed) > 0 {
+		r.mcpServer.DeletePrompts(removed...)
+	}
+	if len(updated) > 0 {
+		r.mcpServer.AddPrompts(updated...)
+	}
+	r.prompts = next
+	return len(removed) > 0 || len(updated) > 0, nil
+}
+
+// resourceFingerprints returns a fingerprint of every resource and the text it reads as, keyed by URI, to detect
+// changes without reading the resources.
+func resourceFingerprints(resources []specResource) (map[string]string, error) {
+	fingerprints := make(map[string]string, len(resources))
+	for _, resource := range resources {
+		data, err := json.Marshal([]interface{}{resource.Resource, resource.text})
+		if err != nil {
+			return nil, fmt.Errorf("error encoding resource %s: %v", resource.Resource.URI, err)
+		}
+		hash := sha256.Sum256(data)
+		fingerprints[resource.Resource.URI] = hex.EncodeToString(hash[:])
+	}
+	return fingerprints, nil
+}
+
+// applyResources registers new and modified resources and removes stale ones. It must be called with r.mu held.
+// Registering them notifies clients with resources/list_changed; resources/updated is not sent, as it is only
+// for clients subscribed to a resource and the server does not support subscriptions.
+func (r *specReloader) applyResources(resources []specResource, fingerprints map[string]string) bool {
+	updated := []server.ServerResource{}
+	for _, resource := range resources {
+		if r.resources[resource.Resource.URI] != fingerprints[resource.Resource.URI] {
+			updated = append(updated, resource.ServerResource)
+		}
+	}
+	removed := []string{}
+	for uri := range r.resources {
+		if _, ok := fingerprints[uri]; !ok {
+			removed = append(removed, uri)
+		}
+	}
+
+	if len(removed) > 0 {
+		r.mcpServer.DeleteResources(removed...)
+	}
+	if len(updated) > 0 {
+		r.mcpServer.AddResources(updated...)
+	}
+	r.resources = fingerprints
+	return len(removed) > 0 || len(updated) > 0
+}
+
+// currentSpec returns the last spec applied, if any.
+func (r *specReloader) currentSpec() (models.SwaggerSpec, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current == nil {
+		return models.SwaggerSpec{}, false
+	}
+	return *r.current, true
+}
+
+// Reload fetches the spec again and applies it. If the spec cannot be loaded or is invalid,
+// the tools and resources of the last good spec are kept.
+func (r *specReloader) Reload() (bool, error) {
+	swaggerSpec, version, loaded, err := swagger.LoadSwaggerIfChanged(r.spec.SpecUrl, r.version)
+	if err != nil {
+		return false, err
+	}
+	if !loaded {
+		return false, nil
+	}
+	if len(swaggerSpec.Paths) == 0 {
+		return false, fmt.Errorf("spec has no paths")
+	}
+	changed, err := r.apply(swaggerSpec)
+	if err != nil {
+		return false, err
+	}
+	r.version = version
+	return changed, nil
+}
+
+// reloadAndLog reloads the spec and reports the outcome.
+func (r *specReloader) reloadAndLog() {
+	changed, err := r.Reload()
+	if err != nil {
+		log.Printf("Failed to reload spec %s, keeping last good spec: %v", specLabel(r.spec), err)
+		return
+	}
+	if changed {
+		log.Printf("Reloaded spec %s, tools and resources updated", specLabel(r.spec))
+	}
+}
+
+// watch reloads the spec whenever it changes until ctx is cancelled.
+// file:// specs are watched with fsnotify; other specs are polled every pollInterval.
+func (r *specReloader) watch(ctx context.Context, pollInterval time.Duration) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	if strings.HasPrefix(r.spec.SpecUrl, "file://") || !strings.Contains(r.spec.SpecUrl, "://") {
+		err := r.watchFile(ctx)
+		if err == nil {
+			return
+		}
+		log.Printf("Cannot watch spec %s, polling instead: %v", specLabel(r.spec), err)
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reloadAndLog()
+		}
+	}
+}
+
+// watchFile watches the directory of a file spec, so atomic renames and symlink swaps are also seen.
+func (r *specReloader) watchFile(ctx context.Context) error {
+	path := strings.TrimPrefix(r.spec.SpecUrl, "file://")
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			debounce = time.After(reloadDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("Error watching spec %s: %v", specLabel(r.spec), err)
+		case <-debounce:
+			debounce = nil
+			r.reloadAndLog()
+		}
+	}
+}
+
+// watchSpecs starts a watcher for every spec and returns immediately.
+func watchSpecs(ctx context.Context, reloaders []*specReloader, reloadCfg models.ReloadConfig) {
+	if !reloadCfg.Watch {
+		return
+	}
+	for _, r := range reloaders {
+		go r.watch(ctx, reloadCfg.PollInterval)
+	}
+}
@@ -0,0 +1,124 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func writeSpecFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write spec: %v", err)
+	}
+}
+
+func TestSpecReloader_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.json")
+	writeSpecFile(t, path, `{"swagger":"2.0","host":"example.com","paths":{"/users":{"get":{"summary":"List users"}},"/orders":{"get":{"summary":"List orders"}}}}`)
+
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+	r := newSpecReloader(mcpServer, models.SpecConfig{SpecUrl: "file://" + path})
+	if _, err := r.Reload(); err != nil {
+		t.Fatalf("initial reload failed: %v", err)
+	}
+	if got := fmt.Sprint(listToolNames(t, mcpServer)); got != "[get_/orders get_/users]" {
+		t.Fatalf("unexpected initial tools %s", got)
+	}
+
+	// unchanged spec does not touch the tools
+	if changed, err := r.Reload(); err != nil || changed {
+		t.Errorf("expected no change, got changed=%v err=%v", changed, err)
+	}
+
+	// removed and added operations are reflected
+	writeSpecFile(t, path, `{"swagger":"2.0","host":"example.com","paths":{"/users":{"get":{"summary":"List users"},"post":{"summary":"Create user"}}}}`)
+	if changed, err := r.Reload(); err != nil || !changed {
+		t.Fatalf("expected change, got changed=%v err=%v", changed, err)
+	}
+	if got := fmt.Sprint(listToolNames(t, mcpServer)); got != "[get_/users post_/users]" {
+		t.Errorf("unexpected tools after reload %s", got)
+	}
+
+	// an invalid spec keeps the last good tools
+	writeSpecFile(t, path, `{"swagger":`)
+	if _, err := r.Reload(); err == nil {
+		t.Error("expected error for invalid spec")
+	}
+	writeSpecFile(t, path, `{"swagger":"2.0","paths":{}}`)
+	if _, err := r.Reload(); err == nil {
+		t.Error("expected error for spec without paths")
+	}
+	if got := fmt.Sprint(listToolNames(t, mcpServer)); got != "[get_/users post_/users]" {
+		t.Errorf("expected last good tools to be kept, got %s", got)
+	}
+}
+
+func TestSpecReloader_HandlerInputs(t *testing.T) {
+	hits := map[string]int{}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		w.Write([]byte(`[]`))
+	}))
+	defer api.Close()
+	spec := func(basePath string) string {
+		return fmt.Sprintf(`{"openapi":"3.0.0","servers":[{"url":%q}],"paths":{"/users":{"get":{"summary":"List users"}}}}`, api.URL+basePath)
+	}
+	path := filepath.Join(t.TempDir(), "spec.json")
+	writeSpecFile(t, path, spec("/v1"))
+
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+	r := newSpecReloader(mcpServer, models.SpecConfig{SpecUrl: "file://" + path})
+	if _, err := r.Reload(); err != nil {
+		t.Fatalf("initial reload failed: %v", err)
+	}
+
+	// the tool definition is the same, but its handler must call the new server URL
+	writeSpecFile(t, path, spec("/v2"))
+	if changed, err := r.Reload(); err != nil || !changed {
+		t.Fatalf("expected change, got changed=%v err=%v", changed, err)
+	}
+	if _, err := mcpServer.GetTool("get_/users").Handler(context.Background(), mcp.CallToolRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if hits["/v2/users"] != 1 || hits["/v1/users"] != 0 {
+		t.Errorf("expected the reloaded handler to call /v2/users, got %v", hits)
+	}
+}
+
+func TestSpecReloader_HTTPNotModified(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"swagger":"2.0","host":"example.com","paths":{"/users":{"get":{"summary":"List users"}}}}`))
+	}))
+	defer ts.Close()
+
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	r := newSpecReloader(mcpServer, models.SpecConfig{SpecUrl: ts.URL})
+	if changed, err := r.Reload(); err != nil || !changed {
+		t.Fatalf("expected initial load, got changed=%v err=%v", changed, err)
+	}
+	if changed, err := r.Reload(); err != nil || changed {
+		t.Errorf("expected not modified, got changed=%v err=%v", changed, err)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+	if got := fmt.Sprint(listToolNames(t, mcpServer)); got != "[get_/users]" {
+		t.Errorf("unexpected tools %s", got)
+	}
+}
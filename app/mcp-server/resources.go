@@ -0,0 +1,409 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// resourceScheme is the URI scheme of the resources describing a spec.
+const resourceScheme = "openapi://"
+
+// httpMethods are the keys of an OpenAPI path item that are operations.
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// specResourceURI returns the URI of a resource of a spec, namespaced by the optional prefix:
+// openapi://spec, or openapi://orders/spec for the spec with prefix orders.
+func specResourceURI(prefix string, parts ...string) string {
+	escaped := make([]string, len(parts))
+	for i, part := range parts {
+		escaped[i] = url.PathEscape(part)
+	}
+	if prefix != "" {
+		return resourceScheme + url.PathEscape(prefix) + "/" + strings.Join(escaped, "/")
+	}
+	return resourceScheme + strings.Join(escaped, "/")
+}
+
+// specResource is a resource describing a spec, along with the text it reads as.
+type specResource struct {
+	server.ServerResource
+	text string
+}
+
+// BuildSwaggerResources builds the resources describing a spec without registering them: the spec document,
+// every component schema and a Markdown page for every operation that has a tool.
+func BuildSwaggerResources(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig) []server.ServerResource {
+	specResources := buildSpecResources(swaggerSpec, apiCfg)
+	resources := make([]server.ServerResource, len(specResources))
+	for i, resource := range specResources {
+		resources[i] = resource.ServerResource
+	}
+	return resources
+}
+
+// buildSpecResources builds the resources of a spec (see BuildSwaggerResources).
+func buildSpecResources(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig) []specResource {
+	raw, doc, err := specDocument(swaggerSpec)
+	if err != nil {
+		return nil
+	}
+
+	title := specTitle(swaggerSpec)
+	prefix := apiCfg.ToolPrefix
+	resources := []specResource{
+		textResource(mcp.NewResource(specResourceURI(prefix, "spec"), title+" OpenAPI spec",
+			mcp.WithResourceDescription("The OpenAPI/Swagger document the tools are generated from"),
+			mcp.WithMIMEType("application/json"),
+		), string(raw)),
+	}
+
+	schemas := specSchemas(doc)
+	for _, name := range sortedKeys(schemas) {
+		data, err := json.MarshalIndent(schemas[name], "", "  ")
+		if err != nil {
+			continue
+		}
+		resources = append(resources, textResource(mcp.NewResource(specResourceURI(prefix, "schemas", name), name,
+			mcp.WithResourceDescription(fmt.Sprintf("JSON Schema of %s in %s", name, title)),
+			mcp.WithMIMEType("application/json"),
+		), string(data)))
+	}
+
+	for _, op := range specOperations(doc, apiCfg) {
+		description := fmt.Sprintf("Documentation of %s %s", strings.ToUpper(op.method), op.path)
+		if summary, _ := op.details["summary"].(string); summary != "" {
+			description = summary
+		}
+		resources = append(resources, textResource(mcp.NewResource(specResourceURI(prefix, "operations", op.id), op.id,
+			mcp.WithResourceDescription(description),
+			mcp.WithMIMEType("text/markdown"),
+		), operationMarkdown(prefix, op.method, op.path, op.toolName, op.details)))
+	}
+	return resources
+}
+
+// specDocument returns the document a spec was loaded from, and the document decoded as generic JSON.
+func specDocument(swaggerSpec models.SwaggerSpec) ([]byte, map[string]interface{}, error) {
+	raw := []byte(swaggerSpec.Raw)
+	if len(raw) == 0 {
+		raw, _ = json.Marshal(swaggerSpec)
+	}
+	doc := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, nil, err
+	}
+	return raw, doc, nil
+}
+
+// specTitle returns the title of the API described by a spec.
+func specTitle(swaggerSpec models.SwaggerSpec) string {
+	if swaggerSpec.Info != nil && swaggerSpec.Info.Title != "" {
+		return swaggerSpec.Info.Title
+	}
+	return "API"
+}
+
+// specOperation is an operation of a spec document that has a tool.
+type specOperation struct {
+	method, path string
+	id           string // operationId, or the tool name if the operation has none
+	toolName     string
+	details      map[string]interface{}
+}
+
+// specOperations returns the operations of a spec document that pass the filters of apiCfg and are not disabled,
+// sorted by path and method.
+func specOperations(doc map[string]interface{}, apiCfg models.ApiConfig) []specOperation {
+	operations := []specOperation{}
+	filter := newOperationFilter(apiCfg)
+	paths, _ := doc["paths"].(map[string]interface{})
+	for _, path := range sortedKeys(paths) {
+		if !filter.includePath(path) {
+			continue
+		}
+		item, _ := paths[path].(map[string]interface{})
+		for _, method := range httpMethods {
+			details, ok := item[method].(map[string]interface{})
+			if !ok || !filter.includeMethod(method) {
+				continue
+			}
+			operationID, _ := details["operationId"].(string)
+			override := findOverride(apiCfg.Overrides, operationID, method, path)
+			if override.Disabled || !filter.includeOperation(method, override, decodeEndpoint(details)) {
+				continue
+			}
+			toolName := buildToolName(apiCfg.ToolPrefix, method, path)
+			if override.Name != "" {
+				toolName = buildOverrideToolName(apiCfg.ToolPrefix, override.Name)
+			}
+			id := operationID
+			if id == "" {
+				id = toolName
+			}
+			operations = append(operations, specOperation{method: method, path: path, id: id, toolName: toolName, details: details})
+		}
+	}
+	return operations
+}
+
+// decodeEndpoint decodes an operation of a spec document.
+func decodeEndpoint(details map[string]interface{}) models.Endpoint {
+	var endpoint models.Endpoint
+	data, _ := json.Marshal(details)
+	json.Unmarshal(data, &endpoint)
+	return endpoint
+}
+
+// textResource returns a resource that always reads as text.
+func textResource(resource mcp.Resource, text string) specResource {
+	return specResource{
+		ServerResource: server.ServerResource{
+			Resource: resource,
+			Handler: func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+				return []mcp.ResourceContents{mcp.TextResourceContents{URI: resource.URI, MIMEType: resource.MIMEType, Text: text}}, nil
+			},
+		},
+		text: text,
+	}
+}
+
+// specSchemas returns the component schemas (OpenAPI 3.0) or definitions (Swagger 2.0) of a spec document.
+func specSchemas(doc map[string]interface{}) map[string]interface{} {
+	if components, ok := doc["components"].(map[string]interface{}); ok {
+		if schemas, ok := components["schemas"].(map[string]interface{}); ok {
+			return schemas
+		}
+	}
+	definitions, _ := doc["definitions"].(map[string]interface{})
+	return definitions
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// operationMarkdown documents an operation: its parameters, request body and responses with their schemas and examples.
+func operationMarkdown(prefix, method, path, toolName string, op map[string]interface{}) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s %s\n\n", strings.ToUpper(method), path)
+	fmt.Fprintf(&b, "Tool: `%s`\n", toolName)
+	if operationID, _ := op["operationId"].(string); operationID != "" {
+		fmt.Fprintf(&b, "Operation ID: `%s`\n", operationID)
+	}
+	if deprecated, _ := op["deprecated"].(bool); deprecated {
+		b.WriteString("\n**Deprecated**\n")
+	}
+	for _, key := range []string{"summary", "description"} {
+		if text, _ := op[key].(string); text != "" {
+			fmt.Fprintf(&b, "\n%s\n", text)
+		}
+	}
+
+	refs := map[string]bool{}
+	var body map[string]interface{}
+	hasParams := false
+	params, _ := op["parameters"].([]interface{})
+	for _, entry := range params {
+		param, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if in, _ := param["in"].(string); in == "body" {
+			body = param
+			continue
+		}
+		if !hasParams {
+			b.WriteString("\n## Parameters\n\n| Name | In | Type | Required | Description | Example |\n| --- | --- | --- | --- | --- | --- |\n")
+			hasParams = true
+		}
+		schema, _ := param["schema"].(map[string]interface{})
+		paramType, _ := param["type"].(string)
+		if paramType == "" && schema != nil {
+			paramType = schemaType(schema)
+		}
+		required := "no"
+		if r, _ := param["required"].(bool); r {
+			required = "yes"
+		}
+		example := param["example"]
+		if example == nil && schema != nil {
+			example = schema["example"]
+		}
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		description, _ := param["description"].(string)
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n", tableCell(name), in, tableCell(paramType), required, tableCell(description), tableCell(compactJSON(example)))
+	}
+
+	if requestBody, ok := op["requestBody"].(map[string]interface{}); ok {
+		// OpenAPI 3.0
+		b.WriteString("\n## Request Body\n")
+		if description, _ := requestBody["description"].(string); description != "" {
+			fmt.Fprintf(&b, "\n%s\n", description)
+		}
+		if r, _ := requestBody["required"].(bool); r {
+			b.WriteString("\nThe request body is required.\n")
+		}
+		writeContent(&b, requestBody["content"], refs)
+	} else if body != nil {
+		// Swagger 2.0
+		b.WriteString("\n## Request Body\n")
+		if description, _ := body["description"].(string); description != "" {
+			fmt.Fprintf(&b, "\n%s\n", description)
+		}
+		writeSchema(&b, body["schema"], refs)
+	}
+
+	responses, _ := op["responses"].(map[string]interface{})
+	if len(responses) > 0 {
+		b.WriteString("\n## Responses\n")
+		for _, status := range sortedKeys(responses) {
+			resp, _ := responses[status].(map[string]interface{})
+			description, _ := resp["description"].(string)
+			fmt.Fprintf(&b, "\n### %s\n", strings.TrimSpace(status+" "+description))
+			if content, ok := resp["content"]; ok {
+				// OpenAPI 3.0
+				writeContent(&b, content, refs)
+				continue
+			}
+			// Swagger 2.0
+			writeSchema(&b, resp["schema"], refs)
+			examples, _ := resp["examples"].(map[string]interface{})
+			for _, mediaType := range sortedKeys(examples) {
+				writeExample(&b, "Example ("+mediaType+")", examples[mediaType])
+			}
+		}
+	}
+
+	if len(refs) > 0 {
+		b.WriteString("\n## Schemas\n\n")
+		names := make([]string, 0, len(refs))
+		for name := range refs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&b, "- `%s`: %s\n", name, specResourceURI(prefix, "schemas", name))
+		}
+	}
+	return b.String()
+}
+
+// writeContent documents the media types of an OpenAPI 3.0 request body or response.
+func writeContent(b *strings.Builder, content interface{}, refs map[string]bool) {
+	mediaTypes, _ := content.(map[string]interface{})
+	for _, mediaType := range sortedKeys(mediaTypes) {
+		media, _ := mediaTypes[mediaType].(map[string]interface{})
+		fmt.Fprintf(b, "\nContent type: `%s`\n", mediaType)
+		writeSchema(b, media["schema"], refs)
+		if example, ok := media["example"]; ok {
+			writeExample(b, "Example", example)
+		}
+		examples, _ := media["examples"].(map[string]interface{})
+		for _, name := range sortedKeys(examples) {
+			example, _ := examples[name].(map[string]interface{})
+			if value, ok := example["value"]; ok {
+				writeExample(b, "Example "+name, value)
+			}
+		}
+	}
+}
+
+// writeSchema writes a schema as a JSON block, followed by its example if it has one,
+// and records the component schemas it references.
+func writeSchema(b *strings.Builder, schema interface{}, refs map[string]bool) {
+	if schema == nil {
+		return
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(b, "\nSchema:\n\n```json\n%s\n```\n", data)
+	if s, ok := schema.(map[string]interface{}); ok {
+		if example, ok := s["example"]; ok {
+			writeExample(b, "Example", example)
+		}
+	}
+	collectRefs(schema, refs)
+}
+
+func writeExample(b *strings.Builder, label string, example interface{}) {
+	if s, ok := example.(string); ok {
+		fmt.Fprintf(b, "\n%s:\n\n```\n%s\n```\n", label, s)
+		return
+	}
+	data, err := json.MarshalIndent(example, "", "  ")
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(b, "\n%s:\n\n```json\n%s\n```\n", label, data)
+}
+
+// collectRefs records the names of the component schemas referenced anywhere in v.
+func collectRefs(v interface{}, refs map[string]bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			for _, base := range []string{"#/components/schemas/", "#/definitions/"} {
+				if strings.HasPrefix(ref, base) {
+					refs[strings.TrimPrefix(ref, base)] = true
+				}
+			}
+		}
+		for _, value := range v {
+			collectRefs(value, refs)
+		}
+	case []interface{}:
+		for _, value := range v {
+			collectRefs(value, refs)
+		}
+	}
+}
+
+// schemaType describes the type of a schema in a few words, e.g. array of string or a referenced schema name.
+func schemaType(schema map[string]interface{}) string {
+	if ref, ok := schema["$ref"].(string); ok {
+		return ExtractSchemaName(ref, "")
+	}
+	t, _ := schema["type"].(string)
+	if t == "array" {
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			return "array of " + schemaType(items)
+		}
+	}
+	return t
+}
+
+func compactJSON(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// tableCell keeps a value on one line of a Markdown table.
+func tableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.Join(strings.Fields(s), " ")
+}
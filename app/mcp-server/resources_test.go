@@ -0,0 +1,182 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const resourcesOpenAPISpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Users", "version": "1.0.0"},
+  "servers": [{"url": "https://api.example.com"}],
+  "paths": {
+    "/users/{id}": {
+      "get": {
+        "operationId": "getUser",
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}, "example": "u-42"}],
+        "summary": "Get a user",
+        "responses": {
+          "200": {"description": "The user", "content": {"application/json": {
+            "schema": {"$ref": "#/components/schemas/User"},
+            "example": {"id": "u-42", "name": "Ada"}
+          }}},
+          "404": {"description": "Not found"}
+        }
+      },
+      "delete": {"operationId": "deleteUser", "responses": {"204": {"description": "Deleted"}}}
+    }
+  },
+  "components": {"schemas": {
+    "User": {"type": "object", "properties": {"id": {"type": "string"}, "name": {"type": "string"}}}
+  }}
+}`
+
+func parseTestSpec(t *testing.T, data string) models.SwaggerSpec {
+	t.Helper()
+	var swaggerSpec models.SwaggerSpec
+	if err := json.Unmarshal([]byte(data), &swaggerSpec); err != nil {
+		t.Fatal(err)
+	}
+	swaggerSpec.Raw = json.RawMessage(data)
+	return swaggerSpec
+}
+
+func resourceURIs(resources []server.ServerResource) []string {
+	uris := []string{}
+	for _, resource := range resources {
+		uris = append(uris, resource.Resource.URI)
+	}
+	sort.Strings(uris)
+	return uris
+}
+
+func resourceText(t *testing.T, resource server.ServerResource) string {
+	t.Helper()
+	contents, err := resource.Handler(context.Background(), mcp.ReadResourceRequest{})
+	if err != nil || len(contents) != 1 {
+		t.Fatalf("reading %s: %v", resource.Resource.URI, err)
+	}
+	return contents[0].(mcp.TextResourceContents).Text
+}
+
+func readResource(t *testing.T, mcpServer *server.MCPServer, uri string) (string, bool) {
+	t.Helper()
+	resp := mcpServer.HandleMessage(context.Background(), json.RawMessage(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":%q}}`, uri)))
+	data, _ := json.Marshal(resp)
+	var decoded struct {
+		Result struct {
+			Contents []mcp.TextResourceContents `json:"contents"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Result.Contents) != 1 {
+		return "", false
+	}
+	return decoded.Result.Contents[0].Text, true
+}
+
+func TestBuildSwaggerResources_OpenAPI(t *testing.T) {
+	resources := BuildSwaggerResources(parseTestSpec(t, resourcesOpenAPISpec), models.ApiConfig{ExcludeMethods: "DELETE"})
+	want := "[openapi://operations/getUser openapi://schemas/User openapi://spec]"
+	if got := fmt.Sprint(resourceURIs(resources)); got != want {
+		t.Fatalf("got resources %s, want %s", got, want)
+	}
+	byURI := map[string]server.ServerResource{}
+	for _, resource := range resources {
+		byURI[resource.Resource.URI] = resource
+	}
+
+	if got := resourceText(t, byURI["openapi://spec"]); got != resourcesOpenAPISpec {
+		t.Error("expected the spec resource to serve the document as loaded")
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(resourceText(t, byURI["openapi://schemas/User"])), &schema); err != nil || schema["type"] != "object" {
+		t.Errorf("unexpected User schema %v, %v", schema, err)
+	}
+
+	doc := byURI["openapi://operations/getUser"]
+	if doc.Resource.MIMEType != "text/markdown" || doc.Resource.Description != "Get a user" {
+		t.Errorf("unexpected operation resource %+v", doc.Resource)
+	}
+	text := resourceText(t, doc)
+	for _, part := range []string{
+		"# GET /users/{id}",
+		"Tool: `get_/users/id`",
+		"| id | path | string | yes |  | \"u-42\" |",
+		"### 200 The user",
+		`"$ref": "#/components/schemas/User"`,
+		`"name": "Ada"`,
+		"### 404 Not found",
+		"- `User`: openapi://schemas/User",
+	} {
+		if !strings.Contains(text, part) {
+			t.Errorf("expected operation doc to contain %q, got:\n%s", part, text)
+		}
+	}
+}
+
+func TestBuildSwaggerResources_Swagger2WithPrefix(t *testing.T) {
+	spec := parseTestSpec(t, `{
+	  "swagger": "2.0",
+	  "host": "api.example.com",
+	  "paths": {"/orders": {"post": {
+	    "parameters": [{"name": "order", "in": "body", "schema": {"$ref": "#/definitions/Order"}}],
+	    "responses": {"201": {"description": "Created", "schema": {"$ref": "#/definitions/Order"}, "examples": {"application/json": {"id": 7}}}}
+	  }}},
+	  "definitions": {"Order": {"type": "object", "properties": {"id": {"type": "integer"}}, "example": {"id": 7}}}
+	}`)
+	resources := BuildSwaggerResources(spec, models.ApiConfig{ToolPrefix: "orders"})
+	want := "[openapi://orders/operations/orders_post_%2Forders openapi://orders/schemas/Order openapi://orders/spec]"
+	if got := fmt.Sprint(resourceURIs(resources)); got != want {
+		t.Fatalf("got resources %s, want %s", got, want)
+	}
+	for _, resource := range resources {
+		if !strings.Contains(resource.Resource.URI, "/operations/") {
+			continue
+		}
+		text := resourceText(t, resource)
+		for _, part := range []string{"## Request Body", "Example (application/json)", "- `Order`: openapi://orders/schemas/Order"} {
+			if !strings.Contains(text, part) {
+				t.Errorf("expected operation doc to contain %q, got:\n%s", part, text)
+			}
+		}
+	}
+}
+
+func TestSpecReloader_Resources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.json")
+	writeSpecFile(t, path, resourcesOpenAPISpec)
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithResourceCapabilities(false, true))
+	r := newSpecReloader(mcpServer, models.SpecConfig{SpecUrl: "file://" + path})
+	if _, err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if text, ok := readResource(t, mcpServer, "openapi://operations/getUser"); !ok || !strings.Contains(text, "Get a user") {
+		t.Fatalf("expected operation doc, got %q", text)
+	}
+
+	// Changed docs are served after a reload and resources no longer in the spec are removed
+	updated := strings.Replace(resourcesOpenAPISpec, "Get a user", "Fetch a user", 1)
+	updated = strings.Replace(updated, `"User": {`, `"Person": {`, 1)
+	writeSpecFile(t, path, updated)
+	if changed, err := r.Reload(); err != nil || !changed {
+		t.Fatalf("expected change, got changed=%v err=%v", changed, err)
+	}
+	if text, _ := readResource(t, mcpServer, "openapi://operations/getUser"); !strings.Contains(text, "Fetch a user") {
+		t.Errorf("expected updated operation doc, got %q", text)
+	}
+	if _, ok := readResource(t, mcpServer, "openapi://schemas/Person"); !ok {
+		t.Error("expected the new schema to be served")
+	}
+	if _, ok := readResource(t, mcpServer, "openapi://schemas/User"); ok {
+		t.Error("expected the removed schema to be gone")
+	}
+}
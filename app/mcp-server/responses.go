@@ -0,0 +1,119 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// ResponseValidationOff does not check API responses.
+	ResponseValidationOff = "off"
+	// ResponseValidationLog logs the responses that do not match the spec and reports them to the client as
+	// warning log notifications.
+	ResponseValidationLog = "log"
+	// ResponseValidationWarn also adds the problems to the tool result, as a warning after the response.
+	ResponseValidationWarn = "warn"
+)
+
+// responseSchemas are the responses an operation declares, which the responses of its tool calls are checked against.
+type responseSchemas struct {
+	validator schemaValidator
+	operation string                 // METHOD /path, for the logs
+	responses map[string]interface{} // responses object of the operation, by status code
+}
+
+// operationResponseSchemas returns the responses declared by an operation of a spec document.
+func operationResponseSchemas(doc map[string]interface{}, path, method string) responseSchemas {
+	paths, _ := doc["paths"].(map[string]interface{})
+	item, _ := paths[path].(map[string]interface{})
+	details, _ := item[method].(map[string]interface{})
+	responses, _ := details["responses"].(map[string]interface{})
+	return responseSchemas{
+		validator: schemaValidator{doc: doc},
+		operation: strings.ToUpper(method) + " " + path,
+		responses: responses,
+	}
+}
+
+// check validates a response against the schema the spec declares for its status code and content type, and
+// returns the problems found. Only JSON bodies are validated; an undeclared status or content type is a problem
+// in itself.
+func (r responseSchemas) check(resp apiResponse) []string {
+	declared, ok := r.declared(resp.status)
+	if !ok {
+		return []string{fmt.Sprintf("status %d is not declared in the spec", resp.status)}
+	}
+	declared = r.validator.resolve(declared)
+
+	mediaType, _, _ := mime.ParseMediaType(resp.contentType)
+	schema, hasSchema := declared["schema"].(map[string]interface{}) // Swagger 2.0
+	if content, ok := declared["content"].(map[string]interface{}); ok && len(content) > 0 {
+		major, _, _ := strings.Cut(mediaType, "/")
+		var media map[string]interface{}
+		for _, key := range []string{mediaType, major + "/*", "*/*"} {
+			if media == nil {
+				media, _ = content[key].(map[string]interface{})
+			}
+		}
+		if media == nil {
+			return []string{fmt.Sprintf("content type %q is not declared for status %d", mediaType, resp.status)}
+		}
+		schema, hasSchema = media["schema"].(map[string]interface{})
+	}
+	if !hasSchema || len(bytes.TrimSpace(resp.body)) == 0 || (mediaType != "" && !strings.HasSuffix(mediaType, "json")) {
+		return nil
+	}
+
+	var body interface{}
+	decoder := json.NewDecoder(bytes.NewReader(resp.body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&body); err != nil {
+		return []string{"body: is not valid JSON"}
+	}
+	return r.validator.validate(schema, body, "body")
+}
+
+// declared returns the response the operation declares for a status: the status as is, then as a range (2XX),
+// then the default response.
+func (r responseSchemas) declared(status int) (map[string]interface{}, bool) {
+	code := strconv.Itoa(status)
+	declared, ok := r.responses[code].(map[string]interface{})
+	for _, key := range []string{code[:1] + "XX", code[:1] + "xx", "default"} {
+		if !ok {
+			declared, ok = r.responses[key].(map[string]interface{})
+		}
+	}
+	return declared, ok
+}
+
+// checkResponse validates the response of a tool call if responses is set. The problems are logged and reported to
+// the calling client as a warning log notification, and added to the result in warn mode.
+func checkResponse(ctx context.Context, responses *responseSchemas, mode string, resp apiResponse, result *mcp.CallToolResult) {
+	if responses == nil {
+		return
+	}
+	problems := responses.check(resp)
+	if len(problems) == 0 {
+		return
+	}
+	log.Printf("Response %d of %s does not match the spec: %s", resp.status, responses.operation, strings.Join(problems, "; "))
+	if mcpServer := server.ServerFromContext(ctx); mcpServer != nil && server.ClientSessionFromContext(ctx) != nil {
+		mcpServer.SendLogMessageToClient(ctx, mcp.NewLoggingMessageNotification(mcp.LoggingLevelWarning, traceLogger, map[string]interface{}{
+			"operation": responses.operation,
+			"status":    resp.status,
+			"problems":  problems,
+		}))
+	}
+	if mode == ResponseValidationWarn {
+		result.Content = append(result.Content, mcp.NewTextContent(fmt.Sprintf("[Warning] the response does not match the API spec, it may be wrong or incomplete:\n- %s", strings.Join(problems, "\n- "))))
+	}
+}
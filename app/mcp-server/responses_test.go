@@ -0,0 +1,152 @@
+package mcpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const responsesSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Shop", "version": "1.0.0"},
+  "paths": {
+    "/orders/{id}": {
+      "get": {"operationId": "getOrder", "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {
+          "200": {"description": "Order", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Order"}}}},
+          "4XX": {"$ref": "#/components/responses/Problem"}
+        }}
+    }
+  },
+  "components": {
+    "schemas": {
+      "Order": {"type": "object", "required": ["id", "status"], "properties": {
+        "id": {"type": "string"}, "status": {"type": "string", "enum": ["open", "shipped"]}, "total": {"type": "number", "minimum": 0}}}
+    },
+    "responses": {
+      "Problem": {"description": "Problem", "content": {"application/problem+json": {"schema": {"type": "object", "required": ["title"]}}}}
+    }
+  }
+}`
+
+func TestResponseSchemas_Check(t *testing.T) {
+	_, doc, err := specDocument(parseTestSpec(t, responsesSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses := operationResponseSchemas(doc, "/orders/{id}", "get")
+
+	tests := []struct {
+		name string
+		resp apiResponse
+		want []string
+	}{
+		{"valid", apiResponse{status: 200, contentType: "application/json; charset=utf-8", body: []byte(`{"id": "7", "status": "open", "total": 12.5}`)}, nil},
+		{"drift", apiResponse{status: 200, contentType: "application/json", body: []byte(`{"id": 7, "status": "lost", "total": -1}`)}, []string{
+			"body.id: must be a string", "body.status: must be one of open, shipped", "body.total: must be at least 0"}},
+		{"missing field", apiResponse{status: 200, contentType: "application/json", body: []byte(`{"id": "7"}`)}, []string{"body.status: is required"}},
+		{"status range", apiResponse{status: 404, contentType: "application/problem+json", body: []byte(`{"detail": "no order 7"}`)}, []string{"body.title: is required"}},
+		{"undeclared status", apiResponse{status: 500, contentType: "application/json", body: []byte(`{}`)}, []string{"status 500 is not declared in the spec"}},
+		{"undeclared content type", apiResponse{status: 200, contentType: "text/html", body: []byte(`<html>`)}, []string{`content type "text/html" is not declared for status 200`}},
+		{"not JSON", apiResponse{status: 200, contentType: "application/json", body: []byte(`{"id":`)}, []string{"body: is not valid JSON"}},
+		{"empty body", apiResponse{status: 200, contentType: "application/json"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := responses.check(tt.resp)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Swagger 2.0 declares the schema on the response
+	_, doc, err = specDocument(parseTestSpec(t, validateSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc["paths"].(map[string]interface{})["/orders"].(map[string]interface{})["get"].(map[string]interface{})["responses"] = map[string]interface{}{
+		"default": map[string]interface{}{"description": "Orders", "schema": map[string]interface{}{"type": "array", "items": map[string]interface{}{"$ref": "#/definitions/Address"}}},
+	}
+	got := operationResponseSchemas(doc, "/orders", "get").check(apiResponse{status: 200, contentType: "application/json", body: []byte(`[{"city": "Lyon"}, {"zip": "69001"}]`)})
+	if strings.Join(got, "\n") != "body[1].city: is required" {
+		t.Errorf("unexpected Swagger 2.0 problems %q", got)
+	}
+}
+
+func TestCheckResponse_Modes(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "7"}`))
+	}))
+	defer api.Close()
+
+	tests := []struct {
+		mode     string
+		warnings int
+	}{
+		{"", 0},
+		{ResponseValidationOff, 0},
+		{ResponseValidationLog, 0},
+		{ResponseValidationWarn, 1},
+	}
+	for _, tt := range tests {
+		tools := BuildSwaggerTools(parseTestSpec(t, responsesSpec), models.ApiConfig{BaseUrl: api.URL, ValidateResponses: tt.mode})
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]interface{}{"id": "7"}
+		result, err := tools[0].Handler(context.Background(), request)
+		if err != nil || result.IsError {
+			t.Fatalf("%q: unexpected result %+v, %v", tt.mode, result, err)
+		}
+		if text := result.Content[0].(mcp.TextContent).Text; text != `{"id": "7"}` {
+			t.Errorf("%q: expected the response first, got %q", tt.mode, text)
+		}
+		if len(result.Content)-1 != tt.warnings {
+			t.Fatalf("%q: expected %d warnings, got %+v", tt.mode, tt.warnings, result.Content)
+		}
+		if tt.warnings > 0 {
+			if text := result.Content[1].(mcp.TextContent).Text; !strings.HasPrefix(text, "[Warning]") || !strings.Contains(text, "- body.status: is required") {
+				t.Errorf("%q: unexpected warning %q", tt.mode, text)
+			}
+		}
+	}
+}
+
+func TestCheckResponse_Async(t *testing.T) {
+	api := newAsyncAPI(t, 0, func(w http.ResponseWriter, done bool) {
+		w.Header().Set("Location", "/results/7")
+		w.WriteHeader(http.StatusSeeOther)
+	})
+	apiCfg := models.ApiConfig{
+		BaseUrl:           api.URL,
+		ValidateResponses: ResponseValidationWarn,
+		Overrides:         map[string]models.OperationOverride{"importUsers": {Async: &models.AsyncConfig{PollInterval: "10ms"}}},
+	}
+	responses := map[string]string{
+		// the final resource is checked against the response declared for its status
+		`"200": {"description": "Imported", "content": {"application/json": {"schema": {"type": "object", "properties": {"imported": {"type": "string"}}}}}}`: "body.imported: must be a string",
+		// a final resource the spec does not describe is not reported
+		`"201": {"description": "Created"}`: "",
+	}
+	for declared, want := range responses {
+		spec := parseTestSpec(t, `{"openapi": "3.0.0", "info": {"title": "Users", "version": "1"},
+		  "paths": {"/imports": {"post": {"operationId": "importUsers", "responses": {"202": {"description": "Accepted"}, `+declared+`}}}}}`)
+		tools := BuildSwaggerTools(spec, apiCfg)
+		result, err := tools[0].Handler(context.Background(), mcp.CallToolRequest{})
+		if err != nil || result.IsError || result.Content[0].(mcp.TextContent).Text != `{"imported": 42}` {
+			t.Fatalf("unexpected result %+v, %v", result, err)
+		}
+		warning := ""
+		if len(result.Content) > 1 {
+			warning = result.Content[1].(mcp.TextContent).Text
+		}
+		if (want == "") != (warning == "") || !strings.Contains(warning, want) {
+			t.Errorf("%s: expected warning %q, got %q", declared, want, warning)
+		}
+	}
+}
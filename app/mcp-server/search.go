@@ -0,0 +1,319 @@
+package mcpserver
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+)
+
+const (
+	// bm25K1 and bm25B are the usual BM25 parameters: term frequency saturation and document length normalisation.
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// synonymWeight is the weight of a query term's synonyms relative to the term itself.
+	synonymWeight = 0.5
+)
+
+// Field weights of an operation document: words of the operation id, summary and tags count more
+// than those of the description or of the schemas it uses.
+const (
+	searchWeightName        = 2.0
+	searchWeightPath        = 1.5
+	searchWeightDescription = 1.0
+	searchWeightParameter   = 1.0
+	searchWeightProperty    = 0.5
+)
+
+// searchStopWords are left out of documents and queries.
+var searchStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true, "for": true,
+	"from": true, "i": true, "in": true, "is": true, "it": true, "me": true, "my": true, "of": true, "on": true,
+	"or": true, "that": true, "the": true, "this": true, "to": true, "want": true, "we": true, "with": true,
+}
+
+// defaultSynonyms are the groups of words a query term is expanded to.
+var defaultSynonyms = [][]string{
+	{"get", "fetch", "retrieve", "read", "show", "view", "find", "lookup", "load", "detail"},
+	{"list", "search", "browse", "query", "enumerate", "all"},
+	{"create", "add", "new", "insert", "make", "register", "post"},
+	{"update", "edit", "modify", "change", "patch", "set", "put"},
+	{"delete", "remove", "destroy", "erase", "drop", "purge"},
+	{"cancel", "abort", "revoke", "stop"},
+	{"user", "account", "member", "person", "profile"},
+	{"customer", "client", "buyer"},
+	{"product", "item", "article", "sku"},
+	{"order", "purchase"},
+	{"image", "picture", "photo"},
+	{"count", "total", "number"},
+}
+
+// schemaRefName matches the component schema names referenced in a raw OpenAPI 3.0 request body or response.
+var schemaRefName = regexp.MustCompile(`#/(?:components/schemas|definitions)/([^"/]+)`)
+
+// SearchResult is an operation matching a search query.
+type SearchResult struct {
+	ID      string   // operationId, prefixed like tool names, or the tool name if the operation has none
+	Method  string   // HTTP method in upper case
+	Path    string   // Path of the operation in the spec
+	Summary string   // Summary of the operation
+	Tags    []string // Tags of the operation
+	Score   float64  // BM25 score, higher is a better match
+}
+
+// searchDocument is an operation as seen by the search index: its weighted term frequencies.
+type searchDocument struct {
+	result SearchResult
+	terms  map[string]float64
+	length float64
+}
+
+// SearchIndex ranks the operations of specs against keyword queries with BM25. Words are stemmed and
+// query words are expanded to their synonyms. It runs in process and needs no external service.
+type SearchIndex struct {
+	docs     []searchDocument
+	df       map[string]int      // term -> number of documents containing it
+	avgLen   float64             // average document length
+	synonyms map[string][]string // stemmed term -> stemmed synonyms
+}
+
+// NewSearchIndex indexes the operations of a spec that pass the filters of apiCfg: their operation ids, paths,
+// summaries, descriptions, tags, parameter names and the property names of the schemas they use.
+func NewSearchIndex(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig) *SearchIndex {
+	docs := []searchDocument{}
+	filter := newOperationFilter(apiCfg)
+	for path, methods := range swaggerSpec.Paths {
+		if !filter.includePath(path) {
+			continue
+		}
+		for method, details := range methods {
+			if !filter.includeMethod(method) {
+				continue
+			}
+			override := findOverride(apiCfg.Overrides, details.OperationID, method, path)
+			if override.Disabled || !filter.includeOperation(method, override, details) {
+				continue
+			}
+			toolName := buildToolName(apiCfg.ToolPrefix, method, path)
+			if override.Name != "" {
+				toolName = buildOverrideToolName(apiCfg.ToolPrefix, override.Name)
+			}
+			docs = append(docs, newSearchDocument(swaggerSpec, operationRef(apiCfg, details.OperationID, toolName), method, path, details))
+		}
+	}
+	return newSearchIndex(docs)
+}
+
+// operationRef returns how an operation is referred to outside its tool: its operationId prefixed like
+// tool names, or its tool name if it has no operationId.
+func operationRef(apiCfg models.ApiConfig, operationID, toolName string) string {
+	if operationID == "" {
+		return toolName
+	}
+	return buildOverrideToolName(apiCfg.ToolPrefix, operationID)
+}
+
+func newSearchIndex(docs []searchDocument) *SearchIndex {
+	x := &SearchIndex{docs: docs, df: map[string]int{}, synonyms: map[string][]string{}}
+	total := 0.0
+	for _, doc := range docs {
+		total += doc.length
+		for term := range doc.terms {
+			x.df[term]++
+		}
+	}
+	if len(docs) > 0 {
+		x.avgLen = total / float64(len(docs))
+	}
+	for _, group := range defaultSynonyms {
+		x.AddSynonyms(group...)
+	}
+	return x
+}
+
+// AddSynonyms declares words that mean the same thing in queries, in addition to the built-in groups
+// of common API verbs and nouns.
+func (x *SearchIndex) AddSynonyms(words ...string) {
+	stems := []string{}
+	for _, word := range words {
+		stems = appendUnique(stems, searchTerms(word)...)
+	}
+	for _, term := range stems {
+		for _, synonym := range stems {
+			if synonym != term {
+				x.synonyms[term] = appendUnique(x.synonyms[term], synonym)
+			}
+		}
+	}
+}
+
+// Search returns the operations matching a query, best first, at most limit of them if limit is positive.
+// Operations with equal scores are sorted by id.
+func (x *SearchIndex) Search(query string, limit int) []SearchResult {
+	weights := map[string]float64{}
+	for _, term := range searchTerms(query) {
+		weights[term] = 1
+	}
+	for term := range weights {
+		for _, synonym := range x.synonyms[term] {
+			if _, ok := weights[synonym]; !ok {
+				weights[synonym] = synonymWeight
+			}
+		}
+	}
+
+	results := []SearchResult{}
+	n := float64(len(x.docs))
+	for _, doc := range x.docs {
+		score := 0.0
+		for term, weight := range weights {
+			tf := doc.terms[term]
+			if tf == 0 {
+				continue
+			}
+			df := float64(x.df[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			score += weight * idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*doc.length/x.avgLen))
+		}
+		if score > 0 {
+			result := doc.result
+			result.Score = score
+			results = append(results, result)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// newSearchDocument collects the weighted terms of an operation.
+func newSearchDocument(swaggerSpec models.SwaggerSpec, id, method, path string, details models.Endpoint) searchDocument {
+	doc := searchDocument{
+		result: SearchResult{ID: id, Method: strings.ToUpper(method), Path: path, Summary: details.Summary, Tags: details.Tags},
+		terms:  map[string]float64{},
+	}
+	add := func(text string, weight float64) {
+		for _, term := range searchTerms(text) {
+			doc.terms[term] += weight
+			doc.length += weight
+		}
+	}
+	add(id, searchWeightName)
+	add(details.Summary, searchWeightName)
+	add(strings.Join(details.Tags, " "), searchWeightName)
+	add(method+" "+path, searchWeightPath)
+	add(details.Description, searchWeightDescription)
+
+	schemas := []string{}
+	for _, param := range details.Parameters {
+		add(param.Name, searchWeightParameter)
+		if param.Schema != nil && param.Schema.Ref != "" {
+			schemas = append(schemas, ExtractSchemaName(param.Schema.Ref, ""))
+		}
+	}
+	for _, resp := range details.Responses {
+		if resp.Schema != nil && resp.Schema.Ref != "" {
+			schemas = append(schemas, ExtractSchemaName(resp.Schema.Ref, ""))
+		}
+		for _, content := range resp.Content {
+			for _, match := range schemaRefName.FindAllStringSubmatch(string(content), -1) {
+				schemas = append(schemas, match[1])
+			}
+		}
+	}
+	for _, match := range schemaRefName.FindAllStringSubmatch(string(details.RequestBody), -1) {
+		schemas = append(schemas, match[1])
+	}
+	for _, name := range appendUnique(nil, schemas...) {
+		definition, ok := swaggerSpec.Definitions[name]
+		if !ok && swaggerSpec.Components != nil {
+			definition, ok = swaggerSpec.Components.Schemas[name]
+		}
+		if !ok {
+			continue
+		}
+		add(name, searchWeightProperty)
+		for property := range definition.Properties {
+			add(property, searchWeightProperty)
+		}
+	}
+	return doc
+}
+
+// searchTerms splits text into stemmed lower case words. camelCase and snake_case identifiers and
+// paths are split into their words.
+func searchTerms(text string) []string {
+	words := []string{}
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	runes := []rune(text)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])):
+			// orderId -> order id
+			flush()
+		case i > 0 && unicode.IsUpper(r) && unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			// HTMLPage -> html page
+			flush()
+		case i > 0 && unicode.IsDigit(r) != unicode.IsDigit(runes[i-1]):
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+
+	terms := []string{}
+	for _, w := range words {
+		if !searchStopWords[w] {
+			terms = append(terms, stem(w))
+		}
+	}
+	return terms
+}
+
+// stem reduces an English word to a stem shared by its inflections: plurals, -ed and -ing forms.
+// It is deliberately light, so stems are not always words (update and updated both become updat).
+func stem(word string) string {
+	if len(word) <= 3 {
+		return word
+	}
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		word = word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "ing") && len(word) > 5:
+		word = word[:len(word)-3]
+	case strings.HasSuffix(word, "ed") && len(word) > 4:
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		word = word[:len(word)-1]
+	}
+	// shipped -> ship, running -> run
+	if n := len(word); n > 3 && word[n-1] == word[n-2] && !strings.ContainsRune("aeiouslz", rune(word[n-1])) {
+		word = word[:n-1]
+	}
+	// create, creates and created -> creat
+	if n := len(word); n > 3 && word[n-1] == 'e' {
+		word = word[:n-1]
+	}
+	return word
+}
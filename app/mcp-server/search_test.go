@@ -0,0 +1,108 @@
+package mcpserver
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+)
+
+const searchSpec = `{
+  "swagger": "2.0",
+  "paths": {
+    "/users": {
+      "get": {"operationId": "listUsers", "summary": "List users", "tags": ["users"], "responses": {"200": {"description": "Users"}}},
+      "post": {"operationId": "createUser", "summary": "Create a user", "tags": ["users"],
+        "parameters": [{"name": "body", "in": "body", "schema": {"$ref": "#/definitions/NewUser"}}],
+        "responses": {"201": {"description": "Created"}}}
+    },
+    "/users/{userId}": {
+      "get": {"operationId": "getUserById", "summary": "Get a user", "tags": ["users"],
+        "parameters": [{"name": "userId", "in": "path", "required": true}], "responses": {"200": {"description": "User"}}},
+      "delete": {"operationId": "deleteUser", "summary": "Delete a user", "tags": ["users"], "responses": {"204": {"description": "Deleted"}}}
+    },
+    "/shipments": {
+      "get": {"operationId": "listShipments", "summary": "List shipments", "description": "Shipped parcels with their tracking numbers",
+        "tags": ["logistics"], "responses": {"200": {"description": "Shipments"}}}
+    },
+    "/invoices/{invoiceId}/pdf": {
+      "get": {"operationId": "downloadInvoicePDF", "summary": "Download an invoice", "tags": ["billing"], "responses": {"200": {"description": "PDF"}}}
+    }
+  },
+  "definitions": {"NewUser": {"type": "object", "properties": {"email": {"type": "string"}, "displayName": {"type": "string"}}}}
+}`
+
+func searchIDs(results []SearchResult) string {
+	ids := []string{}
+	for _, result := range results {
+		ids = append(ids, result.ID)
+	}
+	return fmt.Sprint(ids)
+}
+
+func TestSearchIndex(t *testing.T) {
+	index := NewSearchIndex(parseTestSpec(t, searchSpec), models.ApiConfig{ExcludeMethods: "DELETE"})
+	// Only the best matches are checked, weaker ones follow them
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"exact words", "list users", "[listUsers listShipments]"},
+		{"stemming", "shipped parcel", "[listShipments]"},
+		{"synonyms", "register a new account", "[createUser]"},
+		{"synonym of a verb", "fetch user", "[getUserById listUsers]"},
+		{"parameter name", "user id", "[getUserById]"},
+		{"schema property", "display name", "[createUser]"},
+		{"camel case identifier", "pdf", "[downloadInvoicePDF]"},
+		{"no match", "weather forecast", "[]"},
+		{"stop words only", "the of", "[]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := index.Search(tt.query, 0)
+			if got := searchIDs(results[:min(len(results), len(strings.Fields(tt.want)))]); got != tt.want {
+				t.Errorf("Search(%q) = %s, want %s first", tt.query, searchIDs(results), tt.want)
+			}
+		})
+	}
+	if got := searchIDs(index.Search("delete user", 0)); strings.Contains(got, "deleteUser") {
+		t.Errorf("expected excluded operations not to be indexed, got %s", got)
+	}
+
+	if got := searchIDs(index.Search("user", 2)); got != "[listUsers getUserById]" {
+		t.Errorf("expected the two best matches, got %s", got)
+	}
+	results := index.Search("invoice", 0)
+	if len(results) != 1 || results[0].Method != "GET" || results[0].Path != "/invoices/{invoiceId}/pdf" || results[0].Score <= 0 {
+		t.Errorf("unexpected result %+v", results)
+	}
+
+	index.AddSynonyms("invoice", "bill", "receipt")
+	if got := searchIDs(index.Search("receipts", 0)); got != "[downloadInvoicePDF]" {
+		t.Errorf("expected the added synonyms to be used, got %s", got)
+	}
+}
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"getUserById", "[get user id]"},
+		{"/v2/orders/{orderId}/line_items", "[v 2 order order id lin item]"},
+		{"HTMLPage", "[html pag]"},
+		{"Updated the categories and addresses", "[updat category address]"},
+		{"running shipped creates", "[run ship creat]"},
+		{"status", "[status]"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(searchTerms(tt.text)); got != tt.want {
+			t.Errorf("searchTerms(%q) = %s, want %s", tt.text, got, tt.want)
+		}
+	}
+	if strings.Join(searchTerms("update"), "") != strings.Join(searchTerms("updating"), "") {
+		t.Error("expected inflections to share a stem")
+	}
+}
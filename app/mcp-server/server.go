@@ -1,5 +1,5 @@
 // Package mcpserver provides functions to dynamically generate MCP tools and HTTP handlers
-// from OpenAPI/Swagger specifications, supporting both SSE and stdio server modes.
+// from OpenAPI/Swagger specifications, supporting stdio, SSE and Streamable HTTP transports.
 // It includes utilities for path/method filtering, security handling, and request/response mapping.
 package mcpserver
 
@@ -13,18 +13,21 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
 	"regexp"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/danishjsheikh/swagger-mcp/app/auth"
 	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/danishjsheikh/swagger-mcp/app/secrets"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
-// sseHeadersKey is the context key for passing SSE headers.
-const sseHeadersKey = "__sseHeadersKey"
-
 // ExtractSchemaName returns the schema name from a JSON reference string or falls back to the schema type.
 func ExtractSchemaName(ref, schemaType string) string {
 	if ref != "" {
@@ -93,101 +96,259 @@ func shouldIncludeMethod(method string, includeMethods, excludeMethods []string)
 	return true
 }
 
+// operationFilter selects the operations of a spec that get a tool, according to the path, method, tag, operationId,
+// extension and deprecation filters of the API config.
+type operationFilter struct {
+	includePaths, excludePaths           []*regexp.Regexp
+	includeMethods, excludeMethods       []string
+	includeTags, excludeTags             []string
+	includeOperations, excludeOperations []string // operationIds or glob patterns
+	includeExtensions, excludeExtensions []string // x- extensions as name or name=value
+	excludeDeprecated                    bool
+	readOnly                             bool // only safe operations are included
+}
+
+func newOperationFilter(apiCfg models.ApiConfig) operationFilter {
+	filter := operationFilter{
+		includePaths:      compileRegexes(apiCfg.IncludePaths),
+		excludePaths:      compileRegexes(apiCfg.ExcludePaths),
+		includeTags:       splitFilterList(apiCfg.IncludeTags),
+		excludeTags:       splitFilterList(apiCfg.ExcludeTags),
+		includeOperations: splitFilterList(apiCfg.IncludeOperations),
+		excludeOperations: splitFilterList(apiCfg.ExcludeOperations),
+		includeExtensions: splitFilterList(apiCfg.IncludeExtensions),
+		excludeExtensions: splitFilterList(apiCfg.ExcludeExtensions),
+		excludeDeprecated: apiCfg.ExcludeDeprecated,
+		readOnly:          apiCfg.ReadOnly,
+	}
+	if len(strings.TrimSpace(apiCfg.IncludeMethods)) > 0 {
+		filter.includeMethods = strings.Split(apiCfg.IncludeMethods, ",")
+	}
+	if len(strings.TrimSpace(apiCfg.ExcludeMethods)) > 0 {
+		filter.excludeMethods = strings.Split(apiCfg.ExcludeMethods, ",")
+	}
+	return filter
+}
+
+func (f operationFilter) includePath(path string) bool {
+	return shouldIncludePath(path, f.includePaths, f.excludePaths)
+}
+
+func (f operationFilter) includeMethod(method string) bool {
+	return shouldIncludeMethod(method, f.includeMethods, f.excludeMethods)
+}
+
 // CreateServer creates and starts an MCP server from a Swagger/OpenAPI spec and config.
-// It supports both SSE and stdio server modes.
+// It supports stdio, SSE and Streamable HTTP transports.
 func CreateServer(swaggerSpec models.SwaggerSpec, config models.Config) {
 	apiVersion := "1.0.0"
 	if swaggerSpec.Info != nil && swaggerSpec.Info.Version != "" {
 		apiVersion = swaggerSpec.Info.Version
 	}
+	sessions := newSessionStore(config)
+	life := newLifecycle()
+	completions := newCompletionSet()
+	mcpServer := newMCPServer(apiVersion, sessions, life, completions)
+	r := newSpecReloader(mcpServer, models.SpecConfig{SpecUrl: config.SpecUrl, ApiCfg: config.ApiCfg})
+	r.completions = completions
+	r.catalog = newToolCatalog(mcpServer, config.ToolMode)
+	r.toolsets = newToolsetSet(mcpServer, sessions, config.ToolMode, config.CoreToolsets)
+	if _, err := r.apply(swaggerSpec); err != nil {
+		log.Fatalf("Error registering tools: %v", err)
+	}
+	watchSpecs(context.Background(), []*specReloader{r}, config.ReloadCfg)
+	serve(mcpServer, config, sessions, life, []*specReloader{r})
+}
+
+// newMCPServer creates the MCP server with the capabilities shared by all server modes.
+func newMCPServer(apiVersion string, sessions *sessionStore, life *lifecycle, completions *completionSet) *server.MCPServer {
+	hooks := sessions.hooks()
+	life.addHooks(hooks)
 	mcpServer := server.NewMCPServer(
 		"swagger-mcp",
 		apiVersion,
+		server.WithToolCapabilities(true),
+		server.WithResourceCapabilities(false, true),
+		server.WithPromptCapabilities(true),
+		server.WithCompletions(),
+		server.WithLogging(),
+		server.WithElicitation(),
+		server.WithPromptCompletionProvider(completions),
+		server.WithResourceCompletionProvider(completions),
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(life.toolMiddleware),
 	)
-	LoadSwaggerServer(mcpServer, swaggerSpec, config.ApiCfg)
+	mcpServer.AddNotificationHandler(cancelledNotification, life.cancelCall)
+	return mcpServer
+}
+
+// httpEndpointPath is where the Streamable HTTP transport is served.
+const httpEndpointPath = "/mcp"
+
+// httpSessionIdleTTL is how long an idle Streamable HTTP session is kept before it is dropped.
+// Unlike SSE there is no connection whose end marks the end of the session.
+const httpSessionIdleTTL = 30 * time.Minute
+
+// serve runs the MCP server over the transports selected in the config: SSE and Streamable HTTP
+// share one listener, otherwise the server runs on stdio. It returns after a graceful shutdown
+// on SIGINT or SIGTERM, or when stdin is closed in stdio mode.
+func serve(mcpServer *server.MCPServer, config models.Config, sessions *sessionStore, life *lifecycle, reloaders []*specReloader) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	timeout := config.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+
+	if !config.SseCfg.SseMode && !config.SseCfg.HttpMode {
+		// Run as stdio server
+		if err := life.serveStdio(ctx, mcpServer, os.Stdin, os.Stdout, timeout); err != nil {
+			log.Fatalf("Server error: %v", err)
+		}
+		return
+	}
+
+	authenticator, err := auth.New(config.AuthCfg)
+	if err != nil {
+		log.Fatalf("Error configuring authentication: %v", err)
+	}
+	tlsConfig, err := newTLSConfig(config.TLSCfg)
+	if err != nil {
+		log.Fatalf("Error configuring TLS: %v", err)
+	}
+	// Probes are served without authentication
+	probes := newHealth(life, reloaders)
+	root := http.NewServeMux()
+	root.HandleFunc("/healthz", probes.healthz)
+	root.HandleFunc("/readyz", probes.readyz)
+	mux := http.NewServeMux()
+	root.Handle("/", life.streamMiddleware(authenticator.Middleware(sessions.ownerMiddleware(sessions.connectMiddleware(mux)))))
+	httpServer := &http.Server{
+		Addr:      config.SseCfg.SseAddr,
+		Handler:   root,
+		TLSConfig: tlsConfig,
+	}
 	if config.SseCfg.SseMode {
-		// Create and start SSE server
-		sseServer := server.NewSSEServer(mcpServer, server.WithBaseURL(config.SseCfg.SseUrl), server.WithSSEContextFunc(func(ctx context.Context, r *http.Request) context.Context {
-			if len(config.ApiCfg.SseHeaders) == 0 {
-				return ctx
-			}
-			keys := strings.Split(config.ApiCfg.SseHeaders, ",")
-			sseHeaders := map[string]string{}
-			for _, key := range keys {
-				sseHeaders[key] = r.Header.Get(key)
-			}
-			return context.WithValue(ctx, sseHeadersKey, sseHeaders)
-		}))
+		sseServer := server.NewSSEServer(mcpServer,
+			server.WithBaseURL(config.SseCfg.SseUrl),
+			server.WithHTTPServer(httpServer),
+			server.WithSSEContextFunc(sessions.contextFunc),
+		)
 		endpoint, err := sseServer.CompleteSseEndpoint()
 		if err != nil {
 			log.Fatalf("Error creating SSE endpoint: %v", err)
 		}
-		log.Printf("Starting SSE server on %s, endpoint: %s", config.SseCfg.SseAddr, endpoint)
-		if err := sseServer.Start(config.SseCfg.SseAddr); err != nil {
-			log.Fatalf("Server error: %v", err)
+		mux.Handle("/", sseServer)
+		log.Printf("Serving SSE on %s, endpoint: %s", config.SseCfg.SseAddr, endpoint)
+	}
+	if config.SseCfg.HttpMode {
+		mux.Handle(httpEndpointPath, newStreamableServer(mcpServer, httpServer, sessions))
+		log.Printf("Serving Streamable HTTP on %s, endpoint: %s", config.SseCfg.SseAddr, strings.TrimSuffix(config.SseCfg.SseUrl, "/")+httpEndpointPath)
+	}
+	errs := make(chan error, 1)
+	go func() {
+		if tlsConfig != nil {
+			errs <- httpServer.ListenAndServeTLS("", "")
+		} else {
+			errs <- httpServer.ListenAndServe()
+		}
+	}()
+	select {
+	case err := <-errs:
+		log.Fatalf("Server error: %v", err)
+	case <-ctx.Done():
+		life.shutdownHTTP(httpServer, timeout)
+	}
+}
+
+// newStreamableServer creates the Streamable HTTP transport. Sessions are stateful so that the
+// credentials and headers of a client are tied to the session id it was given on initialize.
+func newStreamableServer(mcpServer *server.MCPServer, httpServer *http.Server, sessions *sessionStore) *server.StreamableHTTPServer {
+	return server.NewStreamableHTTPServer(mcpServer,
+		server.WithEndpointPath(httpEndpointPath),
+		server.WithStreamableHTTPServer(httpServer),
+		server.WithStateful(true),
+		server.WithSessionIdleTTL(httpSessionIdleTTL),
+		server.WithHTTPContextFunc(sessions.httpContextFunc),
+	)
+}
+
+// LoadSwaggerServer registers tools and handlers on the MCP server for each path/method in the Swagger spec,
+// resources describing the spec, prompts and the selected resource templates. It applies path/method filtering and builds tool options and handlers for each endpoint.
+func LoadSwaggerServer(mcpServer *server.MCPServer, swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig) {
+	if tools := BuildSwaggerTools(swaggerSpec, apiCfg); len(tools) > 0 {
+		mcpServer.AddTools(tools...)
+	}
+	if resources := BuildSwaggerResources(swaggerSpec, apiCfg); len(resources) > 0 {
+		mcpServer.AddResources(resources...)
+	}
+	if templates := BuildSwaggerResourceTemplates(swaggerSpec, apiCfg); len(templates) > 0 {
+		mcpServer.AddResourceTemplates(templates...)
+	}
+	if prompts := BuildSwaggerPrompts(swaggerSpec, apiCfg); len(prompts) > 0 {
+		mcpServer.AddPrompts(prompts...)
+	}
+}
+
+// specBaseURL returns the URL API requests of a spec are made against: the configured base URL,
+// or the first server (OpenAPI 3.0) or host and basePath (Swagger 2.0) of the spec.
+func specBaseURL(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig) string {
+	if apiCfg.BaseUrl != "" {
+		return apiCfg.BaseUrl
+	}
+	var baseURL string
+	// Determine base URL based on version
+	if swaggerSpec.OpenAPI != "" {
+		// OpenAPI 3.0
+		if len(swaggerSpec.Servers) > 0 {
+			baseURL = strings.TrimSuffix(swaggerSpec.Servers[0].URL, "/")
+		} else {
+			baseURL = "/" // Default to relative path if no servers defined
 		}
 	} else {
-		// Run as stdio server
-		if err := server.ServeStdio(mcpServer); err != nil {
-			log.Fatalf("Server error: %v", err)
+		// Swagger 2.0
+		baseURL = swaggerSpec.Host
+		if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+			baseURL = "https://" + baseURL
+		}
+		if swaggerSpec.BasePath != "" {
+			baseURL = strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(swaggerSpec.BasePath, "/")
 		}
 	}
+	return baseURL
 }
 
-// LoadSwaggerServer registers tools and handlers on the MCP server for each path/method in the Swagger spec.
-// It applies path/method filtering and builds tool options and handlers for each endpoint.
-func LoadSwaggerServer(mcpServer *server.MCPServer, swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig) {
-	includeRegexes := compileRegexes(apiCfg.IncludePaths)
-	excludeRegexes := compileRegexes(apiCfg.ExcludePaths)
-	includedMethods := []string{}
-	if len(strings.TrimSpace(apiCfg.IncludeMethods)) > 0 {
-		includedMethods = strings.Split(apiCfg.IncludeMethods, ",")
+// BuildSwaggerTools builds the tools and handlers for each path/method in the Swagger spec without registering them.
+func BuildSwaggerTools(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig) []server.ServerTool {
+	tools := []server.ServerTool{}
+	filter := newOperationFilter(apiCfg)
+	confirmations := newConfirmationStore()
+	_, doc, err := specDocument(swaggerSpec)
+	if err != nil {
+		log.Printf("Not validating the arguments and responses of the tools of %s: %v", specTitle(swaggerSpec), err)
 	}
-	excludedMethods := []string{}
-	if len(strings.TrimSpace(apiCfg.ExcludeMethods)) > 0 {
-		excludedMethods = strings.Split(apiCfg.ExcludeMethods, ",")
+	if apiCfg.SessionApiKey.Name == "" {
+		apiCfg.SessionApiKey = specApiKeyLocation(swaggerSpec)
 	}
 
 	for path, methods := range swaggerSpec.Paths {
 
-		if !shouldIncludePath(path, includeRegexes, excludeRegexes) {
+		if !filter.includePath(path) {
 			continue
 		}
 
 		for method, details := range methods {
-			if !shouldIncludeMethod(method, includedMethods, excludedMethods) {
+			if !filter.includeMethod(method) {
+				continue
+			}
+			override := findOverride(apiCfg.Overrides, details.OperationID, method, path)
+			if override.Disabled || !filter.includeOperation(method, override, details) {
 				continue
 			}
 			expectedResponse := []string{}
 			toolOption := []mcp.ToolOption{}
 
-			var reqURL string
-			var baseURL string
-
-			if apiCfg.BaseUrl == "" {
-				// Determine base URL based on version
-				if swaggerSpec.OpenAPI != "" {
-					// OpenAPI 3.0
-					if len(swaggerSpec.Servers) > 0 {
-						baseURL = strings.TrimSuffix(swaggerSpec.Servers[0].URL, "/")
-					} else {
-						baseURL = "/" // Default to relative path if no servers defined
-					}
-				} else {
-					// Swagger 2.0
-					baseURL = swaggerSpec.Host
-					if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
-						baseURL = "https://" + baseURL
-					}
-					if swaggerSpec.BasePath != "" {
-						baseURL = strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(swaggerSpec.BasePath, "/")
-					}
-				}
-			} else {
-				baseURL = apiCfg.BaseUrl
-			}
-
-			reqURL = strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+			reqURL := strings.TrimSuffix(specBaseURL(swaggerSpec, apiCfg), "/") + "/" + strings.TrimPrefix(path, "/")
 
 			reqMethod := fmt.Sprint(method)
 			reqBody := make(map[string]string)
@@ -197,58 +358,25 @@ func LoadSwaggerServer(mcpServer *server.MCPServer, swaggerSpec models.SwaggerSp
 
 			for _, param := range details.Parameters {
 				if param.In == "header" {
-					if param.Required {
-						toolOption = append(toolOption, mcp.WithString(
-							fmt.Sprint(param.Name),
-							mcp.Description(fmt.Sprintf("The data for %s", param.Name)),
-							mcp.Required(),
-						))
-					} else {
-						toolOption = append(toolOption, mcp.WithString(
-							fmt.Sprint(param.Name),
-							mcp.Description(fmt.Sprintf("The data for %s", param.Name)),
-						))
-					}
+					toolOption = append(toolOption, paramOption(param))
 					reqHeader = append(reqHeader, param.Name)
 				}
 			}
 			for _, param := range details.Parameters {
 				if param.In == "query" {
-					if param.Required {
-						toolOption = append(toolOption, mcp.WithString(
-							fmt.Sprint(param.Name),
-							mcp.Description(fmt.Sprintf("The data for %s", param.Name)),
-							mcp.Required(),
-						))
-					} else {
-						toolOption = append(toolOption, mcp.WithString(
-							fmt.Sprint(param.Name),
-							mcp.Description(fmt.Sprintf("The data for %s", param.Name)),
-						))
-					}
+					toolOption = append(toolOption, paramOption(param))
 					reqQueryParam = append(reqQueryParam, param.Name)
 				}
 			}
 
 			for _, param := range details.Parameters {
 				if param.In == "path" {
-					if param.Required {
-						toolOption = append(toolOption, mcp.WithString(
-							fmt.Sprint(param.Name),
-							mcp.Description(fmt.Sprintf("The data for %s", param.Name)),
-							mcp.Required(),
-						))
-					} else {
-						toolOption = append(toolOption, mcp.WithString(
-							fmt.Sprint(param.Name),
-							mcp.Description(fmt.Sprintf("The data for %s", param.Name)),
-						))
-					}
+					toolOption = append(toolOption, paramOption(param))
 					reqPathParam = append(reqPathParam, param.Name)
 				}
 			}
 			for _, param := range details.Parameters {
-				if param.In == "body" {
+				if param.In == "body" && param.Schema != nil {
 					schemaName := ExtractSchemaName(param.Schema.Ref, param.Type)
 					if definition, found := swaggerSpec.Definitions[schemaName]; found {
 						for propName, prop := range definition.Properties {
@@ -274,19 +402,184 @@ func LoadSwaggerServer(mcpServer *server.MCPServer, swaggerSpec models.SwaggerSp
 				}
 			}
 
-			toolOption = append(toolOption, mcp.WithDescription(fmt.Sprintf(`Use this tool only when the request exactly matches %s or %s. If you dont have any of the required parameters then always ask user for it, *Dont fill any paramter on your own or keep it empty*. If there is [Error], only state that error in your reponse and stop the reponse there itself. *Do not ever maintain records in your memory for eg list of users or orders*`,
-				details.Summary, details.Description)))
+			if override.Description != "" {
+				toolOption = append(toolOption, mcp.WithDescription(override.Description))
+			} else {
+				toolOption = append(toolOption, mcp.WithDescription(fmt.Sprintf(`Use this tool only when the request exactly matches %s or %s. If you dont have any of the required parameters then always ask user for it, *Dont fill any paramter on your own or keep it empty*. If there is [Error], only state that error in your reponse and stop the reponse there itself. *Do not ever maintain records in your memory for eg list of users or orders*`,
+					details.Summary, details.Description)))
+			}
+
+			annotations, err := operationAnnotations(method, override, details)
+			if err != nil {
+				log.Printf("Ignoring an annotation of %s %s: %v", strings.ToUpper(method), path, err)
+			}
+			toolOption = append(toolOption, mcp.WithToolAnnotation(annotations))
+
+			toolName := buildToolName(apiCfg.ToolPrefix, method, path)
+			if override.Name != "" {
+				toolName = buildOverrideToolName(apiCfg.ToolPrefix, override.Name)
+			}
+
+			opApiCfg := apiCfg
+			if len(override.Headers) > 0 {
+				opApiCfg.HeaderValues = mergeHeaders(apiCfg.HeaderValues, override.Headers)
+			}
+			opApiCfg.SafeOperation = safeOperation(method, override, details)
+
+			var responses *responseSchemas
+			if doc != nil && apiCfg.ValidateResponses != "" && apiCfg.ValidateResponses != ResponseValidationOff {
+				schemas := operationResponseSchemas(doc, path, method)
+				responses = &schemas
+			}
+			handler := createToolHandler(reqPathParam, reqQueryParam, reqURL, reqBody, reqMethod, reqHeader, opApiCfg, responses)
+			if asyncCfg, err := operationAsync(override, details); err != nil {
+				log.Printf("Not following %s %s as a long-running operation: %v", strings.ToUpper(method), path, err)
+			} else if asyncCfg != nil {
+				async, err := newAsyncOperation(*asyncCfg, specBaseURL(swaggerSpec, apiCfg))
+				if err != nil {
+					log.Printf("Not following %s %s as a long-running operation: %v", strings.ToUpper(method), path, err)
+				} else {
+					handler = createAsyncToolHandler(async, reqPathParam, reqQueryParam, reqURL, reqBody, reqMethod, reqHeader, opApiCfg, responses)
+				}
+			}
+
+			preview := func(ctx context.Context, args map[string]interface{}) (requestPreview, error) {
+				return previewAPIRequest(ctx, args, reqPathParam, reqQueryParam, reqURL, reqBody, reqMethod, reqHeader, opApiCfg)
+			}
+			if operationConfirm(apiCfg, override, details, annotations) {
+				toolOption = append(toolOption, mcp.WithString(confirmTokenArgument,
+					mcp.Description("Token returned when the request needs the user's confirmation, only to be sent once the user approved the request")))
+				handler = confirmToolHandler(toolName, confirmations, preview, handler)
+			}
+			// a dry run is never sent, so it needs no confirmation
+			toolOption = append(toolOption, mcp.WithBoolean(dryRunArgument,
+				mcp.Description("Set to true to get the request as a curl command and JSON instead of sending it")))
+			handler = dryRunToolHandler(apiCfg.DryRun, preview, handler)
+			if doc != nil {
+				handler = validateToolHandler(operationArgumentSchemas(doc, path, method), handler)
+			}
+
+			tools = append(tools, server.ServerTool{
+				Tool:    mcp.NewTool(toolName, toolOption...),
+				Handler: handler,
+			})
+		}
+	}
+	return tools
+}
+
+// paramOption declares a header, query or path parameter as a string argument of a tool,
+// limited to the values of its enum if the spec declares one.
+func paramOption(param models.Parameter) mcp.ToolOption {
+	opts := []mcp.PropertyOption{mcp.Description(fmt.Sprintf("The data for %s", param.Name))}
+	if param.Required {
+		opts = append(opts, mcp.Required())
+	}
+	if values := paramEnum(param); len(values) > 0 {
+		opts = append(opts, mcp.Enum(values...))
+	}
+	return mcp.WithString(param.Name, opts...)
+}
+
+// paramEnum returns the values of a parameter's enum as strings, from the parameter (Swagger 2.0) or its schema.
+func paramEnum(param models.Parameter) []string {
+	enum := param.Enum
+	if len(enum) == 0 && param.Schema != nil {
+		enum = param.Schema.Enum
+	}
+	values := make([]string, 0, len(enum))
+	for _, value := range enum {
+		if value != nil {
+			values = append(values, scalarString(value))
+		}
+	}
+	return values
+}
+
+// scalarString formats a decoded JSON value as a parameter value, numbers without an exponent.
+func scalarString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// buildToolName returns the MCP tool name for a path/method, namespaced by the optional prefix.
+func buildToolName(prefix, method, path string) string {
+	name := fmt.Sprintf("%s_%s", method, strings.ReplaceAll(strings.ReplaceAll(path, "}", ""), "{", ""))
+	if prefix != "" {
+		name = prefix + "_" + name
+	}
+	return name
+}
 
-			toolName := fmt.Sprintf("%s_%s", method, strings.ReplaceAll(strings.ReplaceAll(path, "}", ""), "{", ""))
+// buildOverrideToolName returns a configured tool name, namespaced by the optional prefix.
+func buildOverrideToolName(prefix, name string) string {
+	if prefix != "" {
+		return prefix + "_" + name
+	}
+	return name
+}
 
-			mcpServer.AddTool(
-				mcp.NewTool(toolName, toolOption...),
-				CreateMCPToolHandler(
-					reqPathParam, reqQueryParam, reqURL, reqBody, reqMethod, reqHeader, apiCfg,
-				),
-			)
+// findOverride returns the override configured for an operation, looked up by operationId first and then by "METHOD /path".
+func findOverride(overrides map[string]models.OperationOverride, operationID, method, path string) models.OperationOverride {
+	if operationID != "" {
+		if override, ok := overrides[operationID]; ok {
+			return override
 		}
 	}
+	return overrides[strings.ToUpper(method)+" "+path]
+}
+
+// mergeHeaders returns a new header map with the values of extra taking precedence over base.
+func mergeHeaders(base, extra map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(extra))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+// resolveSecrets returns a copy of apiCfg with every credential reference (env:, file:, exec:) resolved.
+// Errors name the field and reference but never the secret value.
+func resolveSecrets(apiCfg models.ApiConfig) (models.ApiConfig, error) {
+	var err error
+	if apiCfg.BasicAuth, err = secrets.ResolveBasicAuth(apiCfg.BasicAuth); err != nil {
+		return apiCfg, fmt.Errorf("basicAuth: %v", err)
+	}
+	if apiCfg.BearerAuth, err = secrets.Resolve(apiCfg.BearerAuth); err != nil {
+		return apiCfg, fmt.Errorf("bearerAuth: %v", err)
+	}
+	if apiCfg.ApiKeyAuth, err = secrets.ResolveApiKeyAuth(apiCfg.ApiKeyAuth); err != nil {
+		return apiCfg, fmt.Errorf("apiKeyAuth: %v", err)
+	}
+	if len(apiCfg.ApiKeys) > 0 {
+		keys := make([]models.ApiKey, len(apiCfg.ApiKeys))
+		for i, key := range apiCfg.ApiKeys {
+			if key.Value, err = secrets.Resolve(key.Value); err != nil {
+				return apiCfg, fmt.Errorf("apiKeys %s: %v", key.Name, err)
+			}
+			keys[i] = key
+		}
+		apiCfg.ApiKeys = keys
+	}
+	if len(apiCfg.HeaderValues) > 0 {
+		headers := make(map[string]string, len(apiCfg.HeaderValues))
+		for name, value := range apiCfg.HeaderValues {
+			if headers[name], err = secrets.Resolve(value); err != nil {
+				return apiCfg, fmt.Errorf("header %s: %v", name, err)
+			}
+		}
+		apiCfg.HeaderValues = headers
+	}
+	return apiCfg, nil
 }
 
 // setRequestSecurity sets authentication headers, query params, or cookies on the request
@@ -323,31 +616,36 @@ func setRequestSecurity(req *http.Request, security string, basicAuth string, ap
 				if colonIdx == -1 || eqIdx == -1 || eqIdx < colonIdx+2 {
 					continue
 				}
-				passAs := strings.ToLower(strings.TrimSpace(part[:colonIdx]))
+				passAs := strings.TrimSpace(part[:colonIdx])
 				name := strings.TrimSpace(part[colonIdx+1 : eqIdx])
 				value := strings.TrimSpace(part[eqIdx+1:])
-				switch passAs {
-				case "header":
-					req.Header.Set(name, value)
-				case "query":
-					// Update the query param in-place
-					q := req.URL.Query()
-					q.Set(name, value)
-					req.URL.RawQuery = q.Encode()
-				case "cookie":
-					// Set the cookie header directly for test visibility
-					existing := req.Header.Get("Cookie")
-					if existing != "" {
-						req.Header.Set("Cookie", existing+"; "+name+"="+value)
-					} else {
-						req.Header.Set("Cookie", name+"="+value)
-					}
-				}
+				setApiKey(req, passAs, name, value)
 			}
 		}
 	}
 }
 
+// setApiKey passes an API key as a header, query parameter or cookie.
+func setApiKey(req *http.Request, passAs, name, value string) {
+	switch strings.ToLower(passAs) {
+	case "header":
+		req.Header.Set(name, value)
+	case "query":
+		// Update the query param in-place
+		q := req.URL.Query()
+		q.Set(name, value)
+		req.URL.RawQuery = q.Encode()
+	case "cookie":
+		// Set the cookie header directly for test visibility
+		existing := req.Header.Get("Cookie")
+		if existing != "" {
+			req.Header.Set("Cookie", existing+"; "+name+"="+value)
+		} else {
+			req.Header.Set("Cookie", name+"="+value)
+		}
+	}
+}
+
 // CreateMCPToolHandler returns a ToolHandlerFunc that builds and sends HTTP requests for a given endpoint.
 // It handles path, query, header, and body parameters, as well as security and custom headers.
 func CreateMCPToolHandler(
@@ -358,128 +656,209 @@ func CreateMCPToolHandler(
 	reqMethod string,
 	reqHeader []string,
 	apiCfg models.ApiConfig,
+) server.ToolHandlerFunc {
+	return createToolHandler(reqPathParam, reqQueryParam, reqURL, reqBody, reqMethod, reqHeader, apiCfg, nil)
+}
+
+// createToolHandler is CreateMCPToolHandler, also checking the responses against the spec if responses is set.
+func createToolHandler(
+	reqPathParam []string,
+	reqQueryParam []string,
+	reqURL string,
+	reqBody map[string]string,
+	reqMethod string,
+	reqHeader []string,
+	apiCfg models.ApiConfig,
+	responses *responseSchemas,
 ) server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		currentReqURL := reqURL
-		for _, paramName := range reqPathParam {
-			param, ok := request.Params.Arguments[paramName].(string)
+		resp, err := callAPI(ctx, request.GetArguments(), reqPathParam, reqQueryParam, reqURL, reqBody, reqMethod, reqHeader, apiCfg)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("[Error] %v", err)), nil
+		}
+		result := mcp.NewToolResultText(string(resp.body))
+		checkResponse(ctx, responses, apiCfg.ValidateResponses, resp, result)
+		return result, nil
+	}
+}
+
+// apiResponse is the response of an API request made for a tool call or a resource read.
+type apiResponse struct {
+	status      int
+	contentType string
+	header      http.Header
+	body        []byte
+}
+
+// callAPI builds the HTTP request of an endpoint from the call arguments and sends it.
+// Errors are meant to be shown to the client as they are.
+func callAPI(
+	ctx context.Context,
+	args map[string]interface{},
+	reqPathParam []string,
+	reqQueryParam []string,
+	reqURL string,
+	reqBody map[string]string,
+	reqMethod string,
+	reqHeader []string,
+	apiCfg models.ApiConfig,
+) (apiResponse, error) {
+	req, reqBodyDataBytes, apiCfg, err := newAPIRequest(ctx, args, reqPathParam, reqQueryParam, reqURL, reqBody, reqMethod, reqHeader, apiCfg)
+	if err != nil {
+		return apiResponse{}, err
+	}
+	log.Printf("Request  : %s %s", req.Method, traceURL(req.URL.String()))
+	if id, ok := auth.FromContext(ctx); ok {
+		log.Printf("%s %s called by %s (%s)", req.Method, req.URL.Path, id.Subject, id.Method)
+	}
+	trace := upstreamTrace{method: req.Method, url: reqURL, reqBody: reqBodyDataBytes, secrets: traceSecrets(ctx, req, apiCfg)}
+	client := &http.Client{}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		trace.latency, trace.err = time.Since(start), err
+		sendTrace(ctx, trace)
+		return apiResponse{}, fmt.Errorf("failed to make HTTP request: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	trace.latency, trace.status, trace.respBody, trace.err = time.Since(start), resp.StatusCode, body, err
+	sendTrace(ctx, trace)
+	if err != nil {
+		return apiResponse{}, fmt.Errorf("failed to read HTTP Response: %v", err)
+	}
+	return apiResponse{status: resp.StatusCode, contentType: resp.Header.Get("Content-Type"), header: resp.Header, body: body}, nil
+}
+
+// newAPIRequest builds the HTTP request of an endpoint from the call arguments, with its credentials and headers.
+// It also returns the request body and apiCfg with its credential references resolved.
+func newAPIRequest(
+	ctx context.Context,
+	args map[string]interface{},
+	reqPathParam []string,
+	reqQueryParam []string,
+	reqURL string,
+	reqBody map[string]string,
+	reqMethod string,
+	reqHeader []string,
+	apiCfg models.ApiConfig,
+) (*http.Request, []byte, models.ApiConfig, error) {
+	if err := checkReadOnly(reqMethod, apiCfg); err != nil {
+		return nil, nil, apiCfg, err
+	}
+	currentReqURL := reqURL
+	for _, paramName := range reqPathParam {
+		param, ok := args[paramName].(string)
+		if !ok {
+			return nil, nil, apiCfg, fmt.Errorf("missing or invalid Path Parameter: %s", paramName)
+		}
+		currentReqURL = strings.Replace(currentReqURL, fmt.Sprintf("{%s}", paramName), param, 1)
+	}
+	// query param
+	if len(reqQueryParam) > 0 {
+		u, err := url.Parse(currentReqURL)
+		if err != nil {
+			return nil, nil, apiCfg, fmt.Errorf("failed to parse URL: %v", err)
+		}
+		q := u.Query()
+		for _, name := range reqQueryParam {
+			val, ok := args[name].(string)
 			if !ok {
-				return mcp.NewToolResultError(fmt.Sprintf("[Error] missing or invalid Path Parameter: %s", paramName)), nil
+				return nil, nil, apiCfg, fmt.Errorf("missing or invalid Query Parameter: %s", name)
 			}
-			currentReqURL = strings.Replace(currentReqURL, fmt.Sprintf("{%s}", paramName), param, 1)
+			q.Set(name, val)
+		}
+		u.RawQuery = q.Encode()
+		currentReqURL = u.String()
+	}
+	reqBodyData := make(map[string]interface{})
+	for paramName, paramType := range reqBody {
+		paramStr, exists := args[paramName].(string)
+		if !exists {
+			return nil, nil, apiCfg, fmt.Errorf("missing Body Parameter: %s", paramName)
 		}
-		// query param
-		if len(reqQueryParam) > 0 {
-			u, err := url.Parse(currentReqURL)
+		switch paramType {
+		case "string":
+			reqBodyData[paramName] = paramStr
+		case "int", "integer":
+			intValue, err := strconv.Atoi(paramStr)
 			if err != nil {
-				return mcp.NewToolResultError(fmt.Sprintf("[Error] failed to parse URL: %v", err)), nil
+				return nil, nil, apiCfg, fmt.Errorf("invalid type for parameter %s, expected int", paramName)
 			}
-			q := u.Query()
-			for _, name := range reqQueryParam {
-				val, ok := request.Params.Arguments[name].(string)
-				if !ok {
-					return mcp.NewToolResultError(fmt.Sprintf("[Error] missing or invalid Query Parameter: %s", name)), nil
-				}
-				q.Set(name, val)
+			reqBodyData[paramName] = intValue
+		case "float":
+			floatValue, err := strconv.ParseFloat(paramStr, 64)
+			if err != nil {
+				return nil, nil, apiCfg, fmt.Errorf("invalid type for parameter %s, expected float", paramName)
 			}
-			u.RawQuery = q.Encode()
-			currentReqURL = u.String()
-		}
-		reqBodyData := make(map[string]interface{})
-		for paramName, paramType := range reqBody {
-			paramStr, exists := request.Params.Arguments[paramName].(string)
-			if !exists {
-				return mcp.NewToolResultError(fmt.Sprintf("[Error] missing Body Parameter: %s", paramName)), nil
+			reqBodyData[paramName] = floatValue
+		case "bool", "boolean":
+			boolValue, err := strconv.ParseBool(paramStr)
+			if err != nil {
+				return nil, nil, apiCfg, fmt.Errorf("invalid type for parameter %s, expected bool", paramName)
 			}
-			switch paramType {
-			case "string":
-				reqBodyData[paramName] = paramStr
-			case "int", "integer":
-				intValue, err := strconv.Atoi(paramStr)
-				if err != nil {
-					return mcp.NewToolResultError(fmt.Sprintf("[Error] invalid type for parameter %s, expected int", paramName)), nil
-				}
-				reqBodyData[paramName] = intValue
-			case "float":
-				floatValue, err := strconv.ParseFloat(paramStr, 64)
-				if err != nil {
-					return mcp.NewToolResultError(fmt.Sprintf("[Error] invalid type for parameter %s, expected float", paramName)), nil
-				}
-				reqBodyData[paramName] = floatValue
-			case "bool", "boolean":
-				boolValue, err := strconv.ParseBool(paramStr)
-				if err != nil {
-					return mcp.NewToolResultError(fmt.Sprintf("[Error] invalid type for parameter %s, expected bool", paramName)), nil
-				}
-				reqBodyData[paramName] = boolValue
-			case "array":
-				var arrayValue []interface{}
-				if err := json.Unmarshal([]byte(paramStr), &arrayValue); err != nil {
-					return mcp.NewToolResultError(fmt.Sprintf("[Error] invalid type for parameter %s, expected array", paramName)), nil
-				}
-				reqBodyData[paramName] = arrayValue
-			case "object":
-				var objectValue map[string]interface{}
-				if err := json.Unmarshal([]byte(paramStr), &objectValue); err != nil {
-					return mcp.NewToolResultError(fmt.Sprintf("[Error] invalid type for parameter %s, expected object", paramName)), nil
-				}
-				reqBodyData[paramName] = objectValue
-			default:
-				return mcp.NewToolResultError(fmt.Sprintf("[Error] unsupported parameter type: %s for %s", paramType, paramName)), nil
+			reqBodyData[paramName] = boolValue
+		case "array":
+			var arrayValue []interface{}
+			if err := json.Unmarshal([]byte(paramStr), &arrayValue); err != nil {
+				return nil, nil, apiCfg, fmt.Errorf("invalid type for parameter %s, expected array", paramName)
 			}
+			reqBodyData[paramName] = arrayValue
+		case "object":
+			var objectValue map[string]interface{}
+			if err := json.Unmarshal([]byte(paramStr), &objectValue); err != nil {
+				return nil, nil, apiCfg, fmt.Errorf("invalid type for parameter %s, expected object", paramName)
+			}
+			reqBodyData[paramName] = objectValue
+		default:
+			return nil, nil, apiCfg, fmt.Errorf("unsupported parameter type: %s for %s", paramType, paramName)
 		}
-		reqBodyDataBytes, err := json.Marshal(reqBodyData)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("[Error] failed to marshal request body: %v", err)), nil
-		}
-		fmt.Printf("Request  : %s %s\n", strings.ToUpper(reqMethod), currentReqURL)
-		req, err := http.NewRequest(strings.ToUpper(reqMethod), currentReqURL, bytes.NewBuffer(reqBodyDataBytes))
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("[Error] failed to create HTTP request: %v", err)), nil
+	}
+	reqBodyDataBytes, err := json.Marshal(reqBodyData)
+	if err != nil {
+		return nil, nil, apiCfg, fmt.Errorf("failed to marshal request body: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(reqMethod), currentReqURL, bytes.NewBuffer(reqBodyDataBytes))
+	if err != nil {
+		return nil, nil, apiCfg, fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+	for _, headerName := range reqHeader {
+		headerValue, ok := args[headerName].(string)
+		if !ok {
+			return nil, nil, apiCfg, fmt.Errorf("missing or invalid Header: %s", headerName)
 		}
-		for _, headerName := range reqHeader {
-			headerValue, ok := request.Params.Arguments[headerName].(string)
-			if !ok {
-				return mcp.NewToolResultError(fmt.Sprintf("[Error] missing or invalid Header: %s", headerName)), nil
-			}
-			req.Header.Add(headerName, headerValue)
+		req.Header.Add(headerName, headerValue)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// resolve credential references at call time so rotated secrets are picked up
+	apiCfg, err = resolveSecrets(apiCfg)
+	if err != nil {
+		return nil, nil, apiCfg, fmt.Errorf("failed to resolve credentials: %v", err)
+	}
+	// request security
+	setRequestSecurity(req, apiCfg.Security, apiCfg.BasicAuth, apiCfg.ApiKeyAuth, apiCfg.BearerAuth)
+	if strings.TrimSpace(apiCfg.Security) == "apiKey" {
+		for _, key := range apiCfg.ApiKeys {
+			setApiKey(req, key.In, key.Name, key.Value)
 		}
-		req.Header.Set("Content-Type", "application/json")
-		// request security
-		setRequestSecurity(req, apiCfg.Security, apiCfg.BasicAuth, apiCfg.ApiKeyAuth, apiCfg.BearerAuth)
-		// set custom headers from ApiConfig.Headers (format: name1=value1,name2=value2)
-		if apiCfg.Headers != "" {
-			for _, pair := range strings.Split(apiCfg.Headers, ",") {
-				if pair = strings.TrimSpace(pair); pair == "" {
-					continue
-				}
-				if kv := strings.SplitN(pair, "=", 2); len(kv) == 2 {
-					if key := strings.TrimSpace(kv[0]); key != "" {
-						req.Header.Add(key, strings.TrimSpace(kv[1]))
-					}
-				}
+	}
+	// set custom headers from ApiConfig.Headers (format: name1=value1,name2=value2)
+	if apiCfg.Headers != "" {
+		for _, pair := range strings.Split(apiCfg.Headers, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
 			}
-		}
-		// headers from sse
-		sseHeadersValue := ctx.Value(sseHeadersKey)
-		if sseHeadersValue != nil {
-			if sseHeaders, ok := sseHeadersValue.(map[string]string); ok {
-				for k, v := range sseHeaders {
-					req.Header.Set(k, v)
+			if kv := strings.SplitN(pair, "=", 2); len(kv) == 2 {
+				if key := strings.TrimSpace(kv[0]); key != "" {
+					req.Header.Add(key, strings.TrimSpace(kv[1]))
 				}
 			}
 		}
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("[Error] failed to make HTTP request: %v", err)), nil
-		}
-		defer resp.Body.Close()
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("[Error] failed to read HTTP Response: %v", err)), nil
-		}
-		fmt.Printf("Response : %s\n", string(body))
-		return mcp.NewToolResultText(string(body)), nil
 	}
+	for key, value := range apiCfg.HeaderValues {
+		req.Header.Set(key, value)
+	}
+	// credentials and headers supplied by the calling SSE client
+	applySession(ctx, req, apiCfg)
+	return req, reqBodyDataBytes, apiCfg, nil
 }
@@ -150,13 +150,7 @@ func TestCreateMCPToolHandler_BodyTypes(t *testing.T) {
 		"active":   "true",
 		"X-Header": "val",
 	}
-	callReq := mcp.CallToolRequest{Params: struct {
-		Name      string                 `json:"name"`
-		Arguments map[string]interface{} `json:"arguments,omitempty"`
-		Meta      *struct {
-			ProgressToken mcp.ProgressToken `json:"progressToken,omitempty"`
-		} `json:"_meta,omitempty"`
-	}{Arguments: params}}
+	callReq := mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: params}}
 	ctx := context.Background()
 
 	// Use httptest to intercept outgoing HTTP requests
@@ -205,3 +199,102 @@ func TestCreateMCPToolHandler_BodyTypes(t *testing.T) {
 		t.Errorf("Expected ok in response, got %s", resultStr)
 	}
 }
+
+func TestBuildSwaggerTools_Overrides(t *testing.T) {
+	spec := models.SwaggerSpec{Host: "example.com", Paths: map[string]map[string]models.Endpoint{
+		"/users/{id}": {
+			"get":    {OperationID: "getUser", Summary: "Get user"},
+			"delete": {Summary: "Delete user"},
+		},
+		"/orders": {"get": {Summary: "List orders"}},
+	}}
+	apiCfg := models.ApiConfig{
+		ToolPrefix: "svc",
+		Overrides: map[string]models.OperationOverride{
+			"getUser":             {Name: "get_user", Description: "Fetch one user"},
+			"DELETE /users/{id}":  {Disabled: true},
+			"GET /does-not-exist": {Disabled: true},
+		},
+	}
+	tools := map[string]mcp.Tool{}
+	for _, tool := range BuildSwaggerTools(spec, apiCfg) {
+		tools[tool.Tool.Name] = tool.Tool
+	}
+	if len(tools) != 2 {
+		t.Fatalf("expected 2 tools, got %v", tools)
+	}
+	if tool, ok := tools["svc_get_user"]; !ok || tool.Description != "Fetch one user" {
+		t.Errorf("expected renamed tool with custom description, got %+v", tools)
+	}
+	if _, ok := tools["svc_get_/orders"]; !ok {
+		t.Errorf("expected svc_get_/orders tool, got %+v", tools)
+	}
+}
+
+func TestCreateMCPToolHandler_HeaderValuesAndApiKeys(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Tenant"); got != "acme,east" {
+			t.Errorf("expected X-Tenant header 'acme,east', got %q", got)
+		}
+		if got := r.Header.Get("X-API-Key"); got != "k1" {
+			t.Errorf("expected X-API-Key header 'k1', got %q", got)
+		}
+		if got := r.URL.Query().Get("key"); got != "k2" {
+			t.Errorf("expected key query param 'k2', got %q", got)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	apiCfg := models.ApiConfig{
+		Security:     "apiKey",
+		HeaderValues: map[string]string{"X-Tenant": "acme,east"},
+		ApiKeys:      []models.ApiKey{{In: "header", Name: "X-API-Key", Value: "k1"}, {In: "query", Name: "key", Value: "k2"}},
+	}
+	h := CreateMCPToolHandler(nil, nil, ts.URL+"/items", map[string]string{}, "get", nil, apiCfg)
+	if _, err := h(context.Background(), mcp.CallToolRequest{}); err != nil {
+		t.Fatalf("Handler error: %v", err)
+	}
+}
+
+func TestCreateMCPToolHandler_SecretReferences(t *testing.T) {
+	t.Setenv("SERVER_TEST_BEARER", "tok-from-env")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer tok-from-env" {
+			t.Errorf("expected resolved bearer token, got %q", got)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	h := CreateMCPToolHandler(nil, nil, ts.URL, map[string]string{}, "get", nil, models.ApiConfig{Security: "bearer", BearerAuth: "env:SERVER_TEST_BEARER"})
+	if res, err := h(context.Background(), mcp.CallToolRequest{}); err != nil || res.IsError {
+		t.Fatalf("unexpected result %+v, %v", res, err)
+	}
+
+	h = CreateMCPToolHandler(nil, nil, ts.URL, map[string]string{}, "get", nil, models.ApiConfig{Security: "bearer", BearerAuth: "env:SERVER_TEST_MISSING"})
+	res, err := h(context.Background(), mcp.CallToolRequest{})
+	if err != nil || !res.IsError {
+		t.Fatalf("expected tool error for unresolvable secret, got %+v, %v", res, err)
+	}
+}
+
+func TestCreateMCPToolHandler_ApiKeyReferences(t *testing.T) {
+	t.Setenv("SERVER_TEST_API_KEY", "key-from-env")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-API-Key"); got != "key-from-env" {
+			t.Errorf("expected resolved API key, got %q", got)
+		}
+		if got := r.URL.Query().Get("user"); got != "foo" {
+			t.Errorf("expected literal API key, got %q", got)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	apiCfg := models.ApiConfig{Security: "apiKey", ApiKeyAuth: "header:X-API-Key=env:SERVER_TEST_API_KEY,query:user=foo"}
+	h := CreateMCPToolHandler(nil, nil, ts.URL, map[string]string{}, "get", nil, apiCfg)
+	if res, err := h(context.Background(), mcp.CallToolRequest{}); err != nil || res.IsError {
+		t.Fatalf("unexpected result %+v, %v", res, err)
+	}
+}
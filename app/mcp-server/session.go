@@ -0,0 +1,394 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/danishjsheikh/swagger-mcp/app/auth"
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// contextKey is the type of the context keys set by this package.
+type contextKey string
+
+const (
+	// sessionKey is the context key for the Session of the client making a tool call.
+	sessionKey contextKey = "session"
+	// connectHeadersKey is the context key for the headers of the request that opened a session.
+	connectHeadersKey contextKey = "connectHeaders"
+)
+
+// sessionApiKeyHeader is the client header carrying a session API key.
+const sessionApiKeyHeader = "X-Api-Key"
+
+// credentialsCapability is the experimental client capability carrying session credentials in the initialize request.
+const credentialsCapability = "credentials"
+
+// blockedHeaders are never set from client headers: they control the connection to the API rather than the request.
+var blockedHeaders = map[string]bool{
+	"Host":                true,
+	"Content-Length":      true,
+	"Content-Type":        true,
+	"Connection":          true,
+	"Keep-Alive":          true,
+	"Proxy-Authorization": true,
+	"Proxy-Connection":    true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+}
+
+// Session holds what a client supplied for its own session. It is only visible to tool calls of that session.
+type Session struct {
+	BearerToken string                        // Sent as Authorization: Bearer
+	ApiKey      string                        // Sent where the spec's apiKey security scheme expects it
+	Cookies     string                        // Added to the Cookie header
+	Specs       map[string]SessionCredentials // Credentials by spec tool prefix, used instead when several specs are served
+	Headers     map[string]string             // Client headers after the configured header mappings
+	Owner       string                        // Subject of the authenticated client that opened the session
+}
+
+// SessionCredentials are the credentials a client supplied for one of the specs of a multi-spec server.
+type SessionCredentials struct {
+	BearerToken string
+	ApiKey      string
+	Cookies     string
+}
+
+// ParseHeaderMappings parses a --sseHeaders value: a comma-separated list of header names,
+// each optionally renamed as from:to.
+func ParseHeaderMappings(headers string) []models.HeaderMapping {
+	mappings := []models.HeaderMapping{}
+	for _, entry := range strings.Split(headers, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		from, to, _ := strings.Cut(entry, ":")
+		mappings = append(mappings, models.HeaderMapping{From: strings.TrimSpace(from), To: strings.TrimSpace(to)})
+	}
+	return mappings
+}
+
+// ValidateHeaderMappings rejects mappings that would let clients set connection-level headers on API requests.
+func ValidateHeaderMappings(mappings []models.HeaderMapping) error {
+	for _, m := range mappings {
+		if m.From == "" {
+			return fmt.Errorf("header mapping without a source header")
+		}
+		to := m.To
+		if to == "" {
+			to = m.From
+		}
+		if blockedHeaders[http.CanonicalHeaderKey(to)] {
+			return fmt.Errorf("header %s cannot be forwarded to API requests", to)
+		}
+	}
+	return nil
+}
+
+// mapHeaders returns the upstream headers for a client request. Only headers listed in mappings are forwarded.
+func mapHeaders(header http.Header, mappings []models.HeaderMapping) map[string]string {
+	mapped := map[string]string{}
+	for _, m := range mappings {
+		value := header.Get(m.From)
+		if value == "" {
+			continue
+		}
+		to := m.To
+		if to == "" {
+			to = m.From
+		}
+		if blockedHeaders[http.CanonicalHeaderKey(to)] {
+			continue
+		}
+		if m.Value != "" {
+			value = strings.ReplaceAll(m.Value, "{value}", value)
+		}
+		mapped[to] = value
+	}
+	return mapped
+}
+
+// sessionStore keeps the Session of every connected SSE or Streamable HTTP client, keyed by MCP session id.
+type sessionStore struct {
+	credentials bool
+	scoped      bool // several specs are served, so credentials are only accepted per spec
+	mappings    []models.HeaderMapping
+
+	mu       sync.RWMutex
+	sessions map[string]Session
+	forget   []func(sessionID string) // called when a session ends, to drop what other parts keep for it
+}
+
+func newSessionStore(config models.Config) *sessionStore {
+	mappings := ParseHeaderMappings(config.ApiCfg.SseHeaders)
+	mappings = append(mappings, config.SessionCfg.Headers...)
+	return &sessionStore{
+		credentials: config.SessionCfg.Credentials,
+		scoped:      len(config.Specs) > 0,
+		mappings:    mappings,
+		sessions:    map[string]Session{},
+	}
+}
+
+func (s *sessionStore) get(id string) (Session, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	session, ok := s.sessions[id]
+	return session, ok
+}
+
+func (s *sessionStore) update(id string, fn func(*Session)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session := s.sessions[id]
+	fn(&session)
+	s.sessions[id] = session
+}
+
+func (s *sessionStore) delete(id string) {
+	s.mu.Lock()
+	forget := s.forget
+	delete(s.sessions, id)
+	s.mu.Unlock()
+	for _, fn := range forget {
+		fn(id)
+	}
+}
+
+// onDelete registers a function called with the id of every session that ends.
+func (s *sessionStore) onDelete(fn func(sessionID string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forget = append(s.forget, fn)
+}
+
+// sessionFromHeaders builds a Session from the headers of a client request.
+// Credential headers do not name a spec, so they are ignored when several specs are served.
+func (s *sessionStore) sessionFromHeaders(header http.Header) Session {
+	session := Session{Headers: mapHeaders(header, s.mappings)}
+	if s.credentials && !s.scoped {
+		if auth := header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+			session.BearerToken = strings.TrimSpace(auth[7:])
+		}
+		session.ApiKey = header.Get(sessionApiKeyHeader)
+		session.Cookies = header.Get("Cookie")
+	}
+	return session
+}
+
+// hooks registers sessions when clients connect or initialize and forgets them when they disconnect.
+func (s *sessionStore) hooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		header, _ := ctx.Value(connectHeadersKey).(http.Header)
+		connect := s.sessionFromHeaders(header)
+		if id, ok := auth.FromContext(ctx); ok {
+			connect.Owner = id.Subject
+		}
+		// Streamable HTTP registers the session after initialize, so keep what the client sent there
+		s.update(session.SessionID(), func(current *Session) { *current = mergeSession(connect, *current) })
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		s.delete(session.SessionID())
+	})
+	hooks.AddAfterInitialize(func(ctx context.Context, id any, message *mcp.InitializeRequest, result *mcp.InitializeResult) {
+		if !s.credentials {
+			return
+		}
+		session := server.ClientSessionFromContext(ctx)
+		if session == nil {
+			return
+		}
+		creds, ok := message.Params.Capabilities.Experimental[credentialsCapability].(map[string]interface{})
+		if !ok {
+			return
+		}
+		s.update(session.SessionID(), func(current *Session) { s.setCredentials(current, creds) })
+	})
+	return hooks
+}
+
+// setCredentials sets the credentials of the credentials capability of an initialize request on a session.
+// When several specs are served they are keyed by spec tool prefix, e.g. {"orders": {"bearerToken": "..."}}.
+func (s *sessionStore) setCredentials(session *Session, creds map[string]interface{}) {
+	if !s.scoped {
+		c := mergeCredentials(session.credentials(""), parseCredentials(creds))
+		session.BearerToken, session.ApiKey, session.Cookies = c.BearerToken, c.ApiKey, c.Cookies
+		return
+	}
+	specs := make(map[string]SessionCredentials, len(session.Specs)+len(creds))
+	for prefix, c := range session.Specs {
+		specs[prefix] = c
+	}
+	for prefix, value := range creds {
+		if spec, ok := value.(map[string]interface{}); ok {
+			specs[prefix] = mergeCredentials(specs[prefix], parseCredentials(spec))
+		}
+	}
+	session.Specs = specs
+}
+
+// parseCredentials reads the bearerToken, apiKey and cookies of a credentials object.
+func parseCredentials(creds map[string]interface{}) SessionCredentials {
+	var c SessionCredentials
+	c.BearerToken, _ = creds["bearerToken"].(string)
+	c.ApiKey, _ = creds["apiKey"].(string)
+	c.Cookies, _ = creds["cookies"].(string)
+	return c
+}
+
+// mergeCredentials returns base with the credentials set in over taking precedence.
+func mergeCredentials(base, over SessionCredentials) SessionCredentials {
+	if over.BearerToken != "" {
+		base.BearerToken = over.BearerToken
+	}
+	if over.ApiKey != "" {
+		base.ApiKey = over.ApiKey
+	}
+	if over.Cookies != "" {
+		base.Cookies = over.Cookies
+	}
+	return base
+}
+
+// connectMiddleware keeps the headers of the request opening a session, so the session hook can read them.
+// That is the GET request of an SSE connection or the initialize request of a Streamable HTTP session.
+func (s *sessionStore) connectMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(context.WithValue(r.Context(), connectHeadersKey, r.Header.Clone()))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ownerMiddleware refuses requests to a session opened by another authenticated client, be it an SSE message
+// or a Streamable HTTP request, so a client that learns the id of a session cannot use it.
+func (s *sessionStore) ownerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("sessionId")
+		if id == "" {
+			id = r.Header.Get(server.HeaderKeySessionID)
+		}
+		if session, ok := s.get(id); ok && session.Owner != "" {
+			if caller, _ := auth.FromContext(r.Context()); caller.Subject != session.Owner {
+				http.Error(w, "session belongs to another client", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// contextFunc puts the Session of the calling SSE client into the context of each message.
+func (s *sessionStore) contextFunc(ctx context.Context, r *http.Request) context.Context {
+	return s.withSession(ctx, r.URL.Query().Get("sessionId"), r)
+}
+
+// httpContextFunc puts the Session of the calling Streamable HTTP client into the context of each request.
+func (s *sessionStore) httpContextFunc(ctx context.Context, r *http.Request) context.Context {
+	return s.withSession(ctx, r.Header.Get(server.HeaderKeySessionID), r)
+}
+
+// withSession adds the Session with the given id to the context of request r.
+// Mapped headers of the message request take precedence over those sent when connecting.
+// A session opened by an authenticated client is not used for requests of another client.
+func (s *sessionStore) withSession(ctx context.Context, id string, r *http.Request) context.Context {
+	session, _ := s.get(id)
+	if caller, ok := auth.FromContext(r.Context()); ok && session.Owner != "" && session.Owner != caller.Subject {
+		session = Session{}
+	}
+	return context.WithValue(ctx, sessionKey, mergeSession(session, s.sessionFromHeaders(r.Header)))
+}
+
+// mergeSession returns base with the headers and credentials set in over taking precedence.
+func mergeSession(base, over Session) Session {
+	if len(over.Headers) > 0 {
+		base.Headers = mergeHeaders(base.Headers, over.Headers)
+	}
+	if len(over.Specs) > 0 {
+		specs := make(map[string]SessionCredentials, len(base.Specs)+len(over.Specs))
+		for prefix, c := range base.Specs {
+			specs[prefix] = c
+		}
+		for prefix, c := range over.Specs {
+			specs[prefix] = mergeCredentials(specs[prefix], c)
+		}
+		base.Specs = specs
+	}
+	if over.BearerToken != "" {
+		base.BearerToken = over.BearerToken
+	}
+	if over.ApiKey != "" {
+		base.ApiKey = over.ApiKey
+	}
+	if over.Cookies != "" {
+		base.Cookies = over.Cookies
+	}
+	return base
+}
+
+// credentials returns the credentials of the session for the spec with the given tool prefix.
+func (s Session) credentials(prefix string) SessionCredentials {
+	if c, ok := s.Specs[prefix]; ok {
+		return c
+	}
+	return SessionCredentials{s.BearerToken, s.ApiKey, s.Cookies}
+}
+
+// applySession sets the credentials and headers of the calling client's session on an API request.
+// They take precedence over the server's own credentials. Only the credentials given for the spec of the
+// request are used.
+func applySession(ctx context.Context, req *http.Request, apiCfg models.ApiConfig) {
+	session, ok := ctx.Value(sessionKey).(Session)
+	if !ok {
+		return
+	}
+	for k, v := range session.Headers {
+		req.Header.Set(k, v)
+	}
+	creds := session.credentials(apiCfg.ToolPrefix)
+	if creds.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+creds.BearerToken)
+	}
+	if creds.ApiKey != "" {
+		location := apiCfg.SessionApiKey
+		if location.Name == "" {
+			location = models.ApiKey{In: "header", Name: sessionApiKeyHeader}
+		}
+		setApiKey(req, location.In, location.Name, creds.ApiKey)
+	}
+	if creds.Cookies != "" {
+		if existing := req.Header.Get("Cookie"); existing != "" {
+			req.Header.Set("Cookie", existing+"; "+creds.Cookies)
+		} else {
+			req.Header.Set("Cookie", creds.Cookies)
+		}
+	}
+}
+
+// specApiKeyLocation returns where the spec's apiKey security scheme expects the key, if it has one.
+// Schemes are considered in name order so the choice is stable.
+func specApiKeyLocation(swaggerSpec models.SwaggerSpec) models.ApiKey {
+	schemes := swaggerSpec.SecurityDefinitions
+	if swaggerSpec.Components != nil && len(swaggerSpec.Components.SecuritySchemes) > 0 {
+		schemes = swaggerSpec.Components.SecuritySchemes
+	}
+	names := make([]string, 0, len(schemes))
+	for name := range schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if scheme := schemes[name]; scheme.Type == "apiKey" && scheme.Name != "" {
+			return models.ApiKey{In: scheme.In, Name: scheme.Name}
+		}
+	}
+	return models.ApiKey{}
+}
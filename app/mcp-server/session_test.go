@@ -0,0 +1,249 @@
+package mcpserver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danishjsheikh/swagger-mcp/app/auth"
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestParseHeaderMappings(t *testing.T) {
+	mappings := ParseHeaderMappings("X-Tenant, X-User-Token:X-Upstream-Token,")
+	if len(mappings) != 2 {
+		t.Fatalf("expected 2 mappings, got %+v", mappings)
+	}
+	if mappings[1].From != "X-User-Token" || mappings[1].To != "X-Upstream-Token" {
+		t.Errorf("unexpected rename mapping %+v", mappings[1])
+	}
+}
+
+func TestValidateHeaderMappings(t *testing.T) {
+	if err := ValidateHeaderMappings([]models.HeaderMapping{{From: "X-Tenant"}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateHeaderMappings([]models.HeaderMapping{{From: "X-Host", To: "host"}}); err == nil {
+		t.Error("expected error when mapping onto Host")
+	}
+	if err := ValidateHeaderMappings([]models.HeaderMapping{{From: "Transfer-Encoding"}}); err == nil {
+		t.Error("expected error when forwarding Transfer-Encoding")
+	}
+}
+
+func TestMapHeaders_AllowListAndRewrite(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-Tenant", "acme")
+	header.Set("X-User-Token", "abc")
+	header.Set("X-Injected", "evil")
+	mapped := mapHeaders(header, []models.HeaderMapping{
+		{From: "X-Tenant"},
+		{From: "X-User-Token", To: "Authorization", Value: "Token {value}"},
+		{From: "X-Missing"},
+	})
+	if len(mapped) != 2 || mapped["X-Tenant"] != "acme" || mapped["Authorization"] != "Token abc" {
+		t.Errorf("unexpected mapped headers %+v", mapped)
+	}
+}
+
+func TestSessionStore_CredentialsAreIsolated(t *testing.T) {
+	store := newSessionStore(models.Config{
+		ApiCfg:     models.ApiConfig{SseHeaders: "X-Tenant"},
+		SessionCfg: models.SessionConfig{Credentials: true},
+	})
+	alice := http.Header{}
+	alice.Set("Authorization", "Bearer alice-token")
+	alice.Set("X-Tenant", "a")
+	store.update("s1", func(s *Session) { *s = store.sessionFromHeaders(alice) })
+	bob := http.Header{}
+	bob.Set("X-Api-Key", "bob-key")
+	store.update("s2", func(s *Session) { *s = store.sessionFromHeaders(bob) })
+
+	var gotAuth, gotKey, gotTenant string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth, gotKey, gotTenant = r.Header.Get("Authorization"), r.URL.Query().Get("api_key"), r.Header.Get("X-Tenant")
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	apiCfg := models.ApiConfig{Security: "bearer", BearerAuth: "service-token", SessionApiKey: models.ApiKey{In: "query", Name: "api_key"}}
+	h := CreateMCPToolHandler(nil, nil, ts.URL, map[string]string{}, "get", nil, apiCfg)
+
+	call := func(sessionID string) {
+		r := httptest.NewRequest(http.MethodPost, "/message?sessionId="+sessionID, nil)
+		if _, err := h(store.contextFunc(context.Background(), r), mcp.CallToolRequest{}); err != nil {
+			t.Fatalf("handler error: %v", err)
+		}
+	}
+
+	call("s1")
+	if gotAuth != "Bearer alice-token" || gotKey != "" || gotTenant != "a" {
+		t.Errorf("session s1 got auth=%q key=%q tenant=%q", gotAuth, gotKey, gotTenant)
+	}
+	call("s2")
+	if gotAuth != "Bearer service-token" || gotKey != "bob-key" || gotTenant != "" {
+		t.Errorf("session s2 got auth=%q key=%q tenant=%q", gotAuth, gotKey, gotTenant)
+	}
+
+	store.delete("s1")
+	call("s1")
+	if gotAuth != "Bearer service-token" {
+		t.Errorf("expected service credentials after session ended, got %q", gotAuth)
+	}
+}
+
+func TestSpecApiKeyLocation(t *testing.T) {
+	spec := models.SwaggerSpec{OpenAPI: "3.0.0", Components: &models.Components{SecuritySchemes: map[string]models.SecurityScheme{
+		"bearer": {Type: "http", Scheme: "bearer"},
+		"key":    {Type: "apiKey", In: "header", Name: "X-Service-Key"},
+	}}}
+	if got := specApiKeyLocation(spec); got.In != "header" || got.Name != "X-Service-Key" {
+		t.Errorf("unexpected api key location %+v", got)
+	}
+	if got := specApiKeyLocation(models.SwaggerSpec{}); got.Name != "" {
+		t.Errorf("expected no location for spec without schemes, got %+v", got)
+	}
+}
+
+func TestStreamableHTTP_SessionCredentials(t *testing.T) {
+	var gotAuth, gotTenant string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth, gotTenant = r.Header.Get("Authorization"), r.Header.Get("X-Tenant")
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+
+	store := newSessionStore(models.Config{
+		ApiCfg:     models.ApiConfig{SseHeaders: "X-Tenant"},
+		SessionCfg: models.SessionConfig{Credentials: true},
+	})
+	mcpServer := newMCPServer("1.0.0", store, newLifecycle(), newCompletionSet())
+	apiCfg := models.ApiConfig{Security: "bearer", BearerAuth: "service-token"}
+	mcpServer.AddTool(mcp.NewTool("get_thing"), CreateMCPToolHandler(nil, nil, api.URL, map[string]string{}, "get", nil, apiCfg))
+
+	mux := http.NewServeMux()
+	httpServer := &http.Server{Handler: store.connectMiddleware(mux)}
+	mux.Handle(httpEndpointPath, newStreamableServer(mcpServer, httpServer, store))
+	ts := httptest.NewServer(httpServer.Handler)
+	defer ts.Close()
+
+	post := func(sessionID, body string, header map[string]string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+httpEndpointPath, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if sessionID != "" {
+			req.Header.Set(server.HeaderKeySessionID, sessionID)
+		}
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		map[string]string{"Authorization": "Bearer alice-token", "X-Tenant": "a"})
+	sessionID := resp.Header.Get(server.HeaderKeySessionID)
+	if sessionID == "" {
+		t.Fatal("expected a session id from initialize")
+	}
+
+	call := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_thing","arguments":{}}}`
+	post(sessionID, call, nil)
+	if gotAuth != "Bearer alice-token" || gotTenant != "a" {
+		t.Errorf("expected initialize credentials to be used, got auth=%q tenant=%q", gotAuth, gotTenant)
+	}
+	post(sessionID, call, map[string]string{"X-Tenant": "b"})
+	if gotAuth != "Bearer alice-token" || gotTenant != "b" {
+		t.Errorf("expected request header to take precedence, got auth=%q tenant=%q", gotAuth, gotTenant)
+	}
+}
+
+func TestSessionStore_OwnerBinding(t *testing.T) {
+	store := newSessionStore(models.Config{SessionCfg: models.SessionConfig{Credentials: true}})
+	store.update("s1", func(s *Session) { *s = Session{BearerToken: "alice-upstream", Owner: "alice"} })
+
+	lookup := func(subject string) Session {
+		r := httptest.NewRequest(http.MethodPost, "/message?sessionId=s1", nil)
+		r = r.WithContext(auth.WithIdentity(r.Context(), auth.Identity{Subject: subject, Method: auth.MethodBearer}))
+		session, _ := store.contextFunc(context.Background(), r).Value(sessionKey).(Session)
+		return session
+	}
+	if got := lookup("alice"); got.BearerToken != "alice-upstream" {
+		t.Errorf("expected the owner to use the session, got %+v", got)
+	}
+	if got := lookup("mallory"); got.BearerToken != "" {
+		t.Errorf("expected another client not to use the session, got %+v", got)
+	}
+}
+
+func TestSessionStore_OwnerMiddleware(t *testing.T) {
+	store := newSessionStore(models.Config{})
+	store.update("s1", func(s *Session) { *s = Session{Owner: "alice"} })
+	handler := store.ownerMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(subject string, r *http.Request) int {
+		r = r.WithContext(auth.WithIdentity(r.Context(), auth.Identity{Subject: subject, Method: auth.MethodBearer}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+	sse := func() *http.Request { return httptest.NewRequest(http.MethodPost, "/message?sessionId=s1", nil) }
+	streamable := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, httpEndpointPath, nil)
+		r.Header.Set(server.HeaderKeySessionID, "s1")
+		return r
+	}
+	for name, request := range map[string]func() *http.Request{"sse": sse, "streamable": streamable} {
+		if code := send("alice", request()); code != http.StatusOK {
+			t.Errorf("%s: expected the owner to be let through, got %d", name, code)
+		}
+		if code := send("mallory", request()); code != http.StatusForbidden {
+			t.Errorf("%s: expected another client to be refused, got %d", name, code)
+		}
+	}
+	if code := send("mallory", httptest.NewRequest(http.MethodPost, "/message?sessionId=other", nil)); code != http.StatusOK {
+		t.Errorf("expected requests to unknown sessions to be let through, got %d", code)
+	}
+}
+
+func TestSessionStore_ScopedCredentials(t *testing.T) {
+	store := newSessionStore(models.Config{
+		SessionCfg: models.SessionConfig{Credentials: true},
+		Specs:      []models.SpecConfig{{ApiCfg: models.ApiConfig{ToolPrefix: "orders"}}, {ApiCfg: models.ApiConfig{ToolPrefix: "users"}}},
+	})
+	// credential headers do not say which API they are for
+	header := http.Header{}
+	header.Set("Authorization", "Bearer connect-token")
+	session := store.sessionFromHeaders(header)
+	store.setCredentials(&session, map[string]interface{}{
+		"orders": map[string]interface{}{"bearerToken": "orders-token"},
+		"apiKey": "not-a-spec",
+	})
+
+	var gotAuth string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+	ctx := context.WithValue(context.Background(), sessionKey, session)
+	for prefix, want := range map[string]string{"orders": "Bearer orders-token", "users": "Bearer users-service-token"} {
+		apiCfg := models.ApiConfig{ToolPrefix: prefix, Security: "bearer", BearerAuth: prefix + "-service-token"}
+		if _, err := CreateMCPToolHandler(nil, nil, api.URL, map[string]string{}, "get", nil, apiCfg)(ctx, mcp.CallToolRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		if gotAuth != want {
+			t.Errorf("%s: expected %q, got %q", prefix, want, gotAuth)
+		}
+	}
+}
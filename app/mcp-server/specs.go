@@ -0,0 +1,79 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/danishjsheikh/swagger-mcp/app/swagger"
+	"github.com/danishjsheikh/swagger-mcp/app/version"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// specLoader loads a Swagger/OpenAPI spec from a URL or file path. It is a variable so tests can stub it.
+var specLoader = swagger.LoadSwagger
+
+// specLabel returns a human readable name for a spec, used in log messages.
+func specLabel(spec models.SpecConfig) string {
+	if spec.ApiCfg.ToolPrefix != "" {
+		return fmt.Sprintf("%s (%s)", spec.ApiCfg.ToolPrefix, spec.SpecUrl)
+	}
+	return spec.SpecUrl
+}
+
+// loadSpecs loads every spec and registers its tools on the MCP server. The completions of the specs are added to
+// the completion set of the server. The operations are added to the catalog, if any; in meta mode they are not registered as
+// tools, and with toolsets (toolsets mode) only the tools of the core toolsets are registered.
+// It returns a reloader for every spec, including the ones that failed, and the number of specs loaded.
+func loadSpecs(mcpServer *server.MCPServer, completions *completionSet, catalog *operationCatalog, toolsets *toolsetSet, specs []models.SpecConfig) ([]*specReloader, int) {
+	reloaders := []*specReloader{}
+	loaded := 0
+	templates := newTemplateSet(mcpServer)
+	for _, spec := range specs {
+		r := newSpecReloader(mcpServer, spec)
+		r.templates = templates
+		r.completions = completions
+		r.catalog = catalog
+		r.toolsets = toolsets
+		reloaders = append(reloaders, r)
+		swaggerSpec, err := specLoader(spec.SpecUrl)
+		if err != nil {
+			log.Printf("Failed to load spec %s: %v", specLabel(spec), err)
+			continue
+		}
+		if _, err := r.apply(swaggerSpec); err != nil {
+			log.Printf("Failed to load spec %s: %v", specLabel(spec), err)
+			continue
+		}
+		log.Printf("Loaded spec %s with %d paths", specLabel(spec), len(swaggerSpec.Paths))
+		loaded++
+	}
+	return reloaders, loaded
+}
+
+// LoadSpecs loads every spec and registers its tools on the MCP server.
+// A spec that fails to load is reported and skipped, so the remaining specs are still served.
+// It returns the number of specs that were loaded successfully.
+func LoadSpecs(mcpServer *server.MCPServer, specs []models.SpecConfig) int {
+	_, loaded := loadSpecs(mcpServer, newCompletionSet(), nil, nil, specs)
+	return loaded
+}
+
+// CreateMultiSpecServer creates and starts a single MCP server serving the tools of every spec in config.Specs.
+// It returns an error only if none of the specs could be loaded.
+func CreateMultiSpecServer(config models.Config) error {
+	sessions := newSessionStore(config)
+	life := newLifecycle()
+	completions := newCompletionSet()
+	mcpServer := newMCPServer(version.Version, sessions, life, completions)
+	catalog := newToolCatalog(mcpServer, config.ToolMode)
+	toolsets := newToolsetSet(mcpServer, sessions, config.ToolMode, config.CoreToolsets)
+	reloaders, loaded := loadSpecs(mcpServer, completions, catalog, toolsets, config.Specs)
+	if loaded == 0 {
+		return fmt.Errorf("none of the %d specs could be loaded", len(config.Specs))
+	}
+	watchSpecs(context.Background(), reloaders, config.ReloadCfg)
+	serve(mcpServer, config, sessions, life, reloaders)
+	return nil
+}
@@ -0,0 +1,91 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// listToolNames returns the sorted names of the tools registered on the MCP server.
+func listToolNames(t *testing.T, mcpServer *server.MCPServer) []string {
+	t.Helper()
+	resp := mcpServer.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("failed to marshal tools/list response: %v", err)
+	}
+	var decoded struct {
+		Result mcp.ListToolsResult `json:"result"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to decode tools/list response: %v", err)
+	}
+	names := []string{}
+	for _, tool := range decoded.Result.Tools {
+		names = append(names, tool.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestBuildToolName(t *testing.T) {
+	if got := buildToolName("", "get", "/users/{id}"); got != "get_/users/id" {
+		t.Errorf("buildToolName without prefix = %q", got)
+	}
+	if got := buildToolName("billing", "post", "/invoices"); got != "billing_post_/invoices" {
+		t.Errorf("buildToolName with prefix = %q", got)
+	}
+}
+
+func TestLoadSpecs_SkipsFailingSpec(t *testing.T) {
+	origLoader := specLoader
+	defer func() { specLoader = origLoader }()
+	specLoader = func(specUrl string) (models.SwaggerSpec, error) {
+		switch specUrl {
+		case "users":
+			return models.SwaggerSpec{Host: "users.example.com", Paths: map[string]map[string]models.Endpoint{
+				"/users": {"get": {Summary: "List users"}},
+			}}, nil
+		case "orders":
+			return models.SwaggerSpec{Host: "orders.example.com", Paths: map[string]map[string]models.Endpoint{
+				"/orders": {"get": {Summary: "List orders"}},
+			}}, nil
+		}
+		return models.SwaggerSpec{}, fmt.Errorf("error getting spec: status 500")
+	}
+
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	loaded := LoadSpecs(mcpServer, []models.SpecConfig{
+		{SpecUrl: "users", ApiCfg: models.ApiConfig{ToolPrefix: "users"}},
+		{SpecUrl: "broken", ApiCfg: models.ApiConfig{ToolPrefix: "broken"}},
+		{SpecUrl: "orders", ApiCfg: models.ApiConfig{ToolPrefix: "orders"}},
+	})
+	if loaded != 2 {
+		t.Errorf("expected 2 specs loaded, got %d", loaded)
+	}
+	names := listToolNames(t, mcpServer)
+	want := []string{"orders_get_/orders", "users_get_/users"}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Errorf("expected tools %v, got %v", want, names)
+	}
+}
+
+func TestLoadSpecs_BodyWithoutSchema(t *testing.T) {
+	origLoader := specLoader
+	defer func() { specLoader = origLoader }()
+	specLoader = func(string) (models.SwaggerSpec, error) {
+		return models.SwaggerSpec{Paths: map[string]map[string]models.Endpoint{
+			"/items": {"post": {Parameters: []models.Parameter{{Name: "item", In: "body"}}}},
+		}}, nil
+	}
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	if loaded := LoadSpecs(mcpServer, []models.SpecConfig{{SpecUrl: "items"}}); loaded != 1 {
+		t.Errorf("expected spec with schemaless body to load, got %d", loaded)
+	}
+}
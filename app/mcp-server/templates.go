@@ -0,0 +1,304 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// templateScheme is the URI scheme of the resource templates generated from GET operations.
+const templateScheme = "api://"
+
+// templateVarChars matches the characters that are not allowed in a URI template variable name.
+var templateVarChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// BuildSwaggerResourceTemplates builds resource templates for the GET operations selected with the resourceTemplates
+// setting or a resource override, without registering them. Reading a resource calls the API like the operation's tool.
+// Operations that need a request body or header parameters cannot be addressed by a URI and are skipped.
+func BuildSwaggerResourceTemplates(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig) []server.ServerResourceTemplate {
+	templates := []server.ServerResourceTemplate{}
+	for _, template := range buildSpecTemplates(swaggerSpec, apiCfg) {
+		templates = append(templates, template.ServerResourceTemplate)
+	}
+	return templates
+}
+
+// resourceTemplate is a resource template with the parameter each of its variables stands for.
+type resourceTemplate struct {
+	server.ServerResourceTemplate
+	params map[string]models.Parameter // template variable -> parameter
+}
+
+// buildSpecTemplates builds the resource templates of a spec (see BuildSwaggerResourceTemplates).
+func buildSpecTemplates(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig) []resourceTemplate {
+	selectors := []string{}
+	for _, selector := range strings.Split(apiCfg.ResourceTemplates, ",") {
+		if selector = strings.TrimSpace(selector); selector != "" {
+			selectors = append(selectors, selector)
+		}
+	}
+	if apiCfg.SessionApiKey.Name == "" {
+		apiCfg.SessionApiKey = specApiKeyLocation(swaggerSpec)
+	}
+	filter := newOperationFilter(apiCfg)
+	templates := []resourceTemplate{}
+
+	paths := make([]string, 0, len(swaggerSpec.Paths))
+	for path := range swaggerSpec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		for method, details := range swaggerSpec.Paths[path] {
+			if !strings.EqualFold(method, "get") || !filter.includePath(path) || !filter.includeMethod(method) {
+				continue
+			}
+			override := findOverride(apiCfg.Overrides, details.OperationID, method, path)
+			explicit := override.Resource || selectsOperation(selectors, details.OperationID, path, false)
+			if override.Disabled || !filter.includeOperation(method, override, details) || !explicit && !selectsOperation(selectors, details.OperationID, path, true) {
+				continue
+			}
+			template, err := buildResourceTemplate(swaggerSpec, apiCfg, override, path, details)
+			if err != nil {
+				if explicit {
+					log.Printf("Cannot serve GET %s as a resource template: %v", path, err)
+				}
+				continue
+			}
+			templates = append(templates, template)
+		}
+	}
+	return templates
+}
+
+// selectsOperation reports whether one of the selectors names the GET operation, by operationId or "GET /path".
+// The * selector only counts when wildcard is set.
+func selectsOperation(selectors []string, operationID, path string, wildcard bool) bool {
+	for _, selector := range selectors {
+		switch {
+		case selector == "*":
+			if wildcard {
+				return true
+			}
+		case operationID != "" && selector == operationID:
+			return true
+		case strings.EqualFold(selector, "GET "+path):
+			return true
+		}
+	}
+	return false
+}
+
+// buildResourceTemplate builds the resource template of a GET operation.
+func buildResourceTemplate(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig, override models.OperationOverride, path string, details models.Endpoint) (resourceTemplate, error) {
+	pathParams := []string{}
+	queryParams := []string{}
+	byName := map[string]models.Parameter{}
+	for _, param := range details.Parameters {
+		switch param.In {
+		case "path":
+			pathParams = append(pathParams, param.Name)
+		case "query":
+			queryParams = append(queryParams, param.Name)
+		case "body", "formData":
+			return resourceTemplate{}, fmt.Errorf("it has a request body")
+		case "header":
+			if param.Required {
+				return resourceTemplate{}, fmt.Errorf("it requires header %s", param.Name)
+			}
+		}
+		byName[param.Name] = param
+	}
+
+	uriTemplate, vars := templateURI(apiCfg.ToolPrefix, path, pathParams, queryParams)
+	reqURL := strings.TrimSuffix(specBaseURL(swaggerSpec, apiCfg), "/") + "/" + strings.TrimPrefix(path, "/")
+	name := details.OperationID
+	if name == "" {
+		name = buildToolName(apiCfg.ToolPrefix, "get", path)
+	}
+	description := details.Summary
+	if description == "" {
+		description = details.Description
+	}
+	description = strings.TrimSpace(fmt.Sprintf("%s (GET %s)", description, reqURL))
+	opts := []mcp.ResourceTemplateOption{mcp.WithTemplateDescription(description)}
+	if mimeType := responseMIMEType(details); mimeType != "" {
+		opts = append(opts, mcp.WithTemplateMIMEType(mimeType))
+	}
+	template, err := newResourceTemplate(uriTemplate, name, opts...)
+	if err != nil {
+		return resourceTemplate{}, err
+	}
+
+	opApiCfg := apiCfg
+	if len(override.Headers) > 0 {
+		opApiCfg.HeaderValues = mergeHeaders(apiCfg.HeaderValues, override.Headers)
+	}
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		args := map[string]interface{}{}
+		for v, param := range vars {
+			if values, ok := request.Params.Arguments[v].([]string); ok && len(values) > 0 && values[0] != "" {
+				args[param] = values[0]
+			}
+		}
+		// Optional query parameters left out of the URI are not sent
+		queries := []string{}
+		for _, param := range queryParams {
+			if _, ok := args[param]; ok || byName[param].Required {
+				queries = append(queries, param)
+			}
+		}
+		resp, err := callAPI(ctx, args, pathParams, queries, reqURL, nil, "get", nil, opApiCfg)
+		if err != nil {
+			return nil, err
+		}
+		if resp.status < 200 || resp.status >= 300 {
+			return nil, fmt.Errorf("API returned status %d: %s", resp.status, truncate(string(resp.body), 200))
+		}
+		return []mcp.ResourceContents{resourceContents(request.Params.URI, resp)}, nil
+	}
+	params := make(map[string]models.Parameter, len(vars))
+	for v, param := range vars {
+		params[v] = byName[param]
+	}
+	return resourceTemplate{ServerResourceTemplate: server.ServerResourceTemplate{Template: template, Handler: handler}, params: params}, nil
+}
+
+// newResourceTemplate creates a resource template, reporting an invalid URI template as an error.
+func newResourceTemplate(uriTemplate, name string, opts ...mcp.ResourceTemplateOption) (template mcp.ResourceTemplate, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid URI template %s: %v", uriTemplate, r)
+		}
+	}()
+	return mcp.NewResourceTemplate(uriTemplate, name, opts...), nil
+}
+
+// templateURI derives the URI template of a GET operation from its path, e.g. api://users/{id}{?limit} for
+// GET /users/{id} with query parameter limit, namespaced by the optional prefix. It also returns the parameter
+// each template variable stands for, as parameter names may contain characters variables cannot.
+func templateURI(prefix, path string, pathParams, queryParams []string) (string, map[string]string) {
+	vars := map[string]string{}
+	variable := func(param string) string {
+		v := templateVarChars.ReplaceAllString(param, "_")
+		for vars[v] != "" && vars[v] != param {
+			v += "_"
+		}
+		vars[v] = param
+		return v
+	}
+	uri := strings.TrimPrefix(path, "/")
+	for _, param := range pathParams {
+		uri = strings.Replace(uri, "{"+param+"}", "{"+variable(param)+"}", 1)
+	}
+	if prefix != "" {
+		uri = prefix + "/" + uri
+	}
+	if len(queryParams) > 0 {
+		names := make([]string, len(queryParams))
+		for i, param := range queryParams {
+			names[i] = variable(param)
+		}
+		uri += "{?" + strings.Join(names, ",") + "}"
+	}
+	return templateScheme + uri, vars
+}
+
+// responseMIMEType returns the media type of the successful response of an operation, if the spec declares one.
+func responseMIMEType(details models.Endpoint) string {
+	for _, status := range []string{"200", "2XX", "default"} {
+		if resp, ok := details.Responses[status]; ok && len(resp.Content) > 0 {
+			mediaTypes := make([]string, 0, len(resp.Content))
+			for mediaType := range resp.Content {
+				mediaTypes = append(mediaTypes, mediaType)
+			}
+			sort.Strings(mediaTypes)
+			return mediaTypes[0]
+		}
+	}
+	if len(details.Produces) > 0 {
+		return details.Produces[0]
+	}
+	return ""
+}
+
+// resourceContents returns an API response as resource contents with the MIME type of the response.
+// Text responses are returned as text, anything else base64 encoded.
+func resourceContents(uri string, resp apiResponse) mcp.ResourceContents {
+	mimeType := resp.contentType
+	if mediaType, _, err := mime.ParseMediaType(resp.contentType); err == nil {
+		mimeType = mediaType
+	}
+	if isTextMIMEType(mimeType) {
+		return mcp.TextResourceContents{URI: uri, MIMEType: mimeType, Text: string(resp.body)}
+	}
+	return mcp.BlobResourceContents{URI: uri, MIMEType: mimeType, Blob: base64.StdEncoding.EncodeToString(resp.body)}
+}
+
+func isTextMIMEType(mimeType string) bool {
+	if mimeType == "" || strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	for _, suffix := range []string{"json", "xml", "yaml", "javascript", "x-www-form-urlencoded"} {
+		if strings.HasSuffix(mimeType, "/"+suffix) || strings.HasSuffix(mimeType, "+"+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// templateSet holds the resource templates of every spec served by one MCP server. The server can only replace
+// all its templates at once, so when the templates of one spec change those of all specs are set again.
+type templateSet struct {
+	mcpServer *server.MCPServer
+
+	mu     sync.Mutex
+	bySpec map[*specReloader][]server.ServerResourceTemplate
+}
+
+func newTemplateSet(mcpServer *server.MCPServer) *templateSet {
+	return &templateSet{mcpServer: mcpServer, bySpec: map[*specReloader][]server.ServerResourceTemplate{}}
+}
+
+// set replaces the templates of a spec.
+func (t *templateSet) set(spec *specReloader, templates []server.ServerResourceTemplate) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.bySpec[spec] = templates
+	all := []server.ServerResourceTemplate{}
+	for _, specTemplates := range t.bySpec {
+		all = append(all, specTemplates...)
+	}
+	t.mcpServer.SetResourceTemplates(all...)
+}
+
+// encodeTemplates returns the JSON encoding of a list of templates, to detect changes.
+func encodeTemplates(templates []server.ServerResourceTemplate) (string, error) {
+	list := make([]mcp.ResourceTemplate, len(templates))
+	for i, template := range templates {
+		list[i] = template.Template
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return "", fmt.Errorf("error encoding resource templates: %v", err)
+	}
+	return string(data), nil
+}
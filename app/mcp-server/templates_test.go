@@ -0,0 +1,171 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const templatesSpec = `{
+  "openapi": "3.0.0",
+  "paths": {
+    "/users/{user-id}": {"get": {
+      "operationId": "getUser",
+      "summary": "Get a user",
+      "parameters": [
+        {"name": "user-id", "in": "path", "required": true},
+        {"name": "fields", "in": "query"}
+      ],
+      "responses": {"200": {"description": "The user", "content": {"application/json": {}}}}
+    }},
+    "/users": {
+      "get": {"operationId": "listUsers", "responses": {"200": {"description": "Users"}}},
+      "post": {"operationId": "createUser", "responses": {"201": {"description": "Created"}}}
+    },
+    "/reports/{id}": {"get": {
+      "operationId": "getReport",
+      "parameters": [{"name": "id", "in": "path", "required": true}, {"name": "X-Tenant", "in": "header", "required": true}],
+      "responses": {"200": {"description": "Report", "content": {"application/pdf": {}}}}
+    }}
+  }
+}`
+
+func templateNames(templates []server.ServerResourceTemplate) string {
+	names := []string{}
+	for _, template := range templates {
+		names = append(names, template.Template.Name+"="+template.Template.URITemplate.Raw())
+	}
+	return strings.Join(names, " ")
+}
+
+func TestTemplateURI(t *testing.T) {
+	uri, vars := templateURI("crm", "/users/{user-id}/orders/{id}", []string{"user-id", "id"}, []string{"page.size", "user_id"})
+	if uri != "api://crm/users/{user_id}/orders/{id}{?page_size,user_id_}" {
+		t.Errorf("unexpected URI template %s", uri)
+	}
+	want := map[string]string{"user_id": "user-id", "id": "id", "page_size": "page.size", "user_id_": "user_id"}
+	if fmt.Sprint(vars) != fmt.Sprint(want) {
+		t.Errorf("got variables %v, want %v", vars, want)
+	}
+}
+
+func TestBuildSwaggerResourceTemplates_Selection(t *testing.T) {
+	spec := parseTestSpec(t, templatesSpec)
+	if templates := BuildSwaggerResourceTemplates(spec, models.ApiConfig{}); len(templates) != 0 {
+		t.Errorf("expected no templates unless enabled, got %s", templateNames(templates))
+	}
+
+	// * selects every GET operation that can be addressed by a URI
+	got := templateNames(BuildSwaggerResourceTemplates(spec, models.ApiConfig{ResourceTemplates: "*"}))
+	if got != "listUsers=api://users getUser=api://users/{user_id}{?fields}" {
+		t.Errorf("unexpected templates %s", got)
+	}
+
+	apiCfg := models.ApiConfig{
+		ToolPrefix:        "crm",
+		ResourceTemplates: "GET /users/{user-id},createUser",
+		Overrides:         map[string]models.OperationOverride{"listUsers": {Resource: true}},
+	}
+	got = templateNames(BuildSwaggerResourceTemplates(spec, apiCfg))
+	if got != "listUsers=api://crm/users getUser=api://crm/users/{user_id}{?fields}" {
+		t.Errorf("unexpected templates %s", got)
+	}
+
+	apiCfg = models.ApiConfig{ResourceTemplates: "*", Overrides: map[string]models.OperationOverride{"getUser": {Disabled: true}}}
+	if got = templateNames(BuildSwaggerResourceTemplates(spec, apiCfg)); got != "listUsers=api://users" {
+		t.Errorf("expected disabled operation to be skipped, got %s", got)
+	}
+}
+
+func TestResourceTemplate_Read(t *testing.T) {
+	var gotURL string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL.String()
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/missing") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(`{"id":"42"}`))
+	}))
+	defer api.Close()
+
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	LoadSwaggerServer(mcpServer, parseTestSpec(t, templatesSpec), models.ApiConfig{
+		BaseUrl: api.URL, Security: "bearer", BearerAuth: "secret", ResourceTemplates: "getUser",
+	})
+
+	read := func(uri string) map[string]interface{} {
+		resp := mcpServer.HandleMessage(context.Background(), json.RawMessage(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":%q}}`, uri)))
+		data, _ := json.Marshal(resp)
+		decoded := map[string]interface{}{}
+		json.Unmarshal(data, &decoded)
+		return decoded
+	}
+
+	decoded := read("api://users/42?fields=name")
+	if gotURL != "/users/42?fields=name" {
+		t.Errorf("unexpected API request %s", gotURL)
+	}
+	result, _ := decoded["result"].(map[string]interface{})
+	contents, _ := result["contents"].([]interface{})
+	if len(contents) != 1 {
+		t.Fatalf("unexpected response %v", decoded)
+	}
+	content := contents[0].(map[string]interface{})
+	if content["uri"] != "api://users/42?fields=name" || content["mimeType"] != "application/json" || content["text"] != `{"id":"42"}` {
+		t.Errorf("unexpected contents %v", content)
+	}
+
+	// Optional query parameters left out are not sent
+	read("api://users/42")
+	if gotURL != "/users/42" {
+		t.Errorf("unexpected API request %s", gotURL)
+	}
+
+	if decoded = read("api://users/missing"); decoded["error"] == nil {
+		t.Errorf("expected an error for a 404 response, got %v", decoded)
+	}
+}
+
+func TestResourceContents(t *testing.T) {
+	if text, ok := resourceContents("api://a", apiResponse{contentType: "text/csv", body: []byte("a,b")}).(mcp.TextResourceContents); !ok || text.Text != "a,b" || text.MIMEType != "text/csv" {
+		t.Errorf("expected text contents, got %+v", text)
+	}
+	if blob, ok := resourceContents("api://a", apiResponse{contentType: "application/pdf", body: []byte("%PDF")}).(mcp.BlobResourceContents); !ok || blob.Blob != "JVBERg==" {
+		t.Errorf("expected base64 contents, got %+v", blob)
+	}
+}
+
+func TestLoadSpecs_SharesResourceTemplates(t *testing.T) {
+	defer func(prev func(string) (models.SwaggerSpec, error)) { specLoader = prev }(specLoader)
+	specLoader = func(string) (models.SwaggerSpec, error) {
+		var spec models.SwaggerSpec
+		err := json.Unmarshal([]byte(templatesSpec), &spec)
+		return spec, err
+	}
+	mcpServer := server.NewMCPServer("test", "1.0.0")
+	loadSpecs(mcpServer, newCompletionSet(), nil, nil, []models.SpecConfig{
+		{SpecUrl: "a.json", ApiCfg: models.ApiConfig{ToolPrefix: "a", ResourceTemplates: "listUsers"}},
+		{SpecUrl: "b.json", ApiCfg: models.ApiConfig{ToolPrefix: "b", ResourceTemplates: "listUsers"}},
+	})
+	resp := mcpServer.HandleMessage(context.Background(), json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"resources/templates/list"}`))
+	data, _ := json.Marshal(resp)
+	for _, uri := range []string{"api://a/users", "api://b/users"} {
+		if !strings.Contains(string(data), `"`+uri+`"`) {
+			t.Errorf("expected template %s to be listed, got %s", uri, data)
+		}
+	}
+}
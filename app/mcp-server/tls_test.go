@@ -0,0 +1,160 @@
+package mcpserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danishjsheikh/swagger-mcp/app/auth"
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+)
+
+// writeCert creates a certificate for cn signed by parent (self-signed if nil) and writes it and its key as PEM files.
+func writeCert(t *testing.T, dir, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (certFile, keyFile string, cert *x509.Certificate, key *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ = x509.ParseCertificate(der)
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+	certFile, keyFile = filepath.Join(dir, cn+".crt"), filepath.Join(dir, cn+".key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600)
+	return certFile, keyFile, cert, key
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, first, _ := writeCert(t, dir, "server", nil, nil)
+	r, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := r.getCertificate(nil)
+	if got.Leaf == nil || !got.Leaf.Equal(first) {
+		t.Fatal("expected the initial certificate")
+	}
+
+	// A renewed certificate is picked up on the next handshake
+	renewedDir := t.TempDir()
+	renewedCert, renewedKey, second, _ := writeCert(t, renewedDir, "server", nil, nil)
+	for src, dst := range map[string]string{renewedCert: certFile, renewedKey: keyFile} {
+		data, _ := os.ReadFile(src)
+		os.WriteFile(dst, data, 0o600)
+		future := time.Now().Add(time.Minute)
+		os.Chtimes(dst, future, future)
+	}
+	if got, _ = r.getCertificate(nil); !got.Leaf.Equal(second) {
+		t.Error("expected the renewed certificate")
+	}
+
+	// A broken file keeps the current certificate
+	os.WriteFile(certFile, []byte("not a certificate"), 0o600)
+	later := time.Now().Add(2 * time.Minute)
+	os.Chtimes(certFile, later, later)
+	if got, err = r.getCertificate(nil); err != nil || !got.Leaf.Equal(second) {
+		t.Errorf("expected to keep the current certificate, got %v", err)
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	if cfg, err := newTLSConfig(models.TLSConfig{}); cfg != nil || err != nil {
+		t.Errorf("expected no TLS without a certificate, got %v, %v", cfg, err)
+	}
+	if _, err := newTLSConfig(models.TLSConfig{CertFile: "cert.pem"}); err == nil {
+		t.Error("expected error without key")
+	}
+	if _, err := newTLSConfig(models.TLSConfig{CertFile: "missing.crt", KeyFile: "missing.key"}); err == nil {
+		t.Error("expected error for missing files")
+	}
+}
+
+func TestTLS_ClientCertIdentity(t *testing.T) {
+	dir := t.TempDir()
+	caFile, _, ca, caKey := writeCert(t, dir, "ca", nil, nil)
+	serverCert, serverKey, _, _ := writeCert(t, dir, "server", ca, caKey)
+	clientCert, clientKey, _, _ := writeCert(t, dir, "build-agent", ca, caKey)
+
+	tlsConfig, err := newTLSConfig(models.TLSConfig{CertFile: serverCert, KeyFile: serverKey, ClientCAFile: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticator, _ := auth.New(models.InboundAuthConfig{ClientCerts: true})
+	// httptest.Server.StartTLS would add its own certificate, so serve with the config as the listener does
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{TLSConfig: tlsConfig, Handler: authenticator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := auth.FromContext(r.Context())
+		w.Write([]byte(id.Subject))
+	}))}
+	go srv.ServeTLS(listener, "", "")
+	defer srv.Close()
+	url := "https://" + listener.Addr().String()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	client := func(withCert bool) *http.Client {
+		cfg := &tls.Config{RootCAs: roots}
+		if withCert {
+			pair, err := tls.LoadX509KeyPair(clientCert, clientKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg.Certificates = []tls.Certificate{pair}
+		}
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+	}
+
+	resp, err := client(true).Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "build-agent" {
+		t.Errorf("expected client certificate identity, got %d %q", resp.StatusCode, body)
+	}
+
+	resp, err = client(false).Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without client certificate, got %d", resp.StatusCode)
+	}
+}
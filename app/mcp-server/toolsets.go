@@ -0,0 +1,347 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ToolModeToolsets registers the tools of the core toolsets only; sessions enable the tools of
+// other tags on demand with enable_toolset.
+const ToolModeToolsets = "toolsets"
+
+// untaggedToolset is the toolset of the operations without tags.
+const untaggedToolset = "untagged"
+
+// toolset is the group of tools generated from the operations of one tag.
+type toolset struct {
+	description string
+	tools       []server.ServerTool
+}
+
+// toolsetSet holds the toolsets of every spec of a server in toolsets mode and the toolsets each session enabled.
+// Tools of core toolsets are registered for every session, the others as session tools.
+type toolsetSet struct {
+	mcpServer *server.MCPServer
+	core      map[string]bool // names of the core toolsets
+
+	mu      sync.Mutex
+	bySpec  map[*specReloader]map[string]toolset
+	enabled map[string]map[string]bool // session id -> names of the toolsets it enabled
+}
+
+// newToolsetSet registers the toolset tools and returns the set behind them when the tool mode is toolsets.
+// The toolsets a session enabled are forgotten when it ends. In other modes it returns nil.
+func newToolsetSet(mcpServer *server.MCPServer, sessions *sessionStore, toolMode string, core []string) *toolsetSet {
+	if toolMode != ToolModeToolsets {
+		return nil
+	}
+	t := &toolsetSet{
+		mcpServer: mcpServer,
+		core:      map[string]bool{},
+		bySpec:    map[*specReloader]map[string]toolset{},
+		enabled:   map[string]map[string]bool{},
+	}
+	for _, name := range core {
+		t.core[toolsetName("", name)] = true
+	}
+	mcpServer.AddTools(t.toolsetTools()...)
+	sessions.onDelete(t.forget)
+	return t
+}
+
+// forget drops the toolsets a session enabled.
+func (t *toolsetSet) forget(sessionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.enabled, sessionID)
+}
+
+// toolsetName returns the name of the toolset of a tag, prefixed like tool names.
+func toolsetName(prefix, tag string) string {
+	return buildOverrideToolName(prefix, strings.Join(strings.Fields(tag), "_"))
+}
+
+// buildToolsets groups the tools of a spec by the tags of their operations. An operation with several
+// tags is in several toolsets, one without tags is in the untagged toolset.
+func buildToolsets(swaggerSpec models.SwaggerSpec, apiCfg models.ApiConfig, tools []server.ServerTool) map[string]toolset {
+	_, doc, err := specDocument(swaggerSpec)
+	if err != nil {
+		return nil
+	}
+	descriptions := map[string]string{}
+	tagList, _ := doc["tags"].([]interface{})
+	for _, entry := range tagList {
+		tag, _ := entry.(map[string]interface{})
+		name, _ := tag["name"].(string)
+		description, _ := tag["description"].(string)
+		descriptions[toolsetName(apiCfg.ToolPrefix, name)] = description
+	}
+	byName := make(map[string]server.ServerTool, len(tools))
+	for _, tool := range tools {
+		byName[tool.Tool.Name] = tool
+	}
+
+	toolsets := map[string]toolset{}
+	for _, op := range specOperations(doc, apiCfg) {
+		tool, ok := byName[op.toolName]
+		if !ok {
+			continue
+		}
+		tags := swaggerSpec.Paths[op.path][op.method].Tags
+		if len(tags) == 0 {
+			tags = []string{untaggedToolset}
+		}
+		for _, tag := range tags {
+			name := toolsetName(apiCfg.ToolPrefix, tag)
+			set := toolsets[name]
+			set.description = descriptions[name]
+			set.tools = append(set.tools, tool)
+			toolsets[name] = set
+		}
+	}
+	return toolsets
+}
+
+// set replaces the toolsets of a spec and returns the tools of its core toolsets, which are registered for
+// every session. The tools of sessions that enabled a toolset of the spec are updated.
+func (t *toolsetSet) set(spec *specReloader, toolsets map[string]toolset) []server.ServerTool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	before := map[string]map[string]server.ServerTool{}
+	for sessionID := range t.enabled {
+		before[sessionID] = t.sessionTools(sessionID)
+	}
+	t.bySpec[spec] = toolsets
+	for sessionID := range t.enabled {
+		if err := t.syncSession(sessionID, before[sessionID]); errors.Is(err, server.ErrSessionNotFound) {
+			delete(t.enabled, sessionID)
+		} else if err != nil {
+			log.Printf("Error updating the tools of session %s: %v", sessionID, err)
+		}
+	}
+
+	core := []server.ServerTool{}
+	seen := map[string]bool{}
+	for name, set := range toolsets {
+		if !t.core[name] {
+			continue
+		}
+		for _, tool := range set.tools {
+			if !seen[tool.Tool.Name] {
+				seen[tool.Tool.Name] = true
+				core = append(core, tool)
+			}
+		}
+	}
+	return core
+}
+
+// find returns the toolset with the given name. It must be called with t.mu held.
+func (t *toolsetSet) find(name string) (toolset, bool) {
+	for _, toolsets := range t.bySpec {
+		if set, ok := toolsets[name]; ok {
+			return set, true
+		}
+	}
+	return toolset{}, false
+}
+
+// sessionTools returns the tools of the toolsets a session enabled, by name, leaving out the tools of
+// the core toolsets that every session has. It must be called with t.mu held.
+func (t *toolsetSet) sessionTools(sessionID string) map[string]server.ServerTool {
+	core := map[string]bool{}
+	for _, toolsets := range t.bySpec {
+		for name, set := range toolsets {
+			for _, tool := range set.tools {
+				core[tool.Tool.Name] = core[tool.Tool.Name] || t.core[name]
+			}
+		}
+	}
+	tools := map[string]server.ServerTool{}
+	for name := range t.enabled[sessionID] {
+		set, _ := t.find(name)
+		for _, tool := range set.tools {
+			if !core[tool.Tool.Name] {
+				tools[tool.Tool.Name] = tool
+			}
+		}
+	}
+	return tools
+}
+
+// syncSession registers the new and modified tools of the toolsets a session enabled and removes the ones it had
+// before and no longer has. The client is sent tools/list_changed if any changed. Sessions that cannot have their
+// own tools (stdio, which has a single client) get the tools registered for the whole server.
+// It must be called with t.mu held.
+func (t *toolsetSet) syncSession(sessionID string, before map[string]server.ServerTool) error {
+	after := t.sessionTools(sessionID)
+	tools := []server.ServerTool{}
+	for name, tool := range after {
+		previous, existed := before[name]
+		if existed {
+			oldData, _ := json.Marshal(previous.Tool)
+			newData, _ := json.Marshal(tool.Tool)
+			if string(oldData) == string(newData) {
+				continue
+			}
+		}
+		tools = append(tools, tool)
+	}
+	removed := []string{}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+
+	if len(removed) > 0 {
+		err := t.mcpServer.DeleteSessionTools(sessionID, removed...)
+		if errors.Is(err, server.ErrSessionDoesNotSupportTools) {
+			t.mcpServer.DeleteTools(removed...)
+		} else if err != nil {
+			return err
+		}
+	}
+	if len(tools) > 0 {
+		err := t.mcpServer.AddSessionTools(sessionID, tools...)
+		if errors.Is(err, server.ErrSessionDoesNotSupportTools) {
+			t.mcpServer.AddTools(tools...)
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toolsetTools returns the tools listing, enabling and disabling toolsets.
+func (t *toolsetSet) toolsetTools() []server.ServerTool {
+	return []server.ServerTool{
+		{
+			Tool: mcp.NewTool("list_toolsets",
+				mcp.WithDescription("List the toolsets of the API: groups of tools for one area of the API that can be enabled with enable_toolset when you need them."),
+			),
+			Handler: t.list,
+		},
+		{
+			Tool: mcp.NewTool("enable_toolset",
+				mcp.WithDescription("Enable the tools of a toolset listed by list_toolsets. The new tools are available once the tool list is refreshed."),
+				mcp.WithString("name", mcp.Required(), mcp.Description("Name of the toolset")),
+			),
+			Handler: t.enable,
+		},
+		{
+			Tool: mcp.NewTool("disable_toolset",
+				mcp.WithDescription("Disable the tools of a toolset enabled with enable_toolset, when you no longer need them."),
+				mcp.WithString("name", mcp.Required(), mcp.Description("Name of the toolset")),
+			),
+			Handler: t.disable,
+		},
+	}
+}
+
+// list handles list_toolsets.
+func (t *toolsetSet) list(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	sessionID := ""
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		sessionID = session.SessionID()
+	}
+	t.mu.Lock()
+	names := []string{}
+	all := map[string]toolset{}
+	for _, toolsets := range t.bySpec {
+		for name, set := range toolsets {
+			names = append(names, name)
+			all[name] = set
+		}
+	}
+	sort.Strings(names)
+	results := []map[string]interface{}{}
+	for _, name := range names {
+		result := map[string]interface{}{"name": name, "tools": len(all[name].tools)}
+		if all[name].description != "" {
+			result["description"] = all[name].description
+		}
+		switch {
+		case t.core[name]:
+			result["status"] = "core"
+		case t.enabled[sessionID][name]:
+			result["status"] = "enabled"
+		default:
+			result["status"] = "disabled"
+		}
+		results = append(results, result)
+	}
+	t.mu.Unlock()
+
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("[Error] %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(data)), nil
+}
+
+// enable handles enable_toolset.
+func (t *toolsetSet) enable(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return t.toggle(ctx, request.GetString("name", ""), true)
+}
+
+// disable handles disable_toolset.
+func (t *toolsetSet) disable(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return t.toggle(ctx, request.GetString("name", ""), false)
+}
+
+// toggle enables or disables a toolset for the calling session.
+func (t *toolsetSet) toggle(ctx context.Context, name string, enable bool) (*mcp.CallToolResult, error) {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return mcp.NewToolResultError("[Error] toolsets can only be changed within a client session"), nil
+	}
+	sessionID := session.SessionID()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	set, ok := t.find(name)
+	switch {
+	case !ok:
+		return mcp.NewToolResultError(fmt.Sprintf("[Error] unknown toolset %q, use list_toolsets to find it", name)), nil
+	case t.core[name]:
+		return mcp.NewToolResultText(fmt.Sprintf("Toolset %s is a core toolset and always enabled", name)), nil
+	case enable && t.enabled[sessionID][name]:
+		return mcp.NewToolResultText(fmt.Sprintf("Toolset %s is already enabled", name)), nil
+	case !enable && !t.enabled[sessionID][name]:
+		return mcp.NewToolResultText(fmt.Sprintf("Toolset %s is not enabled", name)), nil
+	}
+
+	before := t.sessionTools(sessionID)
+	if enable {
+		if t.enabled[sessionID] == nil {
+			t.enabled[sessionID] = map[string]bool{}
+		}
+		t.enabled[sessionID][name] = true
+	} else {
+		delete(t.enabled[sessionID], name)
+	}
+	if err := t.syncSession(sessionID, before); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("[Error] %v", err)), nil
+	}
+
+	names := make([]string, len(set.tools))
+	for i, tool := range set.tools {
+		names[i] = tool.Tool.Name
+	}
+	sort.Strings(names)
+	if enable {
+		return mcp.NewToolResultText(fmt.Sprintf("Enabled toolset %s with tools: %s", name, strings.Join(names, ", "))), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Disabled toolset %s", name)), nil
+}
@@ -0,0 +1,242 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const toolsetsSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Shop", "version": "1.0.0"},
+  "tags": [{"name": "orders", "description": "Orders of the shop"}, {"name": "billing"}],
+  "paths": {
+    "/orders": {
+      "get": {"operationId": "listOrders", "tags": ["orders"], "responses": {"200": {"description": "Orders"}}}
+    },
+    "/invoices": {
+      "get": {"operationId": "listInvoices", "tags": ["billing", "orders"], "responses": {"200": {"description": "Invoices"}}}
+    },
+    "/health": {
+      "get": {"operationId": "health", "responses": {"200": {"description": "OK"}}}
+    }
+  }
+}`
+
+// toolSession is a client session that can have its own tools, like the SSE and streamable HTTP sessions.
+type toolSession struct {
+	*testSession
+	mu    sync.Mutex
+	tools map[string]server.ServerTool
+}
+
+func (s *toolSession) GetSessionTools() map[string]server.ServerTool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tools
+}
+
+func (s *toolSession) SetSessionTools(tools map[string]server.ServerTool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tools = tools
+}
+
+func newToolsetsServer(t *testing.T, core ...string) (*server.MCPServer, *specReloader) {
+	t.Helper()
+	sessions := newSessionStore(models.Config{})
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true), server.WithHooks(sessions.hooks()))
+	r := newSpecReloader(mcpServer, models.SpecConfig{})
+	r.toolsets = newToolsetSet(mcpServer, sessions, ToolModeToolsets, core)
+	if _, err := r.apply(parseTestSpec(t, toolsetsSpec)); err != nil {
+		t.Fatal(err)
+	}
+	return mcpServer, r
+}
+
+func registerToolSession(t *testing.T, mcpServer *server.MCPServer, id string) (*toolSession, context.Context) {
+	t.Helper()
+	session := &toolSession{testSession: newTestSession(id)}
+	if err := mcpServer.RegisterSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	return session, mcpServer.WithContext(context.Background(), session)
+}
+
+func sessionCall(t *testing.T, mcpServer *server.MCPServer, ctx context.Context, method string, params interface{}) json.RawMessage {
+	t.Helper()
+	data, _ := json.Marshal(params)
+	resp := mcpServer.HandleMessage(ctx, json.RawMessage(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":%q,"params":%s}`, method, data)))
+	data, _ = json.Marshal(resp)
+	var decoded struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Result == nil {
+		t.Fatalf("unexpected response %s", data)
+	}
+	return decoded.Result
+}
+
+func sessionToolNames(t *testing.T, mcpServer *server.MCPServer, ctx context.Context) string {
+	t.Helper()
+	var result mcp.ListToolsResult
+	json.Unmarshal(sessionCall(t, mcpServer, ctx, "tools/list", map[string]interface{}{}), &result)
+	names := []string{}
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+	}
+	sort.Strings(names)
+	return fmt.Sprint(names)
+}
+
+func callToolsetTool(t *testing.T, mcpServer *server.MCPServer, ctx context.Context, name string, args map[string]interface{}) (string, bool) {
+	t.Helper()
+	var result struct {
+		Content []mcp.TextContent `json:"content"`
+		IsError bool              `json:"isError"`
+	}
+	json.Unmarshal(sessionCall(t, mcpServer, ctx, "tools/call", map[string]interface{}{"name": name, "arguments": args}), &result)
+	if len(result.Content) == 0 {
+		t.Fatalf("expected content in the result of %s", name)
+	}
+	return result.Content[0].Text, result.IsError
+}
+
+// listChanged counts the tools/list_changed notifications sent to a session.
+func listChanged(session *toolSession) int {
+	count := 0
+	for {
+		select {
+		case notification := <-session.notifications:
+			if notification.Method == "notifications/tools/list_changed" {
+				count++
+			}
+		default:
+			return count
+		}
+	}
+}
+
+func TestToolsets_EnableDisable(t *testing.T) {
+	mcpServer, r := newToolsetsServer(t, "orders")
+	alice, aliceCtx := registerToolSession(t, mcpServer, "alice")
+	bob, bobCtx := registerToolSession(t, mcpServer, "bob")
+
+	const core = "[disable_toolset enable_toolset get_/invoices get_/orders list_toolsets]"
+	if got := sessionToolNames(t, mcpServer, aliceCtx); got != core {
+		t.Errorf("expected the core toolset and toolset tools, got %s", got)
+	}
+
+	if text, isError := callToolsetTool(t, mcpServer, aliceCtx, "enable_toolset", map[string]interface{}{"name": "untagged"}); isError || text != "Enabled toolset untagged with tools: get_/health" {
+		t.Errorf("unexpected result %q", text)
+	}
+	if got := sessionToolNames(t, mcpServer, aliceCtx); got != "[disable_toolset enable_toolset get_/health get_/invoices get_/orders list_toolsets]" {
+		t.Errorf("expected the enabled toolset tools, got %s", got)
+	}
+	if got := sessionToolNames(t, mcpServer, bobCtx); got != core {
+		t.Errorf("expected other sessions not to have the enabled tools, got %s", got)
+	}
+	if listChanged(alice) != 1 || listChanged(bob) != 0 {
+		t.Error("expected tools/list_changed to be sent to the enabling session only")
+	}
+
+	// billing only has a tool of the core toolset, so enabling it changes nothing
+	if _, isError := callToolsetTool(t, mcpServer, aliceCtx, "enable_toolset", map[string]interface{}{"name": "billing"}); isError || listChanged(alice) != 0 {
+		t.Error("expected enabling a toolset of core tools not to change the tool list")
+	}
+
+	text, _ := callToolsetTool(t, mcpServer, aliceCtx, "list_toolsets", nil)
+	var toolsets []struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Tools       int    `json:"tools"`
+		Status      string `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(text), &toolsets); err != nil {
+		t.Fatalf("unexpected list %s", text)
+	}
+	if got := fmt.Sprint(toolsets); got != "[{billing  1 enabled} {orders Orders of the shop 2 core} {untagged  1 enabled}]" {
+		t.Errorf("unexpected toolsets %s", got)
+	}
+
+	if text, isError := callToolsetTool(t, mcpServer, aliceCtx, "disable_toolset", map[string]interface{}{"name": "untagged"}); isError || text != "Disabled toolset untagged" {
+		t.Errorf("unexpected result %q", text)
+	}
+	if got := sessionToolNames(t, mcpServer, aliceCtx); got != core || listChanged(alice) != 1 {
+		t.Errorf("expected the disabled toolset tools to be removed, got %s", got)
+	}
+
+	tests := []struct {
+		tool string
+		name string
+		want string
+	}{
+		{"enable_toolset", "shipping", "unknown toolset"},
+		{"enable_toolset", "orders", "core toolset"},
+		{"disable_toolset", "untagged", "not enabled"},
+	}
+	for _, tt := range tests {
+		if text, _ := callToolsetTool(t, mcpServer, bobCtx, tt.tool, map[string]interface{}{"name": tt.name}); !strings.Contains(text, tt.want) {
+			t.Errorf("%s(%s): expected %q, got %q", tt.tool, tt.name, tt.want, text)
+		}
+	}
+
+	// the toolsets of a session are forgotten when it ends
+	callToolsetTool(t, mcpServer, bobCtx, "enable_toolset", map[string]interface{}{"name": "untagged"})
+	mcpServer.UnregisterSession(context.Background(), "bob")
+	r.toolsets.mu.Lock()
+	defer r.toolsets.mu.Unlock()
+	if _, ok := r.toolsets.enabled["bob"]; ok || len(r.toolsets.enabled) != 1 {
+		t.Errorf("expected the toolsets of bob to be forgotten, got %v", r.toolsets.enabled)
+	}
+}
+
+func TestToolsets_Reload(t *testing.T) {
+	mcpServer, r := newToolsetsServer(t)
+	alice, aliceCtx := registerToolSession(t, mcpServer, "alice")
+	callToolsetTool(t, mcpServer, aliceCtx, "enable_toolset", map[string]interface{}{"name": "orders"})
+	listChanged(alice)
+
+	// listOrders moves to billing
+	spec := strings.Replace(toolsetsSpec, `"operationId": "listOrders", "tags": ["orders"]`, `"operationId": "listOrders", "tags": ["billing"]`, 1)
+	if _, err := r.apply(parseTestSpec(t, spec)); err != nil {
+		t.Fatal(err)
+	}
+	if got := sessionToolNames(t, mcpServer, aliceCtx); got != "[disable_toolset enable_toolset get_/invoices list_toolsets]" {
+		t.Errorf("expected the reloaded toolset tools, got %s", got)
+	}
+	if listChanged(alice) != 1 {
+		t.Error("expected tools/list_changed after the toolset changed")
+	}
+
+	// reloading an unchanged spec leaves the session tools alone
+	if _, err := r.apply(parseTestSpec(t, spec)); err != nil {
+		t.Fatal(err)
+	}
+	if listChanged(alice) != 0 {
+		t.Error("expected no tools/list_changed for an unchanged spec")
+	}
+}
+
+func TestToolsets_SessionWithoutTools(t *testing.T) {
+	mcpServer, _ := newToolsetsServer(t)
+	session := newTestSession("stdio")
+	if err := mcpServer.RegisterSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	ctx := mcpServer.WithContext(context.Background(), session)
+	if _, isError := callToolsetTool(t, mcpServer, ctx, "enable_toolset", map[string]interface{}{"name": "billing"}); isError {
+		t.Fatal("expected the toolset to be enabled")
+	}
+	if got := sessionToolNames(t, mcpServer, context.Background()); !strings.Contains(got, "get_/invoices") {
+		t.Errorf("expected the tools to be registered for the server, got %s", got)
+	}
+}
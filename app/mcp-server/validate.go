@@ -0,0 +1,525 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxRefDepth bounds the $ref chains followed when resolving a schema, so that cyclic specs cannot loop.
+const maxRefDepth = 32
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// argumentSchema is the schema of a tool argument: a header, query or path parameter, or a property of the body.
+type argumentSchema struct {
+	in       string
+	required bool
+	schema   map[string]interface{}
+}
+
+// argumentSchemas are the schemas the arguments of an operation's tool are validated against, with the spec
+// document their $refs point into.
+type argumentSchemas struct {
+	validator schemaValidator
+	arguments map[string]argumentSchema
+}
+
+// operationArgumentSchemas returns the schemas of the arguments of the tool of an operation of a spec document:
+// the schema of every parameter (the parameter itself in Swagger 2.0) and of every property of a body parameter.
+func operationArgumentSchemas(doc map[string]interface{}, path, method string) argumentSchemas {
+	validator := schemaValidator{doc: doc}
+	schemas := argumentSchemas{validator: validator, arguments: map[string]argumentSchema{}}
+	paths, _ := doc["paths"].(map[string]interface{})
+	item, _ := paths[path].(map[string]interface{})
+	details, _ := item[method].(map[string]interface{})
+	params, _ := details["parameters"].([]interface{})
+	for _, p := range params {
+		param, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		param = validator.resolve(param)
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		schema, hasSchema := param["schema"].(map[string]interface{})
+		if in == "body" {
+			if !hasSchema {
+				continue
+			}
+			body := validator.resolve(schema)
+			properties, _ := body["properties"].(map[string]interface{})
+			for propName, prop := range properties {
+				propSchema, _ := prop.(map[string]interface{})
+				schemas.arguments[propName] = argumentSchema{in: in, required: listContains(body["required"], propName), schema: propSchema}
+			}
+			continue
+		}
+		if !hasSchema {
+			schema = param
+		}
+		required, _ := param["required"].(bool)
+		schemas.arguments[name] = argumentSchema{in: in, required: required, schema: schema}
+	}
+	return schemas
+}
+
+// validate checks the arguments of a tool call and returns the problems found, as "field: problem", in the order of
+// the argument names. Arguments given as strings are converted to the type of their schema first, like the request does.
+func (a argumentSchemas) validate(args map[string]interface{}) []string {
+	names := make([]string, 0, len(a.arguments))
+	for name := range a.arguments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	problems := []string{}
+	for _, name := range names {
+		argument := a.arguments[name]
+		value, ok := args[name]
+		if !ok {
+			if argument.required {
+				problems = append(problems, name+": is required")
+			}
+			continue
+		}
+		value, problem := a.validator.coerce(argument, value)
+		if problem != "" {
+			problems = append(problems, name+": "+problem)
+			continue
+		}
+		problems = append(problems, a.validator.validate(argument.schema, value, name)...)
+	}
+	return problems
+}
+
+// validateToolHandler wraps the handler of an operation so that the arguments of a call are validated against the
+// schemas of the operation first. Invalid calls get the problems back and are never sent, confirmed or dry-run.
+func validateToolHandler(schemas argumentSchemas, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if problems := schemas.validate(request.GetArguments()); len(problems) > 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("[Error] invalid arguments, the request was not sent:\n- %s", strings.Join(problems, "\n- "))), nil
+		}
+		return handler(ctx, request)
+	}
+}
+
+// schemaValidator validates values against the JSON Schemas of a spec document.
+type schemaValidator struct {
+	doc map[string]interface{}
+}
+
+// resolve follows the local $ref of a schema or parameter, e.g. #/definitions/Order.
+func (v schemaValidator) resolve(schema map[string]interface{}) map[string]interface{} {
+	for depth := 0; depth < maxRefDepth; depth++ {
+		ref, ok := schema["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return schema
+		}
+		var node interface{} = v.doc
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			part = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+			parent, _ := node.(map[string]interface{})
+			node = parent[part]
+		}
+		target, ok := node.(map[string]interface{})
+		if !ok {
+			return map[string]interface{}{}
+		}
+		schema = target
+	}
+	return schema
+}
+
+// coerce converts an argument given as a string to the type of its schema, or of the first schema of its allOf, oneOf
+// or anyOf that declares one, like the request is built: numbers and booleans are parsed, body arrays and objects are
+// decoded from JSON and other arrays are split on commas. It returns the problem if the string cannot be converted.
+func (v schemaValidator) coerce(argument argumentSchema, value interface{}) (interface{}, string) {
+	text, ok := value.(string)
+	if !ok {
+		return value, ""
+	}
+	schema := v.resolve(argument.schema)
+	t := schemaTypeName(schema)
+	for _, combinator := range []string{"allOf", "oneOf", "anyOf"} {
+		options, _ := schema[combinator].([]interface{})
+		for _, option := range options {
+			if option, ok := option.(map[string]interface{}); ok && t == "" {
+				t = schemaTypeName(v.resolve(option))
+			}
+		}
+	}
+	switch t {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+			return n, ""
+		}
+		return nil, typeProblem(t)
+	case "boolean":
+		if b, err := strconv.ParseBool(strings.TrimSpace(text)); err == nil {
+			return b, ""
+		}
+		return nil, typeProblem("boolean")
+	case "array":
+		if argument.in != "body" {
+			items, _ := schema["items"].(map[string]interface{})
+			values := []interface{}{}
+			for _, item := range strings.Split(text, ",") {
+				value, problem := v.coerce(argumentSchema{in: argument.in, schema: items}, item)
+				if problem != "" {
+					return nil, "items " + problem
+				}
+				values = append(values, value)
+			}
+			return values, ""
+		}
+		fallthrough
+	case "object":
+		var decoded interface{}
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		if err := decoder.Decode(&decoded); err != nil {
+			return nil, typeProblem(t) + " given as JSON"
+		}
+		return decoded, ""
+	}
+	return text, ""
+}
+
+// validate checks a value against a schema and returns the problems found, prefixed with the path of the field.
+func (v schemaValidator) validate(schema map[string]interface{}, value interface{}, path string) []string {
+	schema = v.resolve(schema)
+	problem := func(format string, a ...interface{}) string {
+		return path + ": " + fmt.Sprintf(format, a...)
+	}
+
+	if value == nil {
+		types := schemaTypes(schema)
+		if len(types) == 0 || schema["nullable"] == true || listContains(schema["type"], "null") {
+			return nil
+		}
+		return []string{problem("must not be null")}
+	}
+
+	problems := []string{}
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			subSchema, _ := sub.(map[string]interface{})
+			problems = append(problems, v.validate(subSchema, value, path)...)
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		if matched, options := v.matchOptions(anyOf, value, path); matched == 0 {
+			problems = append(problems, problem("must match at least one of the anyOf schemas (%s)", strings.Join(options, " | ")))
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		switch matched, options := v.matchOptions(oneOf, value, path); {
+		case matched == 0:
+			problems = append(problems, problem("must match exactly one of the oneOf schemas, it matches none (%s)", strings.Join(options, " | ")))
+		case matched > 1:
+			problems = append(problems, problem("must match exactly one of the oneOf schemas, it matches %d", matched))
+		}
+	}
+
+	if types := schemaTypes(schema); len(types) > 0 && !matchesType(value, types) {
+		if len(types) == 1 {
+			return append(problems, problem(typeProblem(types[0])))
+		}
+		return append(problems, problem("must be one of the types %s", strings.Join(types, ", ")))
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		allowed := false
+		values := make([]string, len(enum))
+		for i, e := range enum {
+			allowed = allowed || sameValue(e, value)
+			values[i] = fmt.Sprint(e)
+		}
+		if !allowed {
+			problems = append(problems, problem("must be one of %s", strings.Join(values, ", ")))
+		}
+	}
+
+	switch value := value.(type) {
+	case string:
+		length := len([]rune(value))
+		if min, ok := number(schema["minLength"]); ok && float64(length) < min {
+			problems = append(problems, problem("must be at least %v characters long", schema["minLength"]))
+		}
+		if max, ok := number(schema["maxLength"]); ok && float64(length) > max {
+			problems = append(problems, problem("must be at most %v characters long", schema["maxLength"]))
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(value) {
+				problems = append(problems, problem("must match the pattern %s", pattern))
+			}
+		}
+		if format, ok := schema["format"].(string); ok {
+			if p := formatProblem(format, value); p != "" {
+				problems = append(problems, problem(p))
+			}
+		}
+	case []interface{}:
+		if min, ok := number(schema["minItems"]); ok && float64(len(value)) < min {
+			problems = append(problems, problem("must have at least %v items", schema["minItems"]))
+		}
+		if max, ok := number(schema["maxItems"]); ok && float64(len(value)) > max {
+			problems = append(problems, problem("must have at most %v items", schema["maxItems"]))
+		}
+		if schema["uniqueItems"] == true {
+			for i := range value {
+				for j := 0; j < i; j++ {
+					if sameValue(value[i], value[j]) {
+						problems = append(problems, problem("must not contain duplicate items, item %d repeats item %d", i, j))
+					}
+				}
+			}
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range value {
+				problems = append(problems, v.validate(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, present := value[name]; !present {
+					problems = append(problems, path+"."+name+": is required")
+				}
+			}
+		}
+		for _, name := range sortedKeys(value) {
+			if prop, ok := properties[name].(map[string]interface{}); ok {
+				problems = append(problems, v.validate(prop, value[name], path+"."+name)...)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					problems = append(problems, path+"."+name+": is not a known property")
+				}
+			case map[string]interface{}:
+				problems = append(problems, v.validate(additional, value[name], path+"."+name)...)
+			}
+		}
+	case float64, json.Number, int, int64:
+		n, _ := number(value)
+		if min, ok := number(schema["minimum"]); ok {
+			if schema["exclusiveMinimum"] == true && n <= min {
+				problems = append(problems, problem("must be greater than %v", schema["minimum"]))
+			} else if n < min {
+				problems = append(problems, problem("must be at least %v", schema["minimum"]))
+			}
+		}
+		if max, ok := number(schema["maximum"]); ok {
+			if schema["exclusiveMaximum"] == true && n >= max {
+				problems = append(problems, problem("must be less than %v", schema["maximum"]))
+			} else if n > max {
+				problems = append(problems, problem("must be at most %v", schema["maximum"]))
+			}
+		}
+		// OpenAPI 3.1 (JSON Schema 2020-12) declares exclusive bounds as numbers
+		if min, ok := number(schema["exclusiveMinimum"]); ok && n <= min {
+			problems = append(problems, problem("must be greater than %v", schema["exclusiveMinimum"]))
+		}
+		if max, ok := number(schema["exclusiveMaximum"]); ok && n >= max {
+			problems = append(problems, problem("must be less than %v", schema["exclusiveMaximum"]))
+		}
+		if step, ok := number(schema["multipleOf"]); ok && step > 0 {
+			if q := n / step; math.Abs(q-math.Round(q)) > 1e-9 {
+				problems = append(problems, problem("must be a multiple of %v", schema["multipleOf"]))
+			}
+		}
+		if format, ok := schema["format"].(string); ok && format == "int32" && (n < math.MinInt32 || n > math.MaxInt32) {
+			problems = append(problems, problem("must fit in a 32-bit integer"))
+		}
+	}
+	return problems
+}
+
+// matchOptions validates a value against the schemas of an anyOf or oneOf. It returns how many match, and the
+// problems of each schema.
+func (v schemaValidator) matchOptions(options []interface{}, value interface{}, path string) (int, []string) {
+	matched := 0
+	problems := []string{}
+	for i, option := range options {
+		schema, _ := option.(map[string]interface{})
+		optionProblems := v.validate(schema, value, path)
+		if len(optionProblems) == 0 {
+			matched++
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("schema %d: %s", i+1, strings.Join(optionProblems, "; ")))
+	}
+	return matched, problems
+}
+
+// schemaTypes returns the types a schema allows, from a type name or a list of them.
+func schemaTypes(schema map[string]interface{}) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := []string{}
+		for _, name := range t {
+			if name, ok := name.(string); ok && name != "null" {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// schemaTypeName returns the first type a schema allows, or "" if it does not declare one.
+func schemaTypeName(schema map[string]interface{}) string {
+	if types := schemaTypes(schema); len(types) > 0 {
+		return types[0]
+	}
+	return ""
+}
+
+// matchesType reports whether a decoded JSON value is of one of the types.
+func matchesType(value interface{}, types []string) bool {
+	for _, t := range types {
+		switch t {
+		case "string":
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case "boolean":
+			if _, ok := value.(bool); ok {
+				return true
+			}
+		case "number":
+			if _, ok := number(value); ok {
+				return true
+			}
+		case "integer":
+			if n, ok := number(value); ok && n == math.Trunc(n) {
+				return true
+			}
+		case "array":
+			if _, ok := value.([]interface{}); ok {
+				return true
+			}
+		case "object":
+			if _, ok := value.(map[string]interface{}); ok {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// typeProblem describes a value that is not of a JSON Schema type.
+func typeProblem(t string) string {
+	switch t {
+	case "integer", "array", "object":
+		return "must be an " + t
+	case "":
+		return "is invalid"
+	}
+	return "must be a " + t
+}
+
+// formatProblem describes a string that is not of a format, or returns "" if it is or the format is not checked.
+func formatProblem(format, value string) string {
+	switch format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return "must be a date-time (RFC 3339, e.g. 2024-05-01T12:00:00Z)"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return "must be a date (YYYY-MM-DD)"
+		}
+	case "uuid":
+		if !uuidPattern.MatchString(value) {
+			return "must be a UUID"
+		}
+	case "email":
+		if address, err := mail.ParseAddress(value); err != nil || address.Address != value {
+			return "must be an email address"
+		}
+	case "uri":
+		if u, err := url.Parse(value); err != nil || !u.IsAbs() {
+			return "must be an absolute URI"
+		}
+	case "ipv4":
+		if ip := net.ParseIP(value); ip == nil || ip.To4() == nil || strings.Contains(value, ":") {
+			return "must be an IPv4 address"
+		}
+	case "ipv6":
+		if ip := net.ParseIP(value); ip == nil || !strings.Contains(value, ":") {
+			return "must be an IPv6 address"
+		}
+	case "byte":
+		if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+			return "must be base64-encoded"
+		}
+	}
+	return ""
+}
+
+// number returns a decoded JSON number as a float64.
+func number(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// sameValue reports whether two decoded JSON values are equal, comparing numbers by value.
+func sameValue(a, b interface{}) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	if _, ok := number(b); ok {
+		return false
+	}
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	x, errA := json.Marshal(a)
+	y, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(x, y)
+}
+
+// listContains reports whether a decoded JSON array contains a string.
+func listContains(list interface{}, value string) bool {
+	values, _ := list.([]interface{})
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package mcpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const validateSpec = `{
+  "swagger": "2.0",
+  "info": {"title": "Shop", "version": "1.0.0"},
+  "paths": {
+    "/orders": {
+      "get": {"operationId": "listOrders",
+        "parameters": [
+          {"name": "status", "in": "query", "type": "string", "enum": ["open", "shipped"]},
+          {"name": "limit", "in": "query", "type": "integer", "minimum": 1, "maximum": 100},
+          {"name": "since", "in": "query", "type": "string", "format": "date-time"},
+          {"name": "ids", "in": "query", "type": "array", "items": {"type": "string", "format": "uuid"}},
+          {"$ref": "#/parameters/Tenant"}
+        ],
+        "responses": {"200": {"description": "Orders"}}},
+      "post": {"operationId": "createOrder",
+        "parameters": [{"name": "body", "in": "body", "schema": {"$ref": "#/definitions/Order"}}],
+        "responses": {"201": {"description": "Created"}}}
+    }
+  },
+  "parameters": {
+    "Tenant": {"name": "X-Tenant", "in": "header", "required": true, "type": "string", "pattern": "^[a-z]+$", "maxLength": 8}
+  },
+  "definitions": {
+    "Order": {"type": "object", "required": ["email", "address"], "properties": {
+      "email": {"type": "string", "format": "email"},
+      "address": {"$ref": "#/definitions/Address"},
+      "lines": {"type": "array", "minItems": 1, "items": {"type": "object", "required": ["sku"], "properties": {
+        "sku": {"type": "string"}, "quantity": {"type": "integer", "minimum": 1}}}},
+      "payment": {"oneOf": [
+        {"type": "object", "required": ["card"], "properties": {"card": {"type": "string"}}, "additionalProperties": false},
+        {"type": "object", "required": ["iban"], "properties": {"iban": {"type": "string"}}, "additionalProperties": false}
+      ]}
+    }},
+    "Address": {"type": "object", "required": ["city"], "properties": {"city": {"type": "string", "minLength": 1}, "zip": {"type": "string", "pattern": "^[0-9]{5}$"}}}
+  }
+}`
+
+func TestArgumentSchemas_Validate(t *testing.T) {
+	_, doc, err := specDocument(parseTestSpec(t, validateSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := operationArgumentSchemas(doc, "/orders", "get")
+	create := operationArgumentSchemas(doc, "/orders", "post")
+
+	tests := []struct {
+		name    string
+		schemas argumentSchemas
+		args    map[string]interface{}
+		want    []string
+	}{
+		{"valid query", list, map[string]interface{}{"X-Tenant": "acme", "status": "open", "limit": "10", "since": "2024-05-01T12:00:00Z",
+			"ids": "0b9f5a4e-7c1d-4c8e-9a3b-2f6d8e1c4a5b,9d8c7b6a-5e4f-4a3b-8c2d-1e0f9a8b7c6d"}, nil},
+		{"missing header", list, map[string]interface{}{}, []string{"X-Tenant: is required"}},
+		{"header pattern and length", list, map[string]interface{}{"X-Tenant": "Acme-Corp-1"}, []string{
+			"X-Tenant: must be at most 8 characters long", "X-Tenant: must match the pattern ^[a-z]+$"}},
+		{"enum", list, map[string]interface{}{"X-Tenant": "acme", "status": "lost"}, []string{"status: must be one of open, shipped"}},
+		{"not an integer", list, map[string]interface{}{"X-Tenant": "acme", "limit": "ten"}, []string{"limit: must be an integer"}},
+		{"maximum", list, map[string]interface{}{"X-Tenant": "acme", "limit": "500"}, []string{"limit: must be at most 100"}},
+		{"date-time", list, map[string]interface{}{"X-Tenant": "acme", "since": "yesterday"}, []string{"since: must be a date-time (RFC 3339, e.g. 2024-05-01T12:00:00Z)"}},
+		{"array items", list, map[string]interface{}{"X-Tenant": "acme", "ids": "0b9f5a4e-7c1d-4c8e-9a3b-2f6d8e1c4a5b,42"}, []string{"ids[1]: must be a UUID"}},
+		{"valid body", create, map[string]interface{}{"email": "ann@example.com", "address": `{"city": "Lyon", "zip": "69001"}`,
+			"lines": `[{"sku": "A1", "quantity": 2}]`, "payment": `{"iban": "FR76"}`}, nil},
+		{"missing body properties", create, map[string]interface{}{"lines": `[]`}, []string{
+			"address: is required", "email: is required", "lines: must have at least 1 items"}},
+		{"nested", create, map[string]interface{}{"email": "ann", "address": `{"zip": "6900"}`, "lines": `[{"quantity": 0}]`}, []string{
+			"address.city: is required", "address.zip: must match the pattern ^[0-9]{5}$", "email: must be an email address",
+			"lines[0].sku: is required", "lines[0].quantity: must be at least 1"}},
+		{"not JSON", create, map[string]interface{}{"email": "ann@example.com", "address": "Lyon"}, []string{"address: must be an object given as JSON"}},
+		{"oneOf none", create, map[string]interface{}{"email": "ann@example.com", "address": `{"city": "Lyon"}`, "payment": `{"cash": true}`}, []string{
+			"payment: must match exactly one of the oneOf schemas, it matches none (schema 1: payment.card: is required; payment.cash: is not a known property | schema 2: payment.iban: is required; payment.cash: is not a known property)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.schemas.validate(tt.args)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatProblem(t *testing.T) {
+	tests := []struct {
+		format, value string
+		valid         bool
+	}{
+		{"date", "2024-05-01", true},
+		{"date", "01/05/2024", false},
+		{"uri", "https://example.com/a", true},
+		{"uri", "/a", false},
+		{"ipv4", "10.0.0.1", true},
+		{"ipv4", "::1", false},
+		{"ipv6", "::1", true},
+		{"byte", "aGVsbG8=", true},
+		{"byte", "not base64!", false},
+		{"color", "anything", true},
+	}
+	for _, tt := range tests {
+		if got := formatProblem(tt.format, tt.value) == ""; got != tt.valid {
+			t.Errorf("%s %q: got valid %v", tt.format, tt.value, got)
+		}
+	}
+}
+
+func TestValidateToolHandler(t *testing.T) {
+	sent := 0
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+		w.Write([]byte(`[]`))
+	}))
+	defer api.Close()
+
+	for _, tool := range BuildSwaggerTools(parseTestSpec(t, validateSpec), models.ApiConfig{BaseUrl: api.URL}) {
+		if tool.Tool.Name != "get_/orders" {
+			continue
+		}
+		request := mcp.CallToolRequest{}
+		args := map[string]interface{}{"X-Tenant": "acme", "status": "lost", "limit": "0"}
+		request.Params.Arguments = args
+		result, _ := tool.Handler(context.Background(), request)
+		text := result.Content[0].(mcp.TextContent).Text
+		if !result.IsError || text != "[Error] invalid arguments, the request was not sent:\n- limit: must be at least 1\n- status: must be one of open, shipped" {
+			t.Errorf("expected the invalid arguments to be listed, got %q", text)
+		}
+		// dry runs are validated too
+		args["_dryRun"] = true
+		if result, _ := tool.Handler(context.Background(), request); !result.IsError {
+			t.Errorf("expected an invalid dry run to be refused")
+		}
+	}
+	if sent != 0 {
+		t.Errorf("expected no request to be sent, got %d", sent)
+	}
+}
@@ -1,5 +1,11 @@
 package models
 
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
 type Server struct {
 	URL         string `json:"url"`
 	Description string `json:"description,omitempty"`
@@ -17,9 +23,12 @@ type SwaggerSpec struct {
 	Components *Components `json:"components,omitempty"`
 
 	// Common fields
-	Info        *SwaggerInfo                   `json:"info,omitempty"`
-	Paths       map[string]map[string]Endpoint `json:"paths"`
-	Definitions map[string]Definition          `json:"definitions,omitempty"` // Swagger 2.0
+	Info                *SwaggerInfo                   `json:"info,omitempty"`
+	Paths               map[string]map[string]Endpoint `json:"paths"`
+	Definitions         map[string]Definition          `json:"definitions,omitempty"`         // Swagger 2.0
+	SecurityDefinitions map[string]SecurityScheme      `json:"securityDefinitions,omitempty"` // Swagger 2.0
+
+	Raw json.RawMessage `json:"-"` // Document the spec was parsed from, served as a resource
 }
 
 // SwaggerInfo holds metadata about the API, including version.
@@ -30,7 +39,16 @@ type SwaggerInfo struct {
 }
 
 type Components struct {
-	Schemas map[string]Definition `json:"schemas,omitempty"` // OpenAPI 3.0
+	Schemas         map[string]Definition     `json:"schemas,omitempty"`         // OpenAPI 3.0
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"` // OpenAPI 3.0
+}
+
+// SecurityScheme describes how an API expects credentials to be passed.
+type SecurityScheme struct {
+	Type   string `json:"type"`             // basic, apiKey or oauth2 (Swagger 2.0); http, apiKey, oauth2 or openIdConnect (OpenAPI 3.0)
+	Scheme string `json:"scheme,omitempty"` // HTTP auth scheme for type http, e.g. bearer or basic
+	Name   string `json:"name,omitempty"`   // Header, query parameter or cookie name for type apiKey
+	In     string `json:"in,omitempty"`     // header, query or cookie for type apiKey
 }
 
 type Definition struct {
@@ -43,39 +61,92 @@ type Property struct {
 }
 
 type Endpoint struct {
+	OperationID string              `json:"operationId,omitempty"`
 	Summary     string              `json:"summary"`
 	Description string              `json:"description"`
 	Parameters  []Parameter         `json:"parameters"`
 	Responses   map[string]Response `json:"responses"`
 	Consumes    []string            `json:"consumes"`
 	Produces    []string            `json:"produces"`
+	Tags        []string            `json:"tags,omitempty"`
+	RequestBody json.RawMessage     `json:"requestBody,omitempty"` // OpenAPI 3.0
+	Async       json.RawMessage     `json:"x-mcp-async,omitempty"` // true or an AsyncConfig for long-running operations
+	Deprecated  bool                `json:"deprecated,omitempty"`
+
+	Extensions map[string]json.RawMessage `json:"-"` // x- extensions of the operation, by name
+}
+
+// UnmarshalJSON decodes an operation and collects its x- extensions.
+func (e *Endpoint) UnmarshalJSON(data []byte) error {
+	type endpoint Endpoint
+	if err := json.Unmarshal(data, (*endpoint)(e)); err != nil {
+		return err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	e.Extensions = nil
+	for name, value := range fields {
+		if strings.HasPrefix(name, "x-") {
+			if e.Extensions == nil {
+				e.Extensions = map[string]json.RawMessage{}
+			}
+			e.Extensions[name] = value
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes an operation with its x- extensions.
+func (e Endpoint) MarshalJSON() ([]byte, error) {
+	type endpoint Endpoint
+	data, err := json.Marshal(endpoint(e))
+	if err != nil || len(e.Extensions) == 0 {
+		return data, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for name, value := range e.Extensions {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
+	}
+	return json.Marshal(fields)
 }
 
 type Parameter struct {
-	Name        string     `json:"name"`
-	In          string     `json:"in"`
-	Required    bool       `json:"required"`
-	Type        string     `json:"type"`
-	Schema      *SchemaRef `json:"schema,omitempty"`
-	Description string     `json:"description"`
+	Name        string        `json:"name"`
+	In          string        `json:"in"`
+	Required    bool          `json:"required"`
+	Type        string        `json:"type"`
+	Schema      *SchemaRef    `json:"schema,omitempty"`
+	Description string        `json:"description"`
+	Enum        []interface{} `json:"enum,omitempty"` // Swagger 2.0, OpenAPI 3.0 declares it in the schema
 }
 
 type Response struct {
-	Description string     `json:"description"`
-	Schema      *SchemaRef `json:"schema,omitempty"`
-	Type        string     `json:"type,omitempty"`
+	Description string                     `json:"description"`
+	Schema      *SchemaRef                 `json:"schema,omitempty"`
+	Type        string                     `json:"type,omitempty"`
+	Content     map[string]json.RawMessage `json:"content,omitempty"` // OpenAPI 3.0, keyed by media type
 }
 
 type SchemaRef struct {
-	Ref  string `json:"$ref,omitempty"`
-	Type string `json:"type,omitempty"`
+	Ref  string        `json:"$ref,omitempty"`
+	Type string        `json:"type,omitempty"`
+	Enum []interface{} `json:"enum,omitempty"`
 }
 
-// SseConfig stores SSE (Server-Sent Events) related parameters
+// SseConfig stores the parameters of the network transports, SSE (Server-Sent Events) and Streamable HTTP.
+// When both are enabled they share one listener.
 type SseConfig struct {
-	SseMode bool   `json:"sseMode"` // Whether to run in SSE mode
-	SseAddr string `json:"sseAddr"` // SSE server listen address
-	SseUrl  string `json:"sseUrl"`  // Base URL for the SSE server
+	SseMode  bool   `json:"sseMode"`  // Whether to serve SSE
+	HttpMode bool   `json:"httpMode"` // Whether to serve Streamable HTTP
+	SseAddr  string `json:"sseAddr"`  // Server listen address
+	SseUrl   string `json:"sseUrl"`   // Base URL of the server
 }
 
 // ApiConfig stores API related parameters
@@ -91,11 +162,160 @@ type ApiConfig struct {
 	BearerAuth     string `json:"bearerAuth"`     // Bearer token
 	SseHeaders     string `json:"sseHeaders"`     // Read headers from sse request, and pass to API request (format: name1,name2)
 	Headers        string `json:"headers"`        // Additional headers to include in requests (format: name1=value1,name2=value2)
+	ToolPrefix     string `json:"toolPrefix"`     // Prefix prepended to every generated tool name
+
+	IncludeTags        string `json:"includeTags"`        // List of tags whose operations are included
+	ExcludeTags        string `json:"excludeTags"`        // List of tags whose operations are excluded
+	IncludeOperations  string `json:"includeOperations"`  // List of operationIds or glob patterns to include
+	ExcludeOperations  string `json:"excludeOperations"`  // List of operationIds or glob patterns to exclude
+	IncludeExtensions  string `json:"includeExtensions"`  // List of x- extensions, as name or name=value, whose operations are included
+	ExcludeExtensions  string `json:"excludeExtensions"`  // List of x- extensions, as name or name=value, whose operations are excluded
+	ExcludeDeprecated  bool   `json:"excludeDeprecated"`  // Whether operations marked deprecated are excluded
+	ReadOnly           bool   `json:"readOnly"`           // Only safe operations get a tool and only safe requests are sent
+	SafeOperation      bool   `json:"-"`                  // Set on the config of a POST operation allowed in read-only mode
+	ConfirmDestructive bool   `json:"confirmDestructive"` // Calls of destructive operations are sent only once the user confirms them
+	DryRun             bool   `json:"dryRun"`             // Tool calls return the request they would send instead of sending it
+	ValidateResponses  string `json:"validateResponses"`  // off, log or warn: whether responses are checked against the spec, and how problems are reported
+
+	ResourceTemplates string `json:"resourceTemplates"` // GET operations also served as resource templates: operationIds or "GET /path", or * for all
+
+	HeaderValues map[string]string            `json:"headerValues,omitempty"` // Additional headers to include in requests, set from a config file
+	ApiKeys      []ApiKey                     `json:"apiKeys,omitempty"`      // API keys used with apiKey security, set from a config file
+	Overrides    map[string]OperationOverride `json:"overrides,omitempty"`    // Per-operation settings keyed by operationId or "METHOD /path"
+	Prompts      []PromptConfig               `json:"prompts,omitempty"`      // Custom prompts, set from a config file
+	Lookups      map[string]Lookup            `json:"lookups,omitempty"`      // Operations listing the valid values of a parameter, keyed by parameter name, set from a config file
+
+	SessionApiKey ApiKey `json:"sessionApiKey,omitempty"` // Where an API key supplied by a client session is passed, from the spec's apiKey security scheme
+}
+
+// ApiKey describes one API key and where it is passed
+type ApiKey struct {
+	In    string `json:"in"`    // header, query or cookie
+	Name  string `json:"name"`  // Header, query parameter or cookie name
+	Value string `json:"value"` // Key value
+}
+
+// OperationOverride stores settings that apply to a single operation
+type OperationOverride struct {
+	Name        string            `json:"name,omitempty"`        // Tool name used instead of the generated one
+	Description string            `json:"description,omitempty"` // Tool description used instead of the generated one
+	Headers     map[string]string `json:"headers,omitempty"`     // Additional headers sent with this operation only
+	Disabled    bool              `json:"disabled,omitempty"`    // Do not generate a tool for this operation
+	Resource    bool              `json:"resource,omitempty"`    // Also serve this GET operation as a resource template
+	Async       *AsyncConfig      `json:"async,omitempty"`       // Follow the operation to completion when it answers 202 Accepted
+	Annotations *ToolAnnotations  `json:"annotations,omitempty"` // Tool annotations used instead of the ones inferred from the HTTP method
+	Safe        bool              `json:"safe,omitempty"`        // POST operation that only reads, such as a search, kept in read-only mode
+	Confirm     *bool             `json:"confirm,omitempty"`     // Whether calls must be confirmed by the user, by default if the operation is destructive and confirmDestructive is set
+}
+
+// ToolAnnotations overrides the MCP annotations of an operation's tool. Unset hints keep their inferred value.
+type ToolAnnotations struct {
+	Title       string `json:"title,omitempty" yaml:"title"`             // Human-readable title, the operation summary by default
+	ReadOnly    *bool  `json:"readOnly,omitempty" yaml:"readOnly"`       // The tool does not modify anything
+	Destructive *bool  `json:"destructive,omitempty" yaml:"destructive"` // The tool may delete or overwrite data
+	Idempotent  *bool  `json:"idempotent,omitempty" yaml:"idempotent"`   // Repeating a call with the same arguments has no further effect
+	OpenWorld   *bool  `json:"openWorld,omitempty" yaml:"openWorld"`     // The tool reaches entities outside the server
+}
+
+// AsyncConfig describes how to follow a long-running operation that answers 202 Accepted with a status URL.
+// Field names are dot-separated paths into the JSON status response.
+type AsyncConfig struct {
+	StatusUrl     string   `json:"statusUrl,omitempty" yaml:"statusUrl"`         // Status URL with {field} placeholders filled from the 202 response, used instead of its Location header
+	StatusField   string   `json:"statusField,omitempty" yaml:"statusField"`     // Field holding the state; without it the operation is done once the status URL stops answering 202
+	DoneValues    []string `json:"doneValues,omitempty" yaml:"doneValues"`       // States meaning the operation succeeded, default succeeded, completed, done
+	FailedValues  []string `json:"failedValues,omitempty" yaml:"failedValues"`   // States meaning the operation failed, default failed, error, cancelled, canceled
+	ProgressField string   `json:"progressField,omitempty" yaml:"progressField"` // Field holding the percentage done
+	ResultField   string   `json:"resultField,omitempty" yaml:"resultField"`     // Field holding the URL of the final resource
+	PollInterval  string   `json:"pollInterval,omitempty" yaml:"pollInterval"`   // Go duration between polls, 2s if empty; a Retry-After header takes precedence
+	Timeout       string   `json:"timeout,omitempty" yaml:"timeout"`             // Go duration after which the call gives up waiting, 30m if empty
+	CancelMethod  string   `json:"cancelMethod,omitempty" yaml:"cancelMethod"`   // HTTP method sent to the status URL when the call is cancelled, e.g. DELETE
+}
+
+// PromptConfig declares an MCP prompt whose messages are filled in with the prompt arguments
+type PromptConfig struct {
+	Name        string           `json:"name"`                  // Prompt name, prefixed like tool names
+	Description string           `json:"description,omitempty"` // What the prompt is for
+	Arguments   []PromptArgument `json:"arguments,omitempty"`   // Arguments the client supplies
+	Messages    []PromptMessage  `json:"messages"`              // Messages returned by the prompt, {argument} is replaced by the argument value
+}
+
+// PromptArgument describes one argument of a prompt
+type PromptArgument struct {
+	Name        string `json:"name"`                  // Argument name, used as {name} in the messages
+	Description string `json:"description,omitempty"` // What the client should supply
+	Required    bool   `json:"required,omitempty"`    // Whether the prompt can be used without it
+}
+
+// PromptMessage is one message of a prompt
+type PromptMessage struct {
+	Role string `json:"role,omitempty"` // user or assistant, defaults to user
+	Text string `json:"text"`           // Message text with {argument} placeholders
+}
+
+// Lookup names the GET operation whose response lists the valid values of a parameter, used to complete it
+type Lookup struct {
+	Operation string        `json:"operation"`       // operationId or "GET /path"
+	Items     string        `json:"items,omitempty"` // Dot-separated path of the list in the response, empty if the response is the list
+	Field     string        `json:"field,omitempty"` // Field of each item holding the value, empty if the items are the values
+	TTL       time.Duration `json:"ttl,omitempty"`   // How long the values are cached, five minutes if zero
+}
+
+// SpecConfig stores the parameters of one spec served alongside others from a single MCP server
+type SpecConfig struct {
+	SpecUrl string    `json:"specUrl"` // URL of the Swagger JSON specification
+	ApiCfg  ApiConfig `json:"apiCfg"`  // API related configuration for this spec
+}
+
+// ReloadConfig stores spec hot reload parameters
+type ReloadConfig struct {
+	Watch        bool          `json:"watch"`        // Watch specs and update tools when they change
+	PollInterval time.Duration `json:"pollInterval"` // How often HTTP specs are polled for changes
+}
+
+// HeaderMapping forwards one client request header to upstream API requests
+type HeaderMapping struct {
+	From  string `json:"from"`            // Header read from the client request
+	To    string `json:"to,omitempty"`    // Header set on API requests, defaults to From
+	Value string `json:"value,omitempty"` // Template for the API request value, {value} is replaced by the client value
+}
+
+// SessionConfig stores per-session credential and header passthrough parameters
+type SessionConfig struct {
+	Credentials bool            `json:"credentials"` // Accept bearer tokens, API keys and cookies supplied by each client for its own session
+	Headers     []HeaderMapping `json:"headers"`     // Client headers forwarded to API requests; headers not listed are never forwarded
+}
+
+// TLSConfig stores the TLS parameters of the SSE and Streamable HTTP listener
+type TLSConfig struct {
+	CertFile          string `json:"certFile"`          // PEM certificate chain, reloaded when the file changes
+	KeyFile           string `json:"keyFile"`           // PEM private key, reloaded when the file changes
+	ClientCAFile      string `json:"clientCAFile"`      // PEM CA bundle client certificates are verified against
+	RequireClientCert bool   `json:"requireClientCert"` // Reject clients without a verified certificate
+}
+
+// InboundAuthConfig stores how MCP clients authenticate to the SSE and Streamable HTTP listener.
+// A client is accepted if any configured method succeeds; with no method configured the listener is open.
+type InboundAuthConfig struct {
+	Tokens       map[string]string `json:"tokens"`       // Accepted bearer tokens by identity name; values may be secret references
+	ApiKeys      map[string]string `json:"apiKeys"`      // Accepted API keys by identity name; values may be secret references
+	ApiKeyHeader string            `json:"apiKeyHeader"` // Header carrying the API key
+	ClientCerts  bool              `json:"clientCerts"`  // Accept verified TLS client certificates, identified by common name
+	JwksFile     string            `json:"jwksFile"`     // Local JWKS file used to verify JWT bearer tokens
+	Issuer       string            `json:"issuer"`       // Expected JWT issuer; its JWKS is discovered when JwksFile is not set
+	Audience     string            `json:"audience"`     // Expected JWT audience
 }
 
 // Config stores all command line parameters
 type Config struct {
-	SpecUrl string    `json:"specUrl"` // URL of the Swagger JSON specification
-	SseCfg  SseConfig `json:"sseCfg"`  // SSE related configuration
-	ApiCfg  ApiConfig `json:"apiCfg"`  // API related configuration
+	SpecUrl         string            `json:"specUrl"`         // URL of the Swagger JSON specification
+	SseCfg          SseConfig         `json:"sseCfg"`          // SSE related configuration
+	ApiCfg          ApiConfig         `json:"apiCfg"`          // API related configuration
+	Specs           []SpecConfig      `json:"specs"`           // Specs served from the same MCP server, each with its own API configuration
+	ReloadCfg       ReloadConfig      `json:"reloadCfg"`       // Spec hot reload configuration
+	SessionCfg      SessionConfig     `json:"sessionCfg"`      // Per-session credentials and header passthrough
+	AuthCfg         InboundAuthConfig `json:"authCfg"`         // Authentication of MCP clients
+	TLSCfg          TLSConfig         `json:"tlsCfg"`          // TLS of the SSE and Streamable HTTP listener
+	ShutdownTimeout time.Duration     `json:"shutdownTimeout"` // Grace period for in-flight tool calls on shutdown
+	ToolMode        string            `json:"toolMode"`        // operations (one tool per operation), meta (search, describe and call tools) or toolsets (tools enabled per tag by each session)
+	CoreToolsets    []string          `json:"coreToolsets"`    // Toolsets every session has in toolsets mode
 }
@@ -0,0 +1,192 @@
+// Package secrets resolves credential references so that secrets do not have to be passed on the command line.
+// A reference is one of:
+//
+//	env:NAME        the value of the environment variable NAME
+//	file:/path      the content of a file, re-read whenever the file changes (e.g. Kubernetes secret mounts)
+//	exec:command    the output of a shell command, cached for ExecCacheTTL, only if AllowExec is set
+//
+// Any other value is used literally. As a literal value starting with one of these prefixes would be taken for a
+// reference, exec: references are refused unless AllowExec is set, rather than running the value as a command.
+// Errors name the reference but never contain the secret value.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AllowExec enables exec: references. It is off by default so that a credential cannot run a command unless the
+// operator asked for it.
+var AllowExec bool
+
+// ExecCacheTTL is how long the output of an exec: reference is reused before the command runs again.
+var ExecCacheTTL = time.Minute
+
+// execTimeout bounds how long an exec: command may run.
+const execTimeout = 10 * time.Second
+
+type fileEntry struct {
+	modTime time.Time
+	size    int64
+	value   string
+}
+
+type execEntry struct {
+	expires time.Time
+	value   string
+}
+
+var (
+	mu        sync.Mutex
+	fileCache = map[string]fileEntry{}
+	execCache = map[string]execEntry{}
+)
+
+// IsRef reports whether value is a secret reference rather than a literal value.
+func IsRef(value string) bool {
+	return strings.HasPrefix(value, "env:") || strings.HasPrefix(value, "file:") || strings.HasPrefix(value, "exec:")
+}
+
+// Resolve returns the secret a reference points to, or value itself if it is not a reference.
+func Resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "env:"):
+		name := strings.TrimPrefix(value, "env:")
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("secret %s: environment variable is not set", value)
+		}
+		return secret, nil
+	case strings.HasPrefix(value, "file:"):
+		return resolveFile(value)
+	case strings.HasPrefix(value, "exec:"):
+		if !AllowExec {
+			// the value is not named, it may be a literal secret
+			return "", fmt.Errorf("secret exec: reference: exec references are disabled, enable them with --allowSecretExec")
+		}
+		return resolveExec(value)
+	}
+	return value, nil
+}
+
+// resolveFile reads a file secret, reusing the cached value while the file's modification time and size are unchanged.
+func resolveFile(ref string) (string, error) {
+	path := strings.TrimPrefix(strings.TrimPrefix(ref, "file:"), "//")
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("secret %s: cannot read file", ref)
+	}
+
+	mu.Lock()
+	entry, ok := fileCache[path]
+	mu.Unlock()
+	if ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		return entry.value, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("secret %s: cannot read file", ref)
+	}
+	value := strings.TrimRight(string(data), "\r\n")
+	mu.Lock()
+	fileCache[path] = fileEntry{modTime: info.ModTime(), size: info.Size(), value: value}
+	mu.Unlock()
+	return value, nil
+}
+
+// resolveExec runs the command of an exec: secret with sh -c, caching its trimmed output for ExecCacheTTL.
+// The command output is never included in errors since it may contain the secret.
+func resolveExec(ref string) (string, error) {
+	command := strings.TrimPrefix(ref, "exec:")
+
+	mu.Lock()
+	entry, ok := execCache[command]
+	mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.value, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), execTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "sh", "-c", command).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("secret %s: command exited with status %d", ref, exitErr.ExitCode())
+		}
+		return "", fmt.Errorf("secret %s: command failed", ref)
+	}
+	value := strings.TrimRight(string(out), "\r\n")
+	mu.Lock()
+	execCache[command] = execEntry{expires: time.Now().Add(ExecCacheTTL), value: value}
+	mu.Unlock()
+	return value, nil
+}
+
+// ResolveBasicAuth resolves basic auth credentials given as a single reference or as user:reference.
+func ResolveBasicAuth(value string) (string, error) {
+	if value == "" || IsRef(value) {
+		return Resolve(value)
+	}
+	user, password, found := strings.Cut(value, ":")
+	if !found || !IsRef(password) {
+		return value, nil
+	}
+	password, err := Resolve(password)
+	if err != nil {
+		return "", err
+	}
+	return user + ":" + password, nil
+}
+
+// ResolveApiKeyAuth resolves API keys given as a single reference or in the passAs:name=value[,...] format
+// with references as values, e.g. header:X-API-Key=env:API_KEY.
+func ResolveApiKeyAuth(value string) (string, error) {
+	if value == "" || IsRef(value) {
+		return Resolve(value)
+	}
+	parts := strings.Split(value, ",")
+	for i, part := range parts {
+		key, ref, found := strings.Cut(part, "=")
+		if !found || !IsRef(strings.TrimSpace(ref)) {
+			continue
+		}
+		secret, err := Resolve(strings.TrimSpace(ref))
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", strings.TrimSpace(key), err)
+		}
+		parts[i] = key + "=" + secret
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// RedactApiKeyAuth returns a representation of API keys in the passAs:name=value[,...] format that is safe
+// to log: every value is redacted as by Redact, keeping where and under which name it is passed.
+func RedactApiKeyAuth(value string) string {
+	if value == "" || IsRef(value) {
+		return value
+	}
+	parts := strings.Split(value, ",")
+	for i, part := range parts {
+		if key, secret, found := strings.Cut(part, "="); found {
+			parts[i] = key + "=" + Redact(strings.TrimSpace(secret))
+		} else {
+			parts[i] = Redact(part)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Redact returns a representation of a credential that is safe to log: references are shown as-is,
+// literal values are masked.
+func Redact(value string) string {
+	if value == "" || IsRef(value) {
+		return value
+	}
+	return "<redacted>"
+}
@@ -0,0 +1,123 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolve_Literal(t *testing.T) {
+	if got, err := Resolve("plain-token"); err != nil || got != "plain-token" {
+		t.Errorf("Resolve literal = %q, %v", got, err)
+	}
+}
+
+func TestResolve_Env(t *testing.T) {
+	t.Setenv("SECRETS_TEST_TOKEN", "s3cr3t")
+	if got, err := Resolve("env:SECRETS_TEST_TOKEN"); err != nil || got != "s3cr3t" {
+		t.Errorf("Resolve env = %q, %v", got, err)
+	}
+	_, err := Resolve("env:SECRETS_TEST_MISSING")
+	if err == nil || !strings.Contains(err.Error(), "env:SECRETS_TEST_MISSING") {
+		t.Errorf("expected missing env error naming the reference, got %v", err)
+	}
+}
+
+func TestResolve_FileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Resolve("file:" + path); err != nil || got != "first" {
+		t.Fatalf("Resolve file = %q, %v", got, err)
+	}
+
+	if err := os.WriteFile(path, []byte("second-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Resolve("file:" + path); err != nil || got != "second-token" {
+		t.Errorf("expected rotated secret, got %q, %v", got, err)
+	}
+
+	if _, err := Resolve("file:" + filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestResolve_Exec(t *testing.T) {
+	_, err := Resolve("exec:echo from-helper")
+	if err == nil || !strings.Contains(err.Error(), "disabled") || strings.Contains(err.Error(), "from-helper") {
+		t.Fatalf("expected exec references to be disabled by default, got %v", err)
+	}
+
+	AllowExec = true
+	defer func() { AllowExec = false }()
+	if got, err := Resolve("exec:echo from-helper"); err != nil || got != "from-helper" {
+		t.Errorf("Resolve exec = %q, %v", got, err)
+	}
+	_, err = Resolve("exec:printf 'lea%s' ked; exit 3")
+	if err == nil || !strings.Contains(err.Error(), "status 3") {
+		t.Fatalf("expected exit status error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "leaked") {
+		t.Errorf("error must not contain command output: %v", err)
+	}
+}
+
+func TestResolveBasicAuth(t *testing.T) {
+	t.Setenv("SECRETS_TEST_PASSWORD", "pw")
+	cases := map[string]string{
+		"user:pass":                      "user:pass",
+		"user:env:SECRETS_TEST_PASSWORD": "user:pw",
+		"":                               "",
+	}
+	for in, want := range cases {
+		if got, err := ResolveBasicAuth(in); err != nil || got != want {
+			t.Errorf("ResolveBasicAuth(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+}
+
+func TestResolveApiKeyAuth(t *testing.T) {
+	t.Setenv("SECRETS_TEST_API_KEY", "k1")
+	cases := map[string]string{
+		"header:X-API-Key=abc":                               "header:X-API-Key=abc",
+		"header:X-API-Key=env:SECRETS_TEST_API_KEY":          "header:X-API-Key=k1",
+		"query:user=foo,cookie:sid=env:SECRETS_TEST_API_KEY": "query:user=foo,cookie:sid=k1",
+		"env:SECRETS_TEST_API_KEY":                           "k1",
+		"":                                                   "",
+	}
+	for in, want := range cases {
+		if got, err := ResolveApiKeyAuth(in); err != nil || got != want {
+			t.Errorf("ResolveApiKeyAuth(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ResolveApiKeyAuth("header:X-API-Key=env:SECRETS_TEST_MISSING"); err == nil || !strings.Contains(err.Error(), "header:X-API-Key") {
+		t.Errorf("expected an error naming the key, got %v", err)
+	}
+}
+
+func TestRedactApiKeyAuth(t *testing.T) {
+	in := "header:X-API-Key=env:API_KEY,query:token=abc123"
+	if got, want := RedactApiKeyAuth(in), "header:X-API-Key=env:API_KEY,query:token=<redacted>"; got != want {
+		t.Errorf("RedactApiKeyAuth(%q) = %q, want %q", in, got, want)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	if got := Redact("abc123"); got != "<redacted>" {
+		t.Errorf("Redact literal = %q", got)
+	}
+	if got := Redact("env:TOKEN"); got != "env:TOKEN" {
+		t.Errorf("Redact reference = %q", got)
+	}
+	if got := Redact(""); got != "" {
+		t.Errorf("Redact empty = %q", got)
+	}
+}
@@ -114,9 +114,69 @@ func LoadSwagger(specUrl string) (models.SwaggerSpec, error) {
 		}
 	}
 
+	return parseSwagger(body)
+}
+
+// parseSwagger decodes a Swagger/OpenAPI JSON document.
+func parseSwagger(body []byte) (models.SwaggerSpec, error) {
 	var swaggerSpec models.SwaggerSpec
 	if err := json.Unmarshal(body, &swaggerSpec); err != nil {
 		return models.SwaggerSpec{}, fmt.Errorf("error parsing JSON: %v", err.Error())
 	}
+	swaggerSpec.Raw = body
 	return swaggerSpec, nil
 }
+
+// SpecVersion identifies a revision of an HTTP spec by its ETag and Last-Modified headers.
+type SpecVersion struct {
+	ETag         string
+	LastModified string
+}
+
+// LoadSwaggerIfChanged loads a spec unless its server reports it unchanged since prev,
+// using If-None-Match and If-Modified-Since. Non-HTTP specs are always loaded.
+// It returns the spec, its new version, and whether a new spec was loaded.
+func LoadSwaggerIfChanged(specUrl string, prev SpecVersion) (models.SwaggerSpec, SpecVersion, bool, error) {
+	if strings.HasPrefix(specUrl, "file://") || !strings.Contains(specUrl, "://") {
+		swaggerSpec, err := LoadSwagger(specUrl)
+		return swaggerSpec, prev, err == nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, specUrl, nil)
+	if err != nil {
+		return models.SwaggerSpec{}, prev, false, fmt.Errorf("error getting spec: %v", err)
+	}
+	if prev.ETag != "" {
+		req.Header.Set("If-None-Match", prev.ETag)
+	}
+	if prev.LastModified != "" {
+		req.Header.Set("If-Modified-Since", prev.LastModified)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return models.SwaggerSpec{}, prev, false, fmt.Errorf("error getting spec: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return models.SwaggerSpec{}, prev, false, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return models.SwaggerSpec{}, prev, false, fmt.Errorf("error getting spec: status %d", resp.StatusCode)
+	}
+
+	maxSize := GetMaxSpecSize()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxSize)+1))
+	if err != nil {
+		return models.SwaggerSpec{}, prev, false, fmt.Errorf("error reading spec: %v", err)
+	}
+	if len(body) > maxSize {
+		return models.SwaggerSpec{}, prev, false, fmt.Errorf("spec file too large (max %d bytes)", maxSize)
+	}
+	swaggerSpec, err := parseSwagger(body)
+	if err != nil {
+		return models.SwaggerSpec{}, prev, false, err
+	}
+	version := SpecVersion{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	return swaggerSpec, version, true, nil
+}
@@ -1,3 +1,6 @@
+//go:build !dev
+// +build !dev
+
 package version
 
 // Version is the current version of the swagger-mcp server, set at build time or from git tag.
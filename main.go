@@ -6,32 +6,44 @@ import (
 	"log"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"unicode"
 
+	"github.com/danishjsheikh/swagger-mcp/app/auth"
+	appconfig "github.com/danishjsheikh/swagger-mcp/app/config"
 	mcpserver "github.com/danishjsheikh/swagger-mcp/app/mcp-server"
 	"github.com/danishjsheikh/swagger-mcp/app/models"
+	"github.com/danishjsheikh/swagger-mcp/app/secrets"
 	"github.com/danishjsheikh/swagger-mcp/app/swagger"
 )
 
-func getSseUrlAddr(sseUrl, sseAddr string) (string, string) {
+func getSseUrlAddr(sseUrl, sseAddr string, useTLS bool) (string, string) {
+	scheme := "http://"
+	if useTLS {
+		scheme = "https://"
+	}
 	// Only complement if one is empty; if both are set, use as-is
 	if sseAddr == "" && sseUrl == "" {
-		return "http://localhost:8080", "localhost:8080"
+		return scheme + "localhost:8080", "localhost:8080"
 	}
 	if sseAddr != "" {
 		// ":Port" or "IP:Port"
 		if strings.HasPrefix(sseAddr, ":") {
-			// sseUrl = http://localhost:Port
-			return "http://localhost" + sseAddr, sseAddr
+			// sseUrl = http(s)://localhost:Port
+			return scheme + "localhost" + sseAddr, sseAddr
 		}
 		if !strings.Contains(sseAddr, ":") {
-			log.Fatal("sseAddr must be in :Port or IP:Port format")
+			log.Panic("sseAddr must be in :Port or IP:Port format")
 		}
-		return "http://" + sseAddr, sseAddr
+		return scheme + sseAddr, sseAddr
 	} else if sseUrl != "" {
 		u, err := url.Parse(sseUrl)
 		if err != nil {
-			log.Fatalf("Invalid sseUrl: %v", err)
+			log.Panicf("Invalid sseUrl: %v", err)
+		}
+		if useTLS && u.Scheme != "https" {
+			log.Panicf("sseUrl must use https when TLS is enabled, got %s", sseUrl)
 		}
 		host := u.Host
 		port := ""
@@ -48,78 +60,463 @@ func getSseUrlAddr(sseUrl, sseAddr string) (string, string) {
 			case "https":
 				port = "443"
 			default:
-				log.Fatalf("Unknown scheme for sseUrl: %s", u.Scheme)
+				log.Panicf("Unknown scheme for sseUrl: %s", u.Scheme)
 			}
 		}
 		return sseUrl, host + ":" + port
 	} else {
-		log.Fatal("Either sseAddr or sseUrl must be provided")
+		log.Panic("Either sseAddr or sseUrl must be provided")
 	}
 	return "", ""
 }
 
+// parseTransport parses a --transport value: stdio, sse, http, or sse,http to serve SSE and
+// Streamable HTTP on one listener. An empty value falls back to the --sse flag.
+func parseTransport(transport string, sse bool) (sseMode, httpMode bool, err error) {
+	if transport == "" {
+		return sse, false, nil
+	}
+	stdio := false
+	for _, t := range strings.Split(transport, ",") {
+		switch strings.ToLower(strings.TrimSpace(t)) {
+		case "stdio":
+			stdio = true
+		case "sse":
+			sseMode = true
+		case "http":
+			httpMode = true
+		default:
+			return false, false, fmt.Errorf("Invalid transport %q: must be stdio, sse, http or sse,http", t)
+		}
+	}
+	if stdio && (sseMode || httpMode) {
+		return false, false, fmt.Errorf("Invalid transport %q: stdio cannot be combined with other transports", transport)
+	}
+	if sse && !sseMode {
+		return false, false, fmt.Errorf("--sse conflicts with --transport=%s", transport)
+	}
+	return sseMode, httpMode, nil
+}
+
+// specFlags collects the values of the repeatable --spec flag.
+type specFlags []string
+
+func (s *specFlags) String() string { return strings.Join(*s, " ") }
+
+func (s *specFlags) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// parseSpec parses a --spec value of the form "prefix=users;specUrl=https://...;baseUrl=...".
+// Keys mirror the single-spec flags; values may contain commas, so settings are separated by semicolons.
+func parseSpec(value string) (models.SpecConfig, error) {
+	var spec models.SpecConfig
+	for _, pair := range strings.Split(value, ";") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return spec, fmt.Errorf("invalid spec setting %q, expected key=value", strings.TrimSpace(kv[0]))
+		}
+		key, val := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch key {
+		case "prefix":
+			spec.ApiCfg.ToolPrefix = val
+		case "specUrl":
+			spec.SpecUrl = val
+		case "baseUrl":
+			spec.ApiCfg.BaseUrl = val
+		case "includePaths":
+			spec.ApiCfg.IncludePaths = val
+		case "excludePaths":
+			spec.ApiCfg.ExcludePaths = val
+		case "includeMethods":
+			spec.ApiCfg.IncludeMethods = val
+		case "excludeMethods":
+			spec.ApiCfg.ExcludeMethods = val
+		case "includeTags":
+			spec.ApiCfg.IncludeTags = val
+		case "excludeTags":
+			spec.ApiCfg.ExcludeTags = val
+		case "includeOperations":
+			spec.ApiCfg.IncludeOperations = val
+		case "excludeOperations":
+			spec.ApiCfg.ExcludeOperations = val
+		case "includeExtensions":
+			spec.ApiCfg.IncludeExtensions = val
+		case "excludeExtensions":
+			spec.ApiCfg.ExcludeExtensions = val
+		case "excludeDeprecated":
+			exclude, err := strconv.ParseBool(val)
+			if err != nil {
+				return spec, fmt.Errorf("invalid excludeDeprecated %q, expected true or false", val)
+			}
+			spec.ApiCfg.ExcludeDeprecated = exclude
+		case "security":
+			spec.ApiCfg.Security = val
+		case "basicAuth":
+			spec.ApiCfg.BasicAuth = val
+		case "bearerAuth":
+			spec.ApiCfg.BearerAuth = val
+		case "apiKeyAuth":
+			spec.ApiCfg.ApiKeyAuth = val
+		case "headers":
+			spec.ApiCfg.Headers = val
+		case "resourceTemplates":
+			spec.ApiCfg.ResourceTemplates = val
+		default:
+			return spec, fmt.Errorf("unknown spec setting %q", key)
+		}
+	}
+	if spec.SpecUrl == "" {
+		return spec, fmt.Errorf("spec with prefix %q is missing specUrl", spec.ApiCfg.ToolPrefix)
+	}
+	return spec, nil
+}
+
+// validateSpecUrl checks that a spec URL is an HTTP URL or an existing file:// path.
+func validateSpecUrl(specUrl string) error {
+	if strings.HasPrefix(specUrl, "http://") || strings.HasPrefix(specUrl, "https://") {
+		_, err := url.ParseRequestURI(specUrl)
+		if err != nil {
+			return fmt.Errorf("Invalid spec URL: %v", err)
+		}
+	} else if strings.HasPrefix(specUrl, "file://") {
+		filePath := strings.TrimPrefix(specUrl, "file://")
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			return fmt.Errorf("Spec file does not exist: %v", err)
+		}
+	} else {
+		return fmt.Errorf("Invalid specUrl format. Must be a valid HTTP URL or file:// path")
+	}
+	return nil
+}
+
+// validateBaseUrl checks that a base URL override, if set, is an HTTP URL.
+func validateBaseUrl(baseUrl string) error {
+	if baseUrl != "" {
+		if !strings.HasPrefix(baseUrl, "http://") && !strings.HasPrefix(baseUrl, "https://") {
+			return fmt.Errorf("baseUrl must start with http:// or https://")
+		}
+	}
+	return nil
+}
+
+// envPrefix is the prefix of environment variables that override flags, e.g. SWAGGER_MCP_SPEC_URL for --specUrl.
+const envPrefix = "SWAGGER_MCP_"
+
+// envFlagName returns the environment variable that overrides a flag.
+func envFlagName(name string) string {
+	var b strings.Builder
+	b.WriteString(envPrefix)
+	for i, r := range name {
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// applyEnvFlags sets every flag not given on the command line from its SWAGGER_MCP_* environment variable.
+// Flags that are set are recorded in set.
+func applyEnvFlags(set map[string]bool) error {
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		if set[f.Name] || err != nil {
+			return
+		}
+		if val, ok := os.LookupEnv(envFlagName(f.Name)); ok {
+			if setErr := flag.Set(f.Name, val); setErr != nil {
+				err = fmt.Errorf("Invalid value for %s: %v", envFlagName(f.Name), setErr)
+				return
+			}
+			set[f.Name] = true
+		}
+	})
+	return err
+}
+
+// splitFileSpecs returns the spec of the config file configured by --specUrl and the related flags, which is the
+// first one unless --specUrl is set on the command line or in the environment, and the specs served alongside it.
+func splitFileSpecs(specs []appconfig.SpecFile, set map[string]bool) (*appconfig.SpecFile, []appconfig.SpecFile) {
+	if len(specs) == 0 || set["specUrl"] {
+		return nil, specs
+	}
+	return &specs[0], specs[1:]
+}
+
+// applyFileFlags sets every flag not given on the command line or in the environment from the config file.
+// The primary spec of the file, if any, is the one configured by --specUrl and the related flags.
+func applyFileFlags(file *appconfig.File, primarySpec *appconfig.SpecFile, set map[string]bool) error {
+	values := map[string]string{
+		"sseAddr":    file.Transport.SseAddr,
+		"sseUrl":     file.Transport.SseUrl,
+		"sseHeaders": strings.Join(file.Transport.SseHeaders, ","),
+	}
+	if !set["sse"] {
+		values["transport"] = file.Transport.Mode
+	}
+	if file.Reload.Watch {
+		values["watch"] = "true"
+	}
+	if file.Session.Credentials {
+		values["sessionCredentials"] = "true"
+	}
+	values["pollInterval"] = file.Reload.PollInterval
+	values["shutdownTimeout"] = file.Transport.ShutdownTimeout
+	values["toolMode"] = file.Tools.Mode
+	values["coreToolsets"] = strings.Join(file.Tools.Core, ",")
+	if file.Tools.ReadOnly {
+		values["readOnly"] = "true"
+	}
+	if file.Tools.ConfirmDestructive {
+		values["confirmDestructive"] = "true"
+	}
+	if file.Tools.DryRun {
+		values["dryRun"] = "true"
+	}
+	values["validateResponses"] = file.Tools.ValidateResponses
+	values["tlsCert"] = file.Transport.TLS.Cert
+	values["tlsKey"] = file.Transport.TLS.Key
+	values["tlsClientCA"] = file.Transport.TLS.ClientCA
+	if file.Transport.TLS.RequireClientCert {
+		values["tlsRequireClientCert"] = "true"
+	}
+	values["authTokens"] = appconfig.Credentials(file.ClientAuth.Tokens)
+	values["authApiKeys"] = appconfig.Credentials(file.ClientAuth.ApiKeys)
+	values["authApiKeyHeader"] = file.ClientAuth.ApiKeyHeader
+	values["authJwks"] = file.ClientAuth.Jwt.Jwks
+	values["authIssuer"] = file.ClientAuth.Jwt.Issuer
+	values["authAudience"] = file.ClientAuth.Jwt.Audience
+	if file.ClientAuth.ClientCerts {
+		values["authClientCerts"] = "true"
+	}
+	if primarySpec != nil {
+		primary := primarySpec.SpecConfig()
+		values["specUrl"] = primary.SpecUrl
+		values["toolPrefix"] = primary.ApiCfg.ToolPrefix
+		values["baseUrl"] = primary.ApiCfg.BaseUrl
+		values["includePaths"] = primary.ApiCfg.IncludePaths
+		values["excludePaths"] = primary.ApiCfg.ExcludePaths
+		values["includeMethods"] = primary.ApiCfg.IncludeMethods
+		values["excludeMethods"] = primary.ApiCfg.ExcludeMethods
+		values["includeTags"] = primary.ApiCfg.IncludeTags
+		values["excludeTags"] = primary.ApiCfg.ExcludeTags
+		values["includeOperations"] = primary.ApiCfg.IncludeOperations
+		values["excludeOperations"] = primary.ApiCfg.ExcludeOperations
+		values["includeExtensions"] = primary.ApiCfg.IncludeExtensions
+		values["excludeExtensions"] = primary.ApiCfg.ExcludeExtensions
+		if primary.ApiCfg.ExcludeDeprecated {
+			values["excludeDeprecated"] = "true"
+		}
+		values["security"] = primary.ApiCfg.Security
+		values["basicAuth"] = primary.ApiCfg.BasicAuth
+		values["bearerAuth"] = primary.ApiCfg.BearerAuth
+		values["resourceTemplates"] = primary.ApiCfg.ResourceTemplates
+	}
+	for name, val := range values {
+		if set[name] || val == "" {
+			continue
+		}
+		if err := flag.Set(name, val); err != nil {
+			return fmt.Errorf("Invalid config value for %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// inboundAuthConfig builds the client authentication settings from the --auth* flags.
+func inboundAuthConfig(tokens, apiKeys, apiKeyHeader string, clientCerts bool, jwks, issuer, audience string) (models.InboundAuthConfig, error) {
+	cfg := models.InboundAuthConfig{
+		ApiKeyHeader: apiKeyHeader,
+		ClientCerts:  clientCerts,
+		JwksFile:     jwks,
+		Issuer:       issuer,
+		Audience:     audience,
+	}
+	var err error
+	if cfg.Tokens, err = auth.ParseCredentials(tokens); err != nil {
+		return cfg, fmt.Errorf("Invalid --authTokens: %v", err)
+	}
+	if cfg.ApiKeys, err = auth.ParseCredentials(apiKeys); err != nil {
+		return cfg, fmt.Errorf("Invalid --authApiKeys: %v", err)
+	}
+	if issuer != "" && !strings.HasPrefix(issuer, "https://") && !strings.HasPrefix(issuer, "http://") {
+		return cfg, fmt.Errorf("Invalid --authIssuer: must be an http(s) URL")
+	}
+	if audience != "" && jwks == "" && issuer == "" {
+		return cfg, fmt.Errorf("--authAudience requires --authJwks or --authIssuer")
+	}
+	return cfg, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	list := []string{}
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// redactHeaders masks the values of a name1=value1,name2=value2 header list so it can be logged.
+func redactHeaders(headers string) string {
+	parts := []string{}
+	for _, pair := range strings.Split(headers, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		parts = append(parts, name+"="+secrets.Redact(value))
+	}
+	return strings.Join(parts, ",")
+}
+
 // runMain is the testable entry point for main logic. Returns error on failure.
 func runMain() error {
 	var finalSseUrl, finalSseAddr string
 	specUrl := flag.String("specUrl", "", "URL of the Swagger JSON specification")
-	sseMode := flag.Bool("sse", false, "Run in SSE mode instead of stdio mode")
-	sseAddr := flag.String("sseAddr", "", "SSE server listen address in :Port or IP:Port format")
-	sseUrl := flag.String("sseUrl", "", "Base URL for the SSE server")
+	sseMode := flag.Bool("sse", false, "Run in SSE mode instead of stdio mode, same as --transport=sse")
+	transport := flag.String("transport", "", "Transport to serve: stdio, sse, http (Streamable HTTP), or sse,http to serve both on one listener")
+	sseAddr := flag.String("sseAddr", "", "SSE and Streamable HTTP server listen address in :Port or IP:Port format")
+	sseUrl := flag.String("sseUrl", "", "Base URL for the SSE and Streamable HTTP server")
 	baseUrl := flag.String("baseUrl", "", "Base URL for API requests")
 	includePaths := flag.String("includePaths", "", "Comma-separated list of paths or regex to include")
 	excludePaths := flag.String("excludePaths", "", "Comma-separated list of paths or regex to exclude")
 	includeMethods := flag.String("includeMethods", "", "Comma-separated list of HTTP methods to include")
 	excludeMethods := flag.String("excludeMethods", "", "Comma-separated list of HTTP methods to exclude")
+	includeTags := flag.String("includeTags", "", "Comma-separated list of tags whose operations are included")
+	excludeTags := flag.String("excludeTags", "", "Comma-separated list of tags whose operations are excluded")
+	includeOperations := flag.String("includeOperations", "", "Comma-separated list of operationIds or glob patterns (e.g. list*) to include")
+	excludeOperations := flag.String("excludeOperations", "", "Comma-separated list of operationIds or glob patterns to exclude")
+	includeExtensions := flag.String("includeExtensions", "", "Comma-separated list of x- extensions, as name or name=value, whose operations are included")
+	excludeExtensions := flag.String("excludeExtensions", "", "Comma-separated list of x- extensions, as name or name=value, whose operations are excluded (e.g. x-internal)")
+	excludeDeprecated := flag.Bool("excludeDeprecated", false, "Exclude operations marked deprecated")
 	security := flag.String("security", "", "API security type: basic, apiKey, or bearer")
-	basicAuth := flag.String("basicAuth", "", "Basic auth credentials in user:password format, used in Authorization header")
-	bearerAuth := flag.String("bearerAuth", "", "Bearer token for Authorization header")
-	apiKeyAuth := flag.String("apiKeyAuth", "", "API key auth, format: 'passAs:name=value', passAs=header/query/cookie, multiple by comma")
+	basicAuth := flag.String("basicAuth", "", "Basic auth credentials in user:password format, used in Authorization header; the whole value or the password may be an env:, file: or exec: reference")
+	bearerAuth := flag.String("bearerAuth", "", "Bearer token for Authorization header, or an env:NAME, file:/path or exec:command reference")
+	apiKeyAuth := flag.String("apiKeyAuth", "", "API key auth, format: 'passAs:name=value', passAs=header/query/cookie, multiple by comma, or an env:, file: or exec: reference")
+	allowSecretExec := flag.Bool("allowSecretExec", false, "Resolve exec:command credential references by running the command with sh -c; without it they are refused")
 	headers := flag.String("headers", "", "Additional headers to include in requests (format: name1=value1,name2=value2)")
-	sseHeaders := flag.String("sseHeaders", "", "Read headers from sse request, and pass to API request (format: name1,name2)")
+	sseHeaders := flag.String("sseHeaders", "", "Read headers from sse request, and pass to API request; only listed headers are forwarded (format: name1,name2 or from:to to rename)")
+	toolPrefix := flag.String("toolPrefix", "", "Prefix prepended to the tool names generated from --specUrl")
+	resourceTemplates := flag.String("resourceTemplates", "", "GET operations also served as MCP resource templates: comma-separated operationIds or 'GET /path', or * for all")
+	toolMode := flag.String("toolMode", mcpserver.ToolModeOperations, "How operations are exposed: operations (one tool each), meta (search_operations, describe_operation and call_operation tools, for large specs) or toolsets (tools of --coreToolsets, others enabled per session with enable_toolset)")
+	coreToolsets := flag.String("coreToolsets", "", "Comma-separated toolsets (tags) every session has with --toolMode=toolsets")
+	confirmDestructive := flag.Bool("confirmDestructive", false, "Ask the user to confirm calls of destructive operations (PUT, PATCH, DELETE) before sending them, through MCP elicitation or a confirmation token")
+	dryRun := flag.Bool("dryRun", false, "Return the request of every tool call (method, URL, headers with credentials redacted, body) as a curl command and JSON instead of sending it")
+	validateResponses := flag.String("validateResponses", mcpserver.ResponseValidationOff, "Check API responses against the schema the spec declares for their status and content type: off, log (log the problems and report them to the client as log notifications) or warn (also add them to the tool result)")
+	readOnly := flag.Bool("readOnly", false, "Only serve operations that cannot change data (GET, HEAD, OPTIONS and POST operations marked safe) and refuse any other request to the APIs")
+	watch := flag.Bool("watch", false, "Watch specs and update tools when they change (file:// specs via file events, HTTP specs via polling)")
+	pollInterval := flag.Duration("pollInterval", mcpserver.DefaultPollInterval, "How often HTTP specs are polled for changes when --watch is set")
+	sessionCredentials := flag.Bool("sessionCredentials", false, "Accept bearer tokens, API keys and cookies supplied by each SSE or Streamable HTTP client for its own session")
+	shutdownTimeout := flag.Duration("shutdownTimeout", mcpserver.DefaultShutdownTimeout, "Grace period for in-flight tool calls after SIGINT/SIGTERM or stdin EOF")
+	tlsCert := flag.String("tlsCert", "", "PEM certificate for serving SSE/HTTP over HTTPS, reloaded when the file changes")
+	tlsKey := flag.String("tlsKey", "", "PEM private key for --tlsCert, reloaded when the file changes")
+	tlsClientCA := flag.String("tlsClientCA", "", "PEM CA bundle used to verify TLS client certificates")
+	tlsRequireClientCert := flag.Bool("tlsRequireClientCert", false, "Reject clients without a certificate verified against --tlsClientCA")
+	authTokens := flag.String("authTokens", "", "Bearer tokens accepted from MCP clients on the SSE/HTTP listener (format: name1=token1,name2=token2; tokens may be env:, file: or exec: references)")
+	authApiKeys := flag.String("authApiKeys", "", "API keys accepted from MCP clients in the --authApiKeyHeader header (format: name1=key1,name2=key2; keys may be env:, file: or exec: references)")
+	authApiKeyHeader := flag.String("authApiKeyHeader", auth.DefaultApiKeyHeader, "Header carrying the API key of MCP clients")
+	authClientCerts := flag.Bool("authClientCerts", false, "Accept MCP clients presenting a verified TLS client certificate, identified by its common name")
+	authJwks := flag.String("authJwks", "", "Path to a JWKS file used to verify JWT bearer tokens of MCP clients")
+	authIssuer := flag.String("authIssuer", "", "Expected issuer of client JWTs; its keys are discovered via OpenID configuration unless --authJwks is set")
+	authAudience := flag.String("authAudience", "", "Expected audience of client JWTs")
+	configPath := flag.String("config", "", "Path to a YAML or JSON config file; SWAGGER_MCP_* env vars and flags override its values")
+	var specs specFlags
+	flag.Var(&specs, "spec", "Additional spec served by the same server, repeatable (format: prefix=name;specUrl=url;baseUrl=url;security=bearer;bearerAuth=token;...)")
 
 	flag.Parse()
 
+	// Flags given on the command line win over environment variables, which win over the config file
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if err := applyEnvFlags(set); err != nil {
+		return err
+	}
+	var filePrimary *appconfig.SpecFile
+	var fileSpecs []appconfig.SpecFile
+	var fileSessionHeaders []models.HeaderMapping
+	if *configPath != "" {
+		file, err := appconfig.Load(*configPath)
+		if err != nil {
+			return err
+		}
+		// a --specUrl given outside the file replaces the spec it configures, whose settings then apply to the
+		// first spec of the file only, served as an additional spec
+		filePrimary, fileSpecs = splitFileSpecs(file.Specs, set)
+		if err := applyFileFlags(file, filePrimary, set); err != nil {
+			return err
+		}
+		fileSessionHeaders = file.Session.Headers
+	}
+	secrets.AllowExec = *allowSecretExec
+
 	// Validate spec
-	if *specUrl == "" {
+	if *specUrl == "" && len(specs) == 0 && len(fileSpecs) == 0 {
 		return fmt.Errorf("Please provide the Swagger JSON URL or file path using the --specUrl flag")
 	}
 
-	if strings.HasPrefix(*specUrl, "http://") || strings.HasPrefix(*specUrl, "https://") {
-		_, err := url.ParseRequestURI(*specUrl)
-		if err != nil {
-			return fmt.Errorf("Invalid spec URL: %v", err)
+	if *specUrl != "" {
+		if err := validateSpecUrl(*specUrl); err != nil {
+			return err
 		}
-	} else if strings.HasPrefix(*specUrl, "file://") {
-		filePath := strings.TrimPrefix(*specUrl, "file://")
-		if _, err := os.Stat(filePath); os.IsNotExist(err) {
-			return fmt.Errorf("Spec file does not exist: %v", err)
-		}
-	} else {
-		return fmt.Errorf("Invalid specUrl format. Must be a valid HTTP URL or file:// path")
 	}
 
 	// Validate baseUrl
-	if *baseUrl != "" {
-		if !strings.HasPrefix(*baseUrl, "http://") && !strings.HasPrefix(*baseUrl, "https://") {
-			return fmt.Errorf("baseUrl must start with http:// or https://")
-		}
+	if err := validateBaseUrl(*baseUrl); err != nil {
+		return err
 	}
 
-	if *sseMode { // get final sseAddr and sseUrl
-		finalSseUrl, finalSseAddr = getSseUrlAddr(*sseUrl, *sseAddr)
+	switch *toolMode {
+	case mcpserver.ToolModeOperations, mcpserver.ToolModeMeta, mcpserver.ToolModeToolsets:
+	default:
+		return fmt.Errorf("Invalid --toolMode %q: must be operations, meta or toolsets", *toolMode)
 	}
-	swaggerSpec, err := swagger.LoadSwagger(*specUrl)
+	if *coreToolsets != "" && *toolMode != mcpserver.ToolModeToolsets {
+		return fmt.Errorf("--coreToolsets requires --toolMode=toolsets")
+	}
+	switch *validateResponses {
+	case mcpserver.ResponseValidationOff, mcpserver.ResponseValidationLog, mcpserver.ResponseValidationWarn:
+	default:
+		return fmt.Errorf("Invalid --validateResponses %q: must be off, log or warn", *validateResponses)
+	}
+
+	serveSse, serveHttp, err := parseTransport(*transport, *sseMode)
 	if err != nil {
-		return fmt.Errorf("Failed to load Swagger spec: %v", err)
+		return err
+	}
+	if (*tlsCert != "") != (*tlsKey != "") {
+		return fmt.Errorf("--tlsCert and --tlsKey must be given together")
+	}
+	if (*tlsClientCA != "" || *tlsRequireClientCert) && *tlsCert == "" {
+		return fmt.Errorf("--tlsClientCA and --tlsRequireClientCert require --tlsCert and --tlsKey")
+	}
+	if *tlsRequireClientCert && *tlsClientCA == "" {
+		return fmt.Errorf("--tlsRequireClientCert requires --tlsClientCA")
+	}
+	if *authClientCerts && *tlsClientCA == "" {
+		return fmt.Errorf("--authClientCerts requires --tlsClientCA")
+	}
+	if *tlsCert != "" && !serveSse && !serveHttp {
+		return fmt.Errorf("TLS requires --transport=sse or http")
+	}
+	if serveSse || serveHttp { // get final sseAddr and sseUrl
+		finalSseUrl, finalSseAddr = getSseUrlAddr(*sseUrl, *sseAddr, *tlsCert != "")
 	}
-	swagger.ExtractSwagger(swaggerSpec)
 
 	config := models.Config{
 		SpecUrl: *specUrl,
 		SseCfg: models.SseConfig{
-			SseMode: *sseMode,
-			SseAddr: finalSseAddr,
-			SseUrl:  finalSseUrl,
+			SseMode:  serveSse,
+			HttpMode: serveHttp,
+			SseAddr:  finalSseAddr,
+			SseUrl:   finalSseUrl,
 		},
 		ApiCfg: models.ApiConfig{
 			BaseUrl:        *baseUrl,
@@ -133,11 +530,106 @@ func runMain() error {
 			BearerAuth:     *bearerAuth,
 			Headers:        *headers,
 			SseHeaders:     *sseHeaders,
+			ToolPrefix:     *toolPrefix,
+
+			IncludeTags:        *includeTags,
+			ExcludeTags:        *excludeTags,
+			IncludeOperations:  *includeOperations,
+			ExcludeOperations:  *excludeOperations,
+			IncludeExtensions:  *includeExtensions,
+			ExcludeExtensions:  *excludeExtensions,
+			ExcludeDeprecated:  *excludeDeprecated,
+			ReadOnly:           *readOnly,
+			ConfirmDestructive: *confirmDestructive,
+			DryRun:             *dryRun,
+			ValidateResponses:  *validateResponses,
+
+			ResourceTemplates: *resourceTemplates,
 		},
+		ReloadCfg: models.ReloadConfig{
+			Watch:        *watch,
+			PollInterval: *pollInterval,
+		},
+		SessionCfg: models.SessionConfig{
+			Credentials: *sessionCredentials,
+			Headers:     fileSessionHeaders,
+		},
+		ShutdownTimeout: *shutdownTimeout,
+		ToolMode:        *toolMode,
+		CoreToolsets:    splitList(*coreToolsets),
+		TLSCfg: models.TLSConfig{
+			CertFile:          *tlsCert,
+			KeyFile:           *tlsKey,
+			ClientCAFile:      *tlsClientCA,
+			RequireClientCert: *tlsRequireClientCert,
+		},
+	}
+	if config.AuthCfg, err = inboundAuthConfig(*authTokens, *authApiKeys, *authApiKeyHeader, *authClientCerts, *authJwks, *authIssuer, *authAudience); err != nil {
+		return err
+	}
+	if !serveSse && !serveHttp && (len(config.AuthCfg.Tokens) > 0 || len(config.AuthCfg.ApiKeys) > 0 || config.AuthCfg.ClientCerts || config.AuthCfg.JwksFile != "" || config.AuthCfg.Issuer != "") {
+		return fmt.Errorf("Client authentication requires --transport=sse or http")
+	}
+	if err := mcpserver.ValidateHeaderMappings(append(mcpserver.ParseHeaderMappings(*sseHeaders), fileSessionHeaders...)); err != nil {
+		return fmt.Errorf("Invalid session headers: %v", err)
+	}
+
+	if filePrimary != nil {
+		// structured settings of the primary spec that have no flag equivalent
+		primary := filePrimary.SpecConfig()
+		config.ApiCfg.HeaderValues = primary.ApiCfg.HeaderValues
+		config.ApiCfg.ApiKeys = primary.ApiCfg.ApiKeys
+		config.ApiCfg.Overrides = primary.ApiCfg.Overrides
+		config.ApiCfg.Prompts = primary.ApiCfg.Prompts
+		config.ApiCfg.Lookups = primary.ApiCfg.Lookups
 	}
 
-	fmt.Printf("Starting server with specUrl: %s, SSE mode: %v, SSE URL: %s, SSE Addr: %s, Base URL: %s, Include Paths: %s, Exclude Paths: %s, Include Methods: %s, Exclude Methods: %s, Security: %s, BasicAuth: %s, ApiKeyAuth: %s, BearerAuth: %s, Headers: %s, SSE Headers: %s\n",
-		config.SpecUrl, config.SseCfg.SseMode, config.SseCfg.SseUrl, config.SseCfg.SseAddr, config.ApiCfg.BaseUrl, config.ApiCfg.IncludePaths, config.ApiCfg.ExcludePaths, config.ApiCfg.IncludeMethods, config.ApiCfg.ExcludeMethods, config.ApiCfg.Security, config.ApiCfg.BasicAuth, config.ApiCfg.ApiKeyAuth, config.ApiCfg.BearerAuth, config.ApiCfg.Headers, config.ApiCfg.SseHeaders)
+	if len(specs) > 0 || len(fileSpecs) > 0 {
+		if *specUrl != "" {
+			config.Specs = append(config.Specs, models.SpecConfig{SpecUrl: *specUrl, ApiCfg: config.ApiCfg})
+		}
+		for _, fileSpec := range fileSpecs {
+			config.Specs = append(config.Specs, fileSpec.SpecConfig())
+		}
+		prefixes := map[string]bool{}
+		for _, value := range specs {
+			spec, err := parseSpec(value)
+			if err != nil {
+				return fmt.Errorf("Invalid --spec: %v", err)
+			}
+			if err := validateSpecUrl(spec.SpecUrl); err != nil {
+				return err
+			}
+			if err := validateBaseUrl(spec.ApiCfg.BaseUrl); err != nil {
+				return err
+			}
+			config.Specs = append(config.Specs, spec)
+		}
+		for i := range config.Specs {
+			// read-only mode, confirmations, dry runs and response validation cover every spec of the server
+			config.Specs[i].ApiCfg.ReadOnly = *readOnly
+			config.Specs[i].ApiCfg.ConfirmDestructive = *confirmDestructive
+			config.Specs[i].ApiCfg.DryRun = *dryRun
+			config.Specs[i].ApiCfg.ValidateResponses = *validateResponses
+		}
+		for _, spec := range config.Specs {
+			if prefixes[spec.ApiCfg.ToolPrefix] {
+				return fmt.Errorf("Each spec must have a unique tool prefix, %q is used more than once", spec.ApiCfg.ToolPrefix)
+			}
+			prefixes[spec.ApiCfg.ToolPrefix] = true
+		}
+		log.Printf("Starting server with %d specs, SSE mode: %v, HTTP mode: %v, SSE URL: %s, SSE Addr: %s", len(config.Specs), config.SseCfg.SseMode, config.SseCfg.HttpMode, config.SseCfg.SseUrl, config.SseCfg.SseAddr)
+		return mcpserver.CreateMultiSpecServer(config)
+	}
+
+	swaggerSpec, err := swagger.LoadSwagger(*specUrl)
+	if err != nil {
+		return fmt.Errorf("Failed to load Swagger spec: %v", err)
+	}
+	swagger.ExtractSwagger(swaggerSpec)
+
+	fmt.Printf("Starting server with specUrl: %s, SSE mode: %v, HTTP mode: %v, SSE URL: %s, SSE Addr: %s, Base URL: %s, Include Paths: %s, Exclude Paths: %s, Include Methods: %s, Exclude Methods: %s, Security: %s, BasicAuth: %s, ApiKeyAuth: %s, BearerAuth: %s, Headers: %s, SSE Headers: %s\n",
+		config.SpecUrl, config.SseCfg.SseMode, config.SseCfg.HttpMode, config.SseCfg.SseUrl, config.SseCfg.SseAddr, config.ApiCfg.BaseUrl, config.ApiCfg.IncludePaths, config.ApiCfg.ExcludePaths, config.ApiCfg.IncludeMethods, config.ApiCfg.ExcludeMethods, config.ApiCfg.Security, secrets.Redact(config.ApiCfg.BasicAuth), secrets.RedactApiKeyAuth(config.ApiCfg.ApiKeyAuth), secrets.Redact(config.ApiCfg.BearerAuth), redactHeaders(config.ApiCfg.Headers), config.ApiCfg.SseHeaders)
 	mcpserver.CreateServer(swaggerSpec, config)
 	return nil
 }
@@ -4,21 +4,28 @@ import (
 	"flag"
 	"os"
 	"testing"
+
+	appconfig "github.com/danishjsheikh/swagger-mcp/app/config"
 )
 
 func Test_getSseUrlAddr(t *testing.T) {
 	cases := []struct {
 		sseUrl, sseAddr, wantUrl, wantAddr string
-		shouldPanic                        bool
+		useTLS, shouldPanic                bool
 	}{
-		{"", ":8080", "http://localhost:8080", ":8080", false},
-		{"", "127.0.0.1:9000", "http://127.0.0.1:9000", "127.0.0.1:9000", false},
-		{"http://foo.com:1234", "", "http://foo.com:1234", "foo.com:1234", false},
-		{"https://bar.com", "", "https://bar.com", "bar.com:443", false},
-		{"http://baz.com", "", "http://baz.com", "baz.com:80", false},
-		{"", "", "", "", true},
-		{"", "badaddr", "", "", true},
-		{"ftp://bad.com", "", "", "", true},
+		{"", ":8080", "http://localhost:8080", ":8080", false, false},
+		{"", "127.0.0.1:9000", "http://127.0.0.1:9000", "127.0.0.1:9000", false, false},
+		{"http://foo.com:1234", "", "http://foo.com:1234", "foo.com:1234", false, false},
+		{"https://bar.com", "", "https://bar.com", "bar.com:443", false, false},
+		{"http://baz.com", "", "http://baz.com", "baz.com:80", false, false},
+		{"", ":8443", "https://localhost:8443", ":8443", true, false},
+		{"", "127.0.0.1:9443", "https://127.0.0.1:9443", "127.0.0.1:9443", true, false},
+		{"", "", "https://localhost:8080", "localhost:8080", true, false},
+		{"https://bar.com", "", "https://bar.com", "bar.com:443", true, false},
+		{"http://foo.com:1234", "", "", "", true, true},
+		{"", "", "", "", false, true},
+		{"", "badaddr", "", "", false, true},
+		{"ftp://bad.com", "", "", "", false, true},
 	}
 	for _, c := range cases {
 		gotUrl, gotAddr := "", ""
@@ -30,10 +37,10 @@ func Test_getSseUrlAddr(t *testing.T) {
 					}
 				}
 			}()
-			gotUrl, gotAddr = getSseUrlAddr(c.sseUrl, c.sseAddr)
+			gotUrl, gotAddr = getSseUrlAddr(c.sseUrl, c.sseAddr, c.useTLS)
 		}()
 		if !c.shouldPanic && (gotUrl != c.wantUrl || gotAddr != c.wantAddr) {
-			t.Errorf("getSseUrlAddr(%q, %q) = (%q, %q), want (%q, %q)", c.sseUrl, c.sseAddr, gotUrl, gotAddr, c.wantUrl, c.wantAddr)
+			t.Errorf("getSseUrlAddr(%q, %q, %v) = (%q, %q), want (%q, %q)", c.sseUrl, c.sseAddr, c.useTLS, gotUrl, gotAddr, c.wantUrl, c.wantAddr)
 		}
 	}
 }
@@ -54,3 +61,89 @@ func Test_runMain_missingSpecUrl(t *testing.T) {
 		t.Errorf("Expected error for missing specUrl, got: %v", err)
 	}
 }
+
+func Test_parseSpec(t *testing.T) {
+	spec, err := parseSpec("prefix=users; specUrl=https://users.example.com/swagger.json; baseUrl=https://users.example.com; includePaths=/users/.*,/groups; security=bearer; bearerAuth=abc; resourceTemplates=getUser,GET /groups")
+	if err != nil {
+		t.Fatalf("parseSpec returned error: %v", err)
+	}
+	if spec.SpecUrl != "https://users.example.com/swagger.json" || spec.ApiCfg.ToolPrefix != "users" {
+		t.Errorf("unexpected spec: %+v", spec)
+	}
+	if spec.ApiCfg.IncludePaths != "/users/.*,/groups" || spec.ApiCfg.BearerAuth != "abc" || spec.ApiCfg.Security != "bearer" || spec.ApiCfg.ResourceTemplates != "getUser,GET /groups" {
+		t.Errorf("unexpected api config: %+v", spec.ApiCfg)
+	}
+
+	spec, err = parseSpec("specUrl=file:///x; includeTags=users,groups; excludeOperations=*Admin; excludeExtensions=x-internal; excludeDeprecated=true")
+	if err != nil || spec.ApiCfg.IncludeTags != "users,groups" || spec.ApiCfg.ExcludeOperations != "*Admin" || spec.ApiCfg.ExcludeExtensions != "x-internal" || !spec.ApiCfg.ExcludeDeprecated {
+		t.Errorf("unexpected operation filters: %+v, %v", spec.ApiCfg, err)
+	}
+
+	for _, bad := range []string{"prefix=users", "specUrl", "specUrl=file:///x;color=red", "specUrl=file:///x;excludeDeprecated=maybe"} {
+		if _, err := parseSpec(bad); err == nil {
+			t.Errorf("parseSpec(%q) expected error", bad)
+		}
+	}
+}
+
+func Test_envFlagName(t *testing.T) {
+	cases := map[string]string{
+		"specUrl":    "SWAGGER_MCP_SPEC_URL",
+		"sse":        "SWAGGER_MCP_SSE",
+		"apiKeyAuth": "SWAGGER_MCP_API_KEY_AUTH",
+		"config":     "SWAGGER_MCP_CONFIG",
+	}
+	for name, want := range cases {
+		if got := envFlagName(name); got != want {
+			t.Errorf("envFlagName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func Test_splitFileSpecs(t *testing.T) {
+	specs := []appconfig.SpecFile{{SpecUrl: "https://a.example/spec.json", Prefix: "a"}, {SpecUrl: "https://b.example/spec.json", Prefix: "b"}}
+
+	primary, additional := splitFileSpecs(specs, map[string]bool{})
+	if primary == nil || primary.Prefix != "a" || len(additional) != 1 || additional[0].Prefix != "b" {
+		t.Errorf("expected the first spec to be primary, got %+v, %+v", primary, additional)
+	}
+	// --specUrl replaces the primary spec, the first spec of the file is then served on its own
+	primary, additional = splitFileSpecs(specs, map[string]bool{"specUrl": true})
+	if primary != nil || len(additional) != 2 {
+		t.Errorf("expected no primary spec, got %+v, %+v", primary, additional)
+	}
+	if primary, additional = splitFileSpecs(nil, map[string]bool{}); primary != nil || len(additional) != 0 {
+		t.Errorf("expected no specs, got %+v, %+v", primary, additional)
+	}
+}
+
+func Test_redactHeaders(t *testing.T) {
+	if got := redactHeaders("X-Token=abc, X-Ref=env:TOKEN"); got != "X-Token=<redacted>,X-Ref=env:TOKEN" {
+		t.Errorf("redactHeaders = %q", got)
+	}
+}
+
+func Test_parseTransport(t *testing.T) {
+	cases := []struct {
+		transport         string
+		sse               bool
+		wantSse, wantHttp bool
+		wantErr           bool
+	}{
+		{"", false, false, false, false},
+		{"", true, true, false, false},
+		{"stdio", false, false, false, false},
+		{"sse", false, true, false, false},
+		{"http", false, false, true, false},
+		{"sse, http", true, true, true, false},
+		{"http", true, false, false, true},
+		{"stdio,http", false, false, false, true},
+		{"websocket", false, false, false, true},
+	}
+	for _, c := range cases {
+		sse, http, err := parseTransport(c.transport, c.sse)
+		if (err != nil) != c.wantErr || sse != c.wantSse || http != c.wantHttp {
+			t.Errorf("parseTransport(%q, %v) = %v, %v, %v", c.transport, c.sse, sse, http, err)
+		}
+	}
+}